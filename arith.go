@@ -0,0 +1,204 @@
+package primitive
+
+import (
+	"math"
+	"math/bits"
+)
+
+// SumNoOverflow returns true if x + y does not overflow a uint64.
+func SumNoOverflow(x uint64, y uint64) bool {
+	return x+y >= x
+}
+
+// SumAssumeNoOverflow returns x + y, assuming that the addition does not
+// overflow.
+//
+// The proof gets to assume the sum is mathematically exact; in Go, an
+// overflowing sum panics.
+func SumAssumeNoOverflow(x uint64, y uint64) uint64 {
+	Assume(SumNoOverflow(x, y))
+	return x + y
+}
+
+// MulNoOverflow returns true if x * y does not overflow a uint64.
+func MulNoOverflow(x uint64, y uint64) bool {
+	hi, _ := bits.Mul64(x, y)
+	return hi == 0
+}
+
+// MulAssumeNoOverflow returns x * y, assuming that the multiplication does
+// not overflow.
+func MulAssumeNoOverflow(x uint64, y uint64) uint64 {
+	Assume(MulNoOverflow(x, y))
+	return x * y
+}
+
+// SubNoUnderflow returns true if x - y does not underflow, that is, if
+// y <= x.
+func SubNoUnderflow(x uint64, y uint64) bool {
+	return y <= x
+}
+
+// SubAssumeNoUnderflow returns x - y, assuming that y <= x.
+func SubAssumeNoUnderflow(x uint64, y uint64) uint64 {
+	Assume(SubNoUnderflow(x, y))
+	return x - y
+}
+
+// SatAdd returns x + y, or math.MaxUint64 if the sum overflows.
+//
+// Modeled as a pure function: min(x + y, 2^64 - 1).
+func SatAdd(x uint64, y uint64) uint64 {
+	if !SumNoOverflow(x, y) {
+		return math.MaxUint64
+	}
+	return x + y
+}
+
+// SatSub returns x - y, or 0 if y > x.
+//
+// Modeled as a pure function: max(x - y, 0).
+func SatSub(x uint64, y uint64) uint64 {
+	if !SubNoUnderflow(x, y) {
+		return 0
+	}
+	return x - y
+}
+
+// SatMul returns x * y, or math.MaxUint64 if the product overflows.
+//
+// Modeled as a pure function: min(x * y, 2^64 - 1).
+func SatMul(x uint64, y uint64) uint64 {
+	if !MulNoOverflow(x, y) {
+		return math.MaxUint64
+	}
+	return x * y
+}
+
+// MinUint64 returns the smaller of x and y.
+//
+// Modeled as a pure function.
+func MinUint64(x uint64, y uint64) uint64 {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+// MaxUint64 returns the larger of x and y.
+//
+// Modeled as a pure function.
+func MaxUint64(x uint64, y uint64) uint64 {
+	if x > y {
+		return x
+	}
+	return y
+}
+
+// Clamp returns x limited to the range [lo, hi].
+//
+// Assumes lo <= hi. Modeled as a pure function.
+func Clamp(x uint64, lo uint64, hi uint64) uint64 {
+	Assume(lo <= hi)
+	return MinUint64(MaxUint64(x, lo), hi)
+}
+
+// PopCount64 returns the number of one bits in x.
+func PopCount64(x uint64) uint64 {
+	return uint64(bits.OnesCount64(x))
+}
+
+// LeadingZeros64 returns the number of leading zero bits in x (64 if x = 0).
+func LeadingZeros64(x uint64) uint64 {
+	return uint64(bits.LeadingZeros64(x))
+}
+
+// TrailingZeros64 returns the number of trailing zero bits in x (64 if
+// x = 0).
+func TrailingZeros64(x uint64) uint64 {
+	return uint64(bits.TrailingZeros64(x))
+}
+
+// Log2Floor returns the base-2 logarithm of x, rounded down: the position of
+// its highest one bit.
+//
+// Assumes x > 0.
+func Log2Floor(x uint64) uint64 {
+	Assume(x > 0)
+	return uint64(63 - bits.LeadingZeros64(x))
+}
+
+// 128-bit arithmetic, with 128-bit values represented as (hi, lo) pairs of
+// uint64s whose value is hi*2^64 + lo.
+
+// Mul64To128 returns the full 128-bit product of x and y.
+func Mul64To128(x uint64, y uint64) (hi uint64, lo uint64) {
+	return bits.Mul64(x, y)
+}
+
+// Add128 returns (xhi, xlo) + (yhi, ylo), wrapping around modulo 2^128.
+func Add128(xhi uint64, xlo uint64, yhi uint64, ylo uint64) (hi uint64, lo uint64) {
+	lo, carry := bits.Add64(xlo, ylo, 0)
+	hi, _ = bits.Add64(xhi, yhi, carry)
+	return hi, lo
+}
+
+// Div128By64 divides the 128-bit value (hi, lo) by y, returning the quotient
+// and remainder.
+//
+// Assumes y != 0 and hi < y, so that the quotient fits in 64 bits.
+func Div128By64(hi uint64, lo uint64, y uint64) (quo uint64, rem uint64) {
+	Assume(y != 0 && hi < y)
+	return bits.Div64(hi, lo, y)
+}
+
+// PrefixSum returns the inclusive prefix sums of xs: out[i] is the sum of
+// xs[0] through xs[i], and out has the same length as xs.
+//
+// Returns false (and a nil slice) if any partial sum overflows a uint64.
+func PrefixSum(xs []uint64) ([]uint64, bool) {
+	out := make([]uint64, len(xs))
+	var sum uint64
+	for i, x := range xs {
+		if !SumNoOverflow(sum, x) {
+			return nil, false
+		}
+		sum += x
+		out[i] = sum
+	}
+	return out, true
+}
+
+// ToUInt32 narrows x to a uint32, returning false if x > math.MaxUint32
+// instead of silently truncating.
+//
+// As with SumNoOverflow and SumAssumeNoOverflow, there are two forms: this
+// checked one, where callers branch on the result, and AssumeToUInt32 for
+// callers that can prove x fits.
+func ToUInt32(x uint64) (uint32, bool) {
+	if x > math.MaxUint32 {
+		return 0, false
+	}
+	return uint32(x), true
+}
+
+// AssumeToUInt32 narrows x to a uint32, assuming x <= math.MaxUint32.
+func AssumeToUInt32(x uint64) uint32 {
+	Assume(x <= math.MaxUint32)
+	return uint32(x)
+}
+
+// ToUInt16 narrows x to a uint16, returning false if x > math.MaxUint16
+// instead of silently truncating.
+func ToUInt16(x uint64) (uint16, bool) {
+	if x > math.MaxUint16 {
+		return 0, false
+	}
+	return uint16(x), true
+}
+
+// AssumeToUInt16 narrows x to a uint16, assuming x <= math.MaxUint16.
+func AssumeToUInt16(x uint64) uint16 {
+	Assume(x <= math.MaxUint16)
+	return uint16(x)
+}
@@ -0,0 +1,169 @@
+package primitive
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSumNoOverflow(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(SumNoOverflow(1, 2))
+	assert.True(SumNoOverflow(math.MaxUint64, 0))
+	assert.True(SumNoOverflow(math.MaxUint64-5, 5))
+	assert.False(SumNoOverflow(math.MaxUint64-5, 6))
+	assert.False(SumNoOverflow(math.MaxUint64, math.MaxUint64))
+
+	assert.Equal(uint64(math.MaxUint64), SumAssumeNoOverflow(math.MaxUint64-5, 5))
+	assert.Panics(func() { SumAssumeNoOverflow(math.MaxUint64, 1) })
+}
+
+func TestMulNoOverflow(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(MulNoOverflow(0, math.MaxUint64))
+	assert.True(MulNoOverflow(1<<32-1, 1<<32+1))
+	assert.False(MulNoOverflow(1<<32, 1<<32))
+	assert.True(MulNoOverflow(math.MaxUint64/4096, 4096))
+	assert.False(MulNoOverflow(math.MaxUint64/4096+1, 4096))
+
+	assert.Equal(uint64(12*4096), MulAssumeNoOverflow(12, 4096))
+	assert.Panics(func() { MulAssumeNoOverflow(1<<40, 1<<40) })
+}
+
+func TestSubNoUnderflow(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(SubNoUnderflow(5, 5))
+	assert.True(SubNoUnderflow(5, 0))
+	assert.False(SubNoUnderflow(5, 6))
+
+	assert.Equal(uint64(2), SubAssumeNoUnderflow(5, 3))
+	assert.Panics(func() { SubAssumeNoUnderflow(0, 1) })
+}
+
+func TestSaturating(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(uint64(5), SatAdd(2, 3))
+	assert.Equal(uint64(math.MaxUint64), SatAdd(math.MaxUint64-1, 1))
+	assert.Equal(uint64(math.MaxUint64), SatAdd(math.MaxUint64-1, 2))
+
+	assert.Equal(uint64(1), SatSub(3, 2))
+	assert.Equal(uint64(0), SatSub(3, 3))
+	assert.Equal(uint64(0), SatSub(3, 4))
+
+	assert.Equal(uint64(6), SatMul(2, 3))
+	assert.Equal(uint64(0), SatMul(0, math.MaxUint64))
+	assert.Equal(uint64(math.MaxUint64), SatMul(1<<32, 1<<32))
+}
+
+func TestMinMaxClamp(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(uint64(2), MinUint64(2, 3))
+	assert.Equal(uint64(2), MinUint64(3, 2))
+	assert.Equal(uint64(3), MaxUint64(2, 3))
+	assert.Equal(uint64(3), MaxUint64(3, 2))
+	assert.Equal(uint64(4), MaxUint64(4, 4))
+
+	assert.Equal(uint64(5), Clamp(5, 1, 10))
+	assert.Equal(uint64(1), Clamp(0, 1, 10))
+	assert.Equal(uint64(10), Clamp(11, 1, 10))
+	assert.Equal(uint64(7), Clamp(3, 7, 7))
+	assert.Panics(func() { Clamp(3, 10, 1) })
+}
+
+func TestBitPrimitives(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(uint64(0), PopCount64(0))
+	assert.Equal(uint64(3), PopCount64(0b1011))
+	assert.Equal(uint64(64), PopCount64(math.MaxUint64))
+
+	assert.Equal(uint64(64), LeadingZeros64(0))
+	assert.Equal(uint64(63), LeadingZeros64(1))
+	assert.Equal(uint64(0), LeadingZeros64(1<<63))
+
+	assert.Equal(uint64(64), TrailingZeros64(0))
+	assert.Equal(uint64(3), TrailingZeros64(0b1000))
+
+	assert.Equal(uint64(0), Log2Floor(1))
+	assert.Equal(uint64(1), Log2Floor(3))
+	assert.Equal(uint64(12), Log2Floor(4096))
+	assert.Equal(uint64(12), Log2Floor(8191))
+	assert.Equal(uint64(63), Log2Floor(math.MaxUint64))
+	assert.Panics(func() { Log2Floor(0) })
+}
+
+func Test128Bit(t *testing.T) {
+	assert := assert.New(t)
+	hi, lo := Mul64To128(math.MaxUint64, math.MaxUint64)
+	assert.Equal(uint64(math.MaxUint64-1), hi)
+	assert.Equal(uint64(1), lo)
+
+	hi, lo = Add128(0, math.MaxUint64, 0, 1)
+	assert.Equal(uint64(1), hi, "carry")
+	assert.Equal(uint64(0), lo)
+	hi, lo = Add128(math.MaxUint64, math.MaxUint64, 0, 1)
+	assert.Equal(uint64(0), hi, "wraps modulo 2^128")
+	assert.Equal(uint64(0), lo)
+
+	hi, lo = Mul64To128(1<<40+3, 1<<50+7)
+	quo, rem := Div128By64(hi, lo, 1<<50+7)
+	assert.Equal(uint64(1<<40+3), quo)
+	assert.Equal(uint64(0), rem)
+	quo, rem = Div128By64(0, 17, 5)
+	assert.Equal(uint64(3), quo)
+	assert.Equal(uint64(2), rem)
+
+	assert.Panics(func() { Div128By64(5, 0, 5) }, "quotient overflow")
+	assert.Panics(func() { Div128By64(0, 1, 0) }, "division by zero")
+}
+
+func TestPrefixSum(t *testing.T) {
+	assert := assert.New(t)
+	out, ok := PrefixSum(nil)
+	assert.True(ok)
+	assert.Empty(out)
+
+	out, ok = PrefixSum([]uint64{3, 0, 4, 10})
+	assert.True(ok)
+	assert.Equal([]uint64{3, 3, 7, 17}, out)
+}
+
+func TestPrefixSumOverflow(t *testing.T) {
+	_, ok := PrefixSum([]uint64{1, math.MaxUint64 - 1, 1, 5})
+	assert.False(t, ok)
+
+	out, ok := PrefixSum([]uint64{1, math.MaxUint64 - 1})
+	assert.True(t, ok)
+	assert.Equal(t, []uint64{1, math.MaxUint64}, out)
+}
+
+func TestToUInt32(t *testing.T) {
+	assert := assert.New(t)
+	x, ok := ToUInt32(17)
+	assert.True(ok)
+	assert.Equal(uint32(17), x)
+
+	x, ok = ToUInt32(math.MaxUint32)
+	assert.True(ok)
+	assert.Equal(uint32(math.MaxUint32), x)
+
+	_, ok = ToUInt32(math.MaxUint32 + 1)
+	assert.False(ok)
+}
+
+func TestAssumeToUInt32(t *testing.T) {
+	assert.Equal(t, uint32(math.MaxUint32), AssumeToUInt32(math.MaxUint32))
+	assert.Panics(t, func() { AssumeToUInt32(math.MaxUint32 + 1) })
+}
+
+func TestToUInt16(t *testing.T) {
+	assert := assert.New(t)
+	x, ok := ToUInt16(math.MaxUint16)
+	assert.True(ok)
+	assert.Equal(uint16(math.MaxUint16), x)
+	_, ok = ToUInt16(math.MaxUint16 + 1)
+	assert.False(ok)
+
+	assert.Equal(uint16(300), AssumeToUInt16(300))
+	assert.Panics(func() { AssumeToUInt16(math.MaxUint16 + 1) })
+}
@@ -0,0 +1,124 @@
+package async_disk
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/goose-lang/primitive/disk"
+)
+
+// AsyncMemDisk is an in-memory disk with the asynchronous durability the
+// async disk model assumes: a write is immediately visible to reads, but is
+// only durable once a Barrier returns. Crash simulates losing power, discarding
+// every write not yet made durable.
+//
+// Unlike MemDisk (which persists every write immediately), this lets tests
+// exercise code that forgets a Barrier.
+type AsyncMemDisk struct {
+	m       *sync.Mutex
+	durable disk.MemDisk
+	// pending holds writes issued since the last Barrier, by address.
+	pending map[uint64]Block
+}
+
+var _ Disk = AsyncMemDisk{}
+
+// NewAsyncMemDisk creates a zero-filled disk of numBlocks blocks with no
+// pending writes.
+func NewAsyncMemDisk(numBlocks uint64) AsyncMemDisk {
+	return AsyncMemDisk{
+		m:       new(sync.Mutex),
+		durable: disk.NewMemDisk(numBlocks),
+		pending: make(map[uint64]Block),
+	}
+}
+
+func (d AsyncMemDisk) ReadTo(a uint64, buf Block) {
+	if uint64(len(buf)) != BlockSize {
+		panic("buffer is not block-sized")
+	}
+	d.m.Lock()
+	defer d.m.Unlock()
+	if v, ok := d.pending[a]; ok {
+		copy(buf, v)
+		return
+	}
+	d.durable.ReadTo(a, buf)
+}
+
+func (d AsyncMemDisk) Read(a uint64) Block {
+	buf := make(Block, BlockSize)
+	d.ReadTo(a, buf)
+	return buf
+}
+
+func (d AsyncMemDisk) Write(a uint64, v Block) {
+	if uint64(len(v)) != BlockSize {
+		panic(fmt.Errorf("v is not block-sized (%d bytes)", len(v)))
+	}
+	d.m.Lock()
+	defer d.m.Unlock()
+	if a >= d.durable.Size() {
+		panic(fmt.Errorf("out-of-bounds write at %v", a))
+	}
+	d.pending[a] = append(Block(nil), v...)
+}
+
+// Trim zeroes block a. Like a write, the trim is pending until the next
+// Barrier.
+func (d AsyncMemDisk) Trim(a uint64) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if a >= d.durable.Size() {
+		panic(fmt.Errorf("out-of-bounds trim at %v", a))
+	}
+	d.pending[a] = make(Block, BlockSize)
+}
+
+// Prefetch does nothing, since the whole disk is already in memory.
+func (d AsyncMemDisk) Prefetch(a uint64, count uint64) {}
+
+func (d AsyncMemDisk) Size() uint64 {
+	return d.durable.Size()
+}
+
+// Resize changes the size of the disk. The new size takes effect (and is
+// durable) immediately; pending writes past the new end are discarded.
+func (d AsyncMemDisk) Resize(newSize uint64) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	for a := range d.pending {
+		if a >= newSize {
+			delete(d.pending, a)
+		}
+	}
+	d.durable.Resize(newSize)
+}
+
+// Barrier makes all pending writes durable.
+func (d AsyncMemDisk) Barrier() {
+	d.m.Lock()
+	defer d.m.Unlock()
+	for a, v := range d.pending {
+		d.durable.Write(a, v)
+	}
+	clear(d.pending)
+}
+
+// Pending returns the number of blocks written since the last Barrier.
+func (d AsyncMemDisk) Pending() uint64 {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return uint64(len(d.pending))
+}
+
+// Crash simulates a crash: every write since the last Barrier is lost, and
+// the disk's contents revert to what was last made durable. The disk remains
+// usable afterward, as if after a reboot.
+func (d AsyncMemDisk) Crash() {
+	d.m.Lock()
+	defer d.m.Unlock()
+	clear(d.pending)
+}
+
+func (d AsyncMemDisk) Close() {}
@@ -0,0 +1,64 @@
+package async_disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mkBlock(x byte) Block {
+	b := make(Block, BlockSize)
+	b[0] = x
+	return b
+}
+
+func TestAsyncMemDiskCrashLosesPending(t *testing.T) {
+	assert := assert.New(t)
+	d := NewAsyncMemDisk(10)
+	d.Write(1, mkBlock(1))
+	d.Barrier()
+	d.Write(1, mkBlock(2))
+	d.Write(2, mkBlock(3))
+	assert.Equal(mkBlock(2), d.Read(1), "pending writes are visible")
+	assert.Equal(uint64(2), d.Pending())
+
+	d.Crash()
+	assert.Equal(mkBlock(1), d.Read(1), "durable contents survive")
+	assert.Equal(mkBlock(0), d.Read(2), "unbarriered write is lost")
+	assert.Equal(uint64(0), d.Pending())
+}
+
+func TestAsyncMemDiskBarrier(t *testing.T) {
+	d := NewAsyncMemDisk(10)
+	v := mkBlock(5)
+	d.Write(3, v)
+	v[0] = 6
+	d.Barrier()
+	d.Crash()
+	assert.Equal(t, mkBlock(5), d.Read(3), "Write should not retain blocks")
+}
+
+func TestAsyncMemDiskOob(t *testing.T) {
+	d := NewAsyncMemDisk(10)
+	assert.Panics(t, func() { d.Write(10, mkBlock(1)) })
+	assert.Panics(t, func() { d.Read(10) })
+}
+
+func TestAsyncMemDiskResize(t *testing.T) {
+	d := NewAsyncMemDisk(10)
+	d.Write(8, mkBlock(1))
+	d.Resize(5)
+	d.Resize(10)
+	d.Barrier()
+	assert.Equal(t, mkBlock(0), d.Read(8))
+}
+
+func TestAsyncMemDiskTrim(t *testing.T) {
+	d := NewAsyncMemDisk(10)
+	d.Write(1, mkBlock(1))
+	d.Barrier()
+	d.Trim(1)
+	assert.Equal(t, mkBlock(0), d.Read(1))
+	d.Crash()
+	assert.Equal(t, mkBlock(1), d.Read(1), "trim is lost like a pending write")
+}
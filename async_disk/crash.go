@@ -0,0 +1,172 @@
+package async_disk
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/goose-lang/primitive"
+)
+
+// CrashDisk wraps a Disk to simulate crashes with the nondeterminism of the
+// async disk model: after a crash, each block holds either its contents as of
+// the last Barrier or the value of any one of the writes issued to it since.
+//
+// Writes since the last Barrier are buffered by the CrashDisk (and visible to
+// its reads); Barrier applies them to the underlying disk. Crash picks, for
+// each block, which of its pending writes (if any) survives, using a
+// generator seeded at construction so that a failing crash state can be
+// reproduced. After a crash every operation other than Close panics; recovery
+// should reopen the underlying disk.
+type CrashDisk struct {
+	d       Disk
+	m       *sync.Mutex
+	rng     *primitive.Rand
+	pending map[uint64][]Block
+	crashed *bool
+}
+
+var _ Disk = CrashDisk{}
+
+// NewCrashDisk wraps d, choosing crash states with a generator seeded by
+// seed.
+func NewCrashDisk(d Disk, seed uint64) CrashDisk {
+	return CrashDisk{
+		d:       d,
+		m:       new(sync.Mutex),
+		rng:     primitive.NewRand(seed),
+		pending: make(map[uint64][]Block),
+		crashed: new(bool),
+	}
+}
+
+// checkLive panics if the disk has crashed; the caller must hold d.m.
+func (d CrashDisk) checkLive() {
+	if *d.crashed {
+		panic("CrashDisk used after crash")
+	}
+}
+
+func (d CrashDisk) ReadTo(a uint64, buf Block) {
+	if uint64(len(buf)) != BlockSize {
+		panic("buffer is not block-sized")
+	}
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.checkLive()
+	if ws := d.pending[a]; len(ws) > 0 {
+		copy(buf, ws[len(ws)-1])
+		return
+	}
+	d.d.ReadTo(a, buf)
+}
+
+func (d CrashDisk) Read(a uint64) Block {
+	buf := make(Block, BlockSize)
+	d.ReadTo(a, buf)
+	return buf
+}
+
+// buffer records a pending write of v to a; the caller must hold d.m.
+func (d CrashDisk) buffer(a uint64, v Block) {
+	d.checkLive()
+	if a >= d.d.Size() {
+		panic(fmt.Errorf("out-of-bounds write at %v", a))
+	}
+	d.pending[a] = append(d.pending[a], v)
+}
+
+func (d CrashDisk) Write(a uint64, v Block) {
+	if uint64(len(v)) != BlockSize {
+		panic(fmt.Errorf("v is not block-sized (%d bytes)", len(v)))
+	}
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.buffer(a, append(Block(nil), v...))
+}
+
+// Trim is buffered like a write of zeros.
+func (d CrashDisk) Trim(a uint64) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.buffer(a, make(Block, BlockSize))
+}
+
+func (d CrashDisk) Prefetch(a uint64, count uint64) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.checkLive()
+	d.d.Prefetch(a, count)
+}
+
+func (d CrashDisk) Size() uint64 {
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.checkLive()
+	return d.d.Size()
+}
+
+// Resize resizes the underlying disk immediately; pending writes past the new
+// end are discarded.
+func (d CrashDisk) Resize(newSize uint64) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.checkLive()
+	for a := range d.pending {
+		if a >= newSize {
+			delete(d.pending, a)
+		}
+	}
+	d.d.Resize(newSize)
+}
+
+// Barrier applies the latest pending write to each block and then issues a
+// barrier on the underlying disk.
+func (d CrashDisk) Barrier() {
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.checkLive()
+	for a, ws := range d.pending {
+		d.d.Write(a, ws[len(ws)-1])
+	}
+	clear(d.pending)
+	d.d.Barrier()
+}
+
+// Pending returns the number of blocks with writes since the last Barrier.
+func (d CrashDisk) Pending() uint64 {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return uint64(len(d.pending))
+}
+
+// Crash simulates a crash. For each block with pending writes it keeps either
+// none of them or one arbitrary write (chosen uniformly), makes the result
+// durable on the underlying disk, and then refuses further operations.
+func (d CrashDisk) Crash() {
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.checkLive()
+	// visit addresses in order so the choices depend only on the seed
+	addrs := make([]uint64, 0, len(d.pending))
+	for a := range d.pending {
+		addrs = append(addrs, a)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i] < addrs[j] })
+	for _, a := range addrs {
+		ws := d.pending[a]
+		i := d.rng.Uint64n(uint64(len(ws)) + 1)
+		if i > 0 {
+			d.d.Write(a, ws[i-1])
+		}
+	}
+	clear(d.pending)
+	d.d.Barrier()
+	*d.crashed = true
+}
+
+// Close closes the underlying disk. Unlike other operations it is allowed
+// after a crash.
+func (d CrashDisk) Close() {
+	d.d.Close()
+}
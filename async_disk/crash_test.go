@@ -0,0 +1,54 @@
+package async_disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrashDiskBarrierIsDurable(t *testing.T) {
+	assert := assert.New(t)
+	base := NewMemDisk(10)
+	d := NewCrashDisk(base, 1)
+	d.Write(1, mkBlock(1))
+	assert.Equal(mkBlock(1), d.Read(1))
+	assert.Equal(mkBlock(0), base.Read(1), "writes are buffered until Barrier")
+	d.Barrier()
+	assert.Equal(mkBlock(1), base.Read(1))
+	d.Crash()
+	assert.Equal(mkBlock(1), base.Read(1))
+	assert.Panics(func() { d.Read(1) }, "use after crash")
+	assert.Panics(func() { d.Write(1, mkBlock(2)) }, "use after crash")
+}
+
+func TestCrashDiskStates(t *testing.T) {
+	// every allowed crash state shows up across seeds, and nothing else does
+	seen := map[byte]bool{}
+	for seed := uint64(0); seed < 100; seed++ {
+		base := NewMemDisk(10)
+		base.Write(1, mkBlock(1))
+		d := NewCrashDisk(base, seed)
+		d.Write(1, mkBlock(2))
+		d.Write(1, mkBlock(3))
+		d.Crash()
+		seen[base.Read(1)[0]] = true
+	}
+	assert.Equal(t, map[byte]bool{1: true, 2: true, 3: true}, seen)
+}
+
+func TestCrashDiskReproducible(t *testing.T) {
+	run := func() []byte {
+		base := NewMemDisk(10)
+		d := NewCrashDisk(base, 42)
+		for a := uint64(0); a < 10; a++ {
+			d.Write(a, mkBlock(byte(a+1)))
+		}
+		d.Crash()
+		var state []byte
+		for a := uint64(0); a < 10; a++ {
+			state = append(state, base.Read(a)[0])
+		}
+		return state
+	}
+	assert.Equal(t, run(), run())
+}
@@ -0,0 +1,12 @@
+package async_disk
+
+import (
+	"github.com/goose-lang/primitive/disk"
+)
+
+// WriteMulti writes blocks[i] to address a+i of d for each i, issuing them as
+// one batch when d supports it (see disk.WriteMulti). As with Write, the
+// blocks are only durable after a Barrier.
+func WriteMulti(d Disk, a uint64, blocks []Block) {
+	disk.WriteMulti(d, a, blocks)
+}
@@ -0,0 +1,207 @@
+package async_disk
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/goose-lang/primitive/disk"
+)
+
+// queueDepth is the number of requests that can be submitted to a queue of a
+// MultiQueueDisk before Submit blocks, and also the number of completions a
+// queue holds before its worker waits for them to be collected.
+const queueDepth = 128
+
+// Request is an operation submitted to a queue of a MultiQueueDisk: a read or
+// a write of block Addr. Data is the block to write, and is ignored for
+// reads.
+type Request struct {
+	Op   disk.DiskOp
+	Addr uint64
+	Data Block
+}
+
+// Completion reports a finished request: its id (as returned by Submit) and,
+// for a read, the block read.
+type Completion struct {
+	ID   uint64
+	Data Block
+	// Failure holds the value the request panicked with (for example, because
+	// the disk shrank after it was submitted), or nil if it succeeded.
+	Failure any
+}
+
+// MultiQueueDisk exposes a Disk through several independent submission
+// queues, like the queues of an NVMe device, so that different goroutines can
+// keep I/O in flight without coordinating.
+//
+// Each queue has its own worker: the requests submitted to a queue execute,
+// and complete, in submission order, while requests on different queues are
+// unordered with respect to each other. Completions are collected with
+// Complete, and every request's completion must be collected: a queue with
+// queueDepth uncollected completions stops executing requests until some are
+// collected, so that they (and the blocks they read) are not held without
+// bound. As with Disk.Write, a write is durable only once a Barrier issued
+// after it completed returns; Barrier is shared by all queues.
+type MultiQueueDisk struct {
+	d       Disk
+	queues  []*Queue
+	pending *pendingCount
+	workers *sync.WaitGroup
+}
+
+// Queue is one submission queue of a MultiQueueDisk. A Queue may be used from
+// several goroutines, but is meant to be owned by one.
+type Queue struct {
+	d       Disk
+	pending *pendingCount
+	sq      chan queued
+	// sm orders submissions, so ids enter sq in order.
+	sm     sync.Mutex
+	nextID uint64
+
+	m    sync.Mutex
+	cond *sync.Cond
+	// cq holds completions not yet collected, oldest first; the worker waits
+	// on cond while it holds queueDepth of them.
+	cq []Completion
+	// closed is set by Close, after which completions are discarded.
+	closed bool
+}
+
+type queued struct {
+	id  uint64
+	req Request
+}
+
+// NewMultiQueueDisk creates numQueues queues over d, each with its own
+// worker goroutine.
+//
+// Requires numQueues > 0.
+func NewMultiQueueDisk(d Disk, numQueues uint64) MultiQueueDisk {
+	if numQueues == 0 {
+		panic("MultiQueueDisk requires at least one queue")
+	}
+	mq := MultiQueueDisk{
+		d:       d,
+		queues:  make([]*Queue, numQueues),
+		pending: newPendingCount(),
+		workers: new(sync.WaitGroup),
+	}
+	mq.workers.Add(int(numQueues))
+	for i := range mq.queues {
+		q := &Queue{d: d, pending: mq.pending, sq: make(chan queued, queueDepth)}
+		q.cond = sync.NewCond(&q.m)
+		mq.queues[i] = q
+		go mq.work(q)
+	}
+	return mq
+}
+
+// NumQueues returns the number of queues.
+func (mq MultiQueueDisk) NumQueues() uint64 {
+	return uint64(len(mq.queues))
+}
+
+// Queue returns queue i. Requires i < NumQueues().
+func (mq MultiQueueDisk) Queue(i uint64) *Queue {
+	return mq.queues[i]
+}
+
+func (mq MultiQueueDisk) work(q *Queue) {
+	defer mq.workers.Done()
+	for r := range q.sq {
+		c := mq.execute(r)
+		q.m.Lock()
+		for uint64(len(q.cq)) >= queueDepth && !q.closed {
+			q.cond.Wait()
+		}
+		if !q.closed {
+			q.cq = append(q.cq, c)
+		}
+		q.cond.Broadcast()
+		q.m.Unlock()
+		q.pending.done()
+	}
+}
+
+// execute runs one request, recording a panic in the completion rather than
+// letting it crash the worker.
+func (mq MultiQueueDisk) execute(r queued) (c Completion) {
+	c.ID = r.id
+	defer func() {
+		c.Failure = recover()
+	}()
+	switch r.req.Op {
+	case disk.OpRead:
+		c.Data = mq.d.Read(r.req.Addr)
+	case disk.OpWrite:
+		mq.d.Write(r.req.Addr, r.req.Data)
+	}
+	return c
+}
+
+// Submit queues a request, returning its id, and blocks only if the queue
+// is full. A write's data is copied, so the caller may reuse it immediately.
+//
+// Requires req.Op to be disk.OpRead or disk.OpWrite, and req.Addr to be in
+// bounds.
+func (q *Queue) Submit(req Request) uint64 {
+	switch req.Op {
+	case disk.OpRead:
+	case disk.OpWrite:
+		if uint64(len(req.Data)) != BlockSize {
+			panic(fmt.Errorf("v is not block-sized (%d bytes)", len(req.Data)))
+		}
+		req.Data = append(Block(nil), req.Data...)
+	default:
+		panic(fmt.Errorf("unsupported queued operation %v", req.Op))
+	}
+	if req.Addr >= q.d.Size() {
+		panic(fmt.Errorf("out-of-bounds %v at %v", req.Op, req.Addr))
+	}
+	q.sm.Lock()
+	defer q.sm.Unlock()
+	id := q.nextID
+	q.nextID++
+	q.pending.add()
+	q.sq <- queued{id: id, req: req}
+	return id
+}
+
+// Complete waits for and returns the oldest completion on q not yet returned.
+// Completions are returned in submission order.
+func (q *Queue) Complete() Completion {
+	q.m.Lock()
+	defer q.m.Unlock()
+	for len(q.cq) == 0 {
+		q.cond.Wait()
+	}
+	c := q.cq[0]
+	q.cq = q.cq[1:]
+	q.cond.Broadcast()
+	return c
+}
+
+// Barrier waits for every request submitted to any queue to complete and then
+// issues a Barrier to the underlying disk, making the writes durable.
+// Completions must keep being collected meanwhile, or a queue with queueDepth
+// of them stalls and Barrier waits for it.
+func (mq MultiQueueDisk) Barrier() {
+	mq.pending.wait()
+	mq.d.Barrier()
+}
+
+// Close waits for submitted requests and stops the workers, discarding any
+// uncollected completions. It does not close the underlying disk.
+func (mq MultiQueueDisk) Close() {
+	for _, q := range mq.queues {
+		q.m.Lock()
+		q.closed = true
+		q.cq = nil
+		q.cond.Broadcast()
+		q.m.Unlock()
+		close(q.sq)
+	}
+	mq.workers.Wait()
+}
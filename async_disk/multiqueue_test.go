@@ -0,0 +1,109 @@
+package async_disk
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goose-lang/primitive/disk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiQueueDisk(t *testing.T) {
+	assert := assert.New(t)
+	d := NewAsyncMemDisk(100)
+	mq := NewMultiQueueDisk(d, 4)
+	defer mq.Close()
+	assert.Equal(uint64(4), mq.NumQueues())
+
+	q := mq.Queue(0)
+	w := q.Submit(Request{Op: disk.OpWrite, Addr: 3, Data: mkBlock(1)})
+	r := q.Submit(Request{Op: disk.OpRead, Addr: 3})
+	c := q.Complete()
+	assert.Equal(w, c.ID)
+	c = q.Complete()
+	assert.Equal(r, c.ID)
+	assert.Equal(mkBlock(1), c.Data, "requests on a queue execute in order")
+
+	mq.Barrier()
+	assert.Equal(uint64(0), d.Pending())
+}
+
+func TestMultiQueueDiskParallel(t *testing.T) {
+	const perQueue = 2*queueDepth + 10
+	d := NewMemDisk(4 * perQueue)
+	mq := NewMultiQueueDisk(d, 4)
+	defer mq.Close()
+	var wg sync.WaitGroup
+	for i := uint64(0); i < 4; i++ {
+		wg.Add(2)
+		go func(q *Queue, base uint64) {
+			defer wg.Done()
+			// submit more than the queue depth before collecting
+			for a := base; a < base+perQueue; a++ {
+				q.Submit(Request{Op: disk.OpWrite, Addr: a, Data: mkBlock(byte(a))})
+			}
+		}(mq.Queue(i), i*perQueue)
+		go func(q *Queue) {
+			defer wg.Done()
+			for n := 0; n < perQueue; n++ {
+				q.Complete()
+			}
+		}(mq.Queue(i))
+	}
+	mq.Barrier()
+	wg.Wait()
+	mq.Barrier()
+	for a := uint64(0); a < 4*perQueue; a++ {
+		assert.Equal(t, mkBlock(byte(a)), d.Read(a))
+	}
+}
+
+func TestMultiQueueDiskBadRequest(t *testing.T) {
+	mq := NewMultiQueueDisk(NewMemDisk(1), 1)
+	defer mq.Close()
+	q := mq.Queue(0)
+	assert.Panics(t, func() { q.Submit(Request{Op: disk.OpRead, Addr: 1}) })
+	assert.Panics(t, func() { q.Submit(Request{Op: disk.OpBarrier}) })
+}
+
+func TestMultiQueueDiskFailure(t *testing.T) {
+	assert := assert.New(t)
+	d := disk.NewFaultDisk(NewMemDisk(10), 1)
+	d.Inject(disk.Fault{Op: disk.OpWrite, Addr: 2, Kind: disk.FaultFail})
+	mq := NewMultiQueueDisk(d, 1)
+	defer mq.Close()
+	q := mq.Queue(0)
+	q.Submit(Request{Op: disk.OpWrite, Addr: 2, Data: mkBlock(1)})
+	q.Submit(Request{Op: disk.OpWrite, Addr: 3, Data: mkBlock(2)})
+	c := q.Complete()
+	assert.NotNil(c.Failure, "the failed write is reported")
+	c = q.Complete()
+	assert.Nil(c.Failure, "the worker survives a failed request")
+	mq.Barrier()
+	assert.Equal(mkBlock(2), d.Read(3))
+}
+
+func TestMultiQueueDiskBackpressure(t *testing.T) {
+	assert := assert.New(t)
+	mq := NewMultiQueueDisk(NewMemDisk(1), 1)
+	q := mq.Queue(0)
+	for i := 0; i < 2*queueDepth; i++ {
+		q.Submit(Request{Op: disk.OpRead, Addr: 0})
+	}
+	held := func() uint64 {
+		q.m.Lock()
+		defer q.m.Unlock()
+		return uint64(len(q.cq))
+	}
+	for held() < queueDepth {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(uint64(queueDepth), held(), "uncollected completions are bounded")
+	assert.NotZero(len(q.sq), "the worker waits for completions to be collected")
+	for i := uint64(0); i < 2*queueDepth; i++ {
+		assert.Equal(i, q.Complete().ID)
+	}
+	mq.Close()
+}
@@ -0,0 +1,148 @@
+package async_disk
+
+import (
+	"sync"
+)
+
+// AsyncWriter submits writes to a Disk without waiting for them, so that
+// several can be outstanding at once and the device's queue depth is used.
+//
+// Writes are carried out by a fixed pool of workers. Writes to the same
+// address go to the same worker and are applied in submission order; writes
+// to different addresses may complete in any order. A write is only durable
+// once a Barrier issued after it has completed returns, as with Disk.Write.
+type AsyncWriter struct {
+	d       Disk
+	queues  []chan asyncWrite
+	pending *pendingCount
+	workers *sync.WaitGroup
+}
+
+// pendingCount counts outstanding operations. Unlike a sync.WaitGroup, it
+// may be incremented while another goroutine waits for it to reach zero.
+type pendingCount struct {
+	m    sync.Mutex
+	zero *sync.Cond
+	n    uint64
+}
+
+func newPendingCount() *pendingCount {
+	p := &pendingCount{}
+	p.zero = sync.NewCond(&p.m)
+	return p
+}
+
+func (p *pendingCount) add() {
+	p.m.Lock()
+	p.n++
+	p.m.Unlock()
+}
+
+func (p *pendingCount) done() {
+	p.m.Lock()
+	p.n--
+	if p.n == 0 {
+		p.zero.Broadcast()
+	}
+	p.m.Unlock()
+}
+
+// wait waits until no operations are outstanding.
+func (p *pendingCount) wait() {
+	p.m.Lock()
+	for p.n > 0 {
+		p.zero.Wait()
+	}
+	p.m.Unlock()
+}
+
+// WriteToken identifies a write submitted with WriteAsync.
+type WriteToken struct {
+	done *completion
+}
+
+type completion struct {
+	ch chan struct{}
+	// failure holds the value the write panicked with, if it did.
+	failure any
+}
+
+type asyncWrite struct {
+	a    uint64
+	v    Block
+	done *completion
+}
+
+// NewAsyncWriter creates an AsyncWriter over d with numWorkers workers.
+//
+// Requires numWorkers > 0.
+func NewAsyncWriter(d Disk, numWorkers uint64) AsyncWriter {
+	if numWorkers == 0 {
+		panic("AsyncWriter requires at least one worker")
+	}
+	w := AsyncWriter{
+		d:       d,
+		queues:  make([]chan asyncWrite, numWorkers),
+		pending: newPendingCount(),
+		workers: new(sync.WaitGroup),
+	}
+	w.workers.Add(int(numWorkers))
+	for i := range w.queues {
+		w.queues[i] = make(chan asyncWrite, 64)
+		go w.work(w.queues[i])
+	}
+	return w
+}
+
+func (w AsyncWriter) work(q chan asyncWrite) {
+	defer w.workers.Done()
+	for req := range q {
+		w.apply(req)
+	}
+}
+
+func (w AsyncWriter) apply(req asyncWrite) {
+	defer w.pending.done()
+	defer close(req.done.ch)
+	defer func() {
+		req.done.failure = recover()
+	}()
+	w.d.Write(req.a, req.v)
+}
+
+// WriteAsync submits a write of v to address a and returns without waiting
+// for it. v is copied, so the caller may reuse it immediately.
+func (w AsyncWriter) WriteAsync(a uint64, v Block) WriteToken {
+	done := &completion{ch: make(chan struct{})}
+	w.pending.add()
+	q := w.queues[a%uint64(len(w.queues))]
+	q <- asyncWrite{a: a, v: append(Block(nil), v...), done: done}
+	return WriteToken{done: done}
+}
+
+// Await waits for the write identified by t to complete. If the write
+// panicked (for example, because it was out-of-bounds), Await panics with the
+// same value.
+func (w AsyncWriter) Await(t WriteToken) {
+	<-t.done.ch
+	if t.done.failure != nil {
+		panic(t.done.failure)
+	}
+}
+
+// Barrier waits for every outstanding write to complete and then issues a
+// Barrier to the underlying disk, making them durable. Writes submitted
+// concurrently with Barrier may or may not be waited for.
+func (w AsyncWriter) Barrier() {
+	w.pending.wait()
+	w.d.Barrier()
+}
+
+// Close waits for outstanding writes and stops the workers. It does not close
+// the underlying disk. The AsyncWriter must not be used afterward.
+func (w AsyncWriter) Close() {
+	for _, q := range w.queues {
+		close(q)
+	}
+	w.workers.Wait()
+}
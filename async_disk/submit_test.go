@@ -0,0 +1,80 @@
+package async_disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsyncWriter(t *testing.T) {
+	assert := assert.New(t)
+	d := NewAsyncMemDisk(10)
+	w := NewAsyncWriter(d, 4)
+	defer w.Close()
+
+	var tokens []WriteToken
+	for a := uint64(0); a < 10; a++ {
+		tokens = append(tokens, w.WriteAsync(a, mkBlock(byte(a+1))))
+	}
+	w.Await(tokens[3])
+	assert.Equal(mkBlock(4), d.Read(3))
+
+	w.Barrier()
+	assert.Equal(uint64(0), d.Pending(), "barrier should wait for every write")
+	for a := uint64(0); a < 10; a++ {
+		assert.Equal(mkBlock(byte(a+1)), d.Read(a))
+	}
+}
+
+func TestAsyncWriterSameAddressOrder(t *testing.T) {
+	d := NewMemDisk(2)
+	w := NewAsyncWriter(d, 3)
+	defer w.Close()
+	for i := 0; i < 100; i++ {
+		w.WriteAsync(1, mkBlock(byte(i)))
+	}
+	w.Barrier()
+	assert.Equal(t, mkBlock(99), d.Read(1))
+}
+
+func TestAsyncWriterCopiesBlock(t *testing.T) {
+	d := NewMemDisk(1)
+	w := NewAsyncWriter(d, 1)
+	defer w.Close()
+	b := mkBlock(1)
+	tok := w.WriteAsync(0, b)
+	b[0] = 2
+	w.Await(tok)
+	assert.Equal(t, mkBlock(1), d.Read(0))
+}
+
+func TestAsyncWriterFailure(t *testing.T) {
+	d := NewMemDisk(1)
+	w := NewAsyncWriter(d, 2)
+	defer w.Close()
+	tok := w.WriteAsync(5, mkBlock(1))
+	assert.Panics(t, func() { w.Await(tok) })
+	assert.NotPanics(t, func() { w.Barrier() }, "a failed write should not block the barrier")
+}
+
+func TestAsyncWriterBarrierDuringWrites(t *testing.T) {
+	d := NewMemDisk(100)
+	w := NewAsyncWriter(d, 4)
+	defer w.Close()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for a := uint64(0); a < 100; a++ {
+			w.WriteAsync(a, mkBlock(byte(a)))
+		}
+	}()
+	// barriers may overlap submissions
+	for i := 0; i < 20; i++ {
+		w.Barrier()
+	}
+	<-done
+	w.Barrier()
+	for a := uint64(0); a < 100; a++ {
+		assert.Equal(t, mkBlock(byte(a)), d.Read(a))
+	}
+}
@@ -0,0 +1,92 @@
+package primitive
+
+import "sync/atomic"
+
+// Atomic operations on uint64 cells.
+//
+// Each operation is modeled as a single atomic step on the cell at addr. addr
+// must be 8-byte aligned (guaranteed for the first word of an allocated struct
+// or array, and for any uint64 variable on 64-bit platforms).
+
+// LoadUint64 atomically reads *addr.
+func LoadUint64(addr *uint64) uint64 {
+	return atomic.LoadUint64(addr)
+}
+
+// StoreUint64 atomically sets *addr to val.
+func StoreUint64(addr *uint64, val uint64) {
+	atomic.StoreUint64(addr, val)
+}
+
+// AddUint64 atomically adds delta to *addr (wrapping on overflow) and returns
+// the new value.
+func AddUint64(addr *uint64, delta uint64) uint64 {
+	return atomic.AddUint64(addr, delta)
+}
+
+// CASUint64 atomically sets *addr to new if it currently holds old, reporting
+// whether the swap happened.
+func CASUint64(addr *uint64, old, new uint64) bool {
+	return atomic.CompareAndSwapUint64(addr, old, new)
+}
+
+// AtomicBool is a boolean cell whose operations are each a single atomic step,
+// for flags such as "shutdown requested".
+//
+// The zero value holds false.
+type AtomicBool struct {
+	v atomic.Bool
+}
+
+// Load atomically reads the flag.
+func (b *AtomicBool) Load() bool {
+	return b.v.Load()
+}
+
+// Store atomically sets the flag to val.
+func (b *AtomicBool) Store(val bool) {
+	b.v.Store(val)
+}
+
+// Swap atomically sets the flag to val and returns its previous value.
+func (b *AtomicBool) Swap(val bool) bool {
+	return b.v.Swap(val)
+}
+
+// CompareAndSwap atomically sets the flag to new if it currently holds old,
+// reporting whether the swap happened.
+func (b *AtomicBool) CompareAndSwap(old, new bool) bool {
+	return b.v.CompareAndSwap(old, new)
+}
+
+// AtomicPointer is a cell holding a *T whose operations are each a single
+// atomic step. It is intended for publishing immutable snapshots: readers Load
+// the current snapshot while a writer installs a fresh one with Store or
+// CompareAndSwap, so the pointed-to value must not be mutated after it is
+// published.
+//
+// The zero value holds nil.
+type AtomicPointer[T any] struct {
+	p atomic.Pointer[T]
+}
+
+// Load atomically reads the pointer.
+func (a *AtomicPointer[T]) Load() *T {
+	return a.p.Load()
+}
+
+// Store atomically sets the pointer to val.
+func (a *AtomicPointer[T]) Store(val *T) {
+	a.p.Store(val)
+}
+
+// Swap atomically sets the pointer to val and returns its previous value.
+func (a *AtomicPointer[T]) Swap(val *T) *T {
+	return a.p.Swap(val)
+}
+
+// CompareAndSwap atomically sets the pointer to new if it currently equals old,
+// reporting whether the swap happened. Pointers are compared by identity.
+func (a *AtomicPointer[T]) CompareAndSwap(old, new *T) bool {
+	return a.p.CompareAndSwap(old, new)
+}
@@ -0,0 +1,61 @@
+package primitive
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAtomicUint64(t *testing.T) {
+	assert := assert.New(t)
+	var x uint64
+	StoreUint64(&x, 5)
+	assert.Equal(uint64(5), LoadUint64(&x))
+	assert.Equal(uint64(8), AddUint64(&x, 3))
+	assert.False(CASUint64(&x, 5, 10))
+	assert.True(CASUint64(&x, 8, 10))
+	assert.Equal(uint64(10), LoadUint64(&x))
+}
+
+func TestAddUint64Concurrent(t *testing.T) {
+	var x uint64
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				AddUint64(&x, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, uint64(8000), LoadUint64(&x))
+}
+
+func TestAtomicBool(t *testing.T) {
+	assert := assert.New(t)
+	var b AtomicBool
+	assert.False(b.Load())
+	b.Store(true)
+	assert.True(b.Load())
+	assert.True(b.Swap(false))
+	assert.False(b.CompareAndSwap(true, true))
+	assert.True(b.CompareAndSwap(false, true))
+	assert.True(b.Load())
+}
+
+func TestAtomicPointer(t *testing.T) {
+	assert := assert.New(t)
+	var p AtomicPointer[[]uint64]
+	assert.Nil(p.Load())
+	s1 := &[]uint64{1}
+	s2 := &[]uint64{2}
+	p.Store(s1)
+	assert.Same(s1, p.Load())
+	assert.False(p.CompareAndSwap(s2, s2))
+	assert.True(p.CompareAndSwap(s1, s2))
+	assert.Same(s2, p.Swap(nil))
+	assert.Nil(p.Load())
+}
@@ -0,0 +1,65 @@
+package primitive
+
+// Backoff computes delays for retrying a failing operation: each delay
+// doubles the previous one, starting at a base and capped at a maximum, with
+// optional jitter so that many clients retrying at once spread out.
+//
+// The delays are only a liveness aid (like Sleep, they are not modeled), and
+// the jitter comes from a Rand, so a run is reproducible from its seed. A
+// Backoff is not safe for concurrent use.
+type Backoff struct {
+	baseNs uint64
+	maxNs  uint64
+	next   uint64
+	// rng is nil if there is no jitter.
+	rng *Rand
+}
+
+// NewBackoff creates a backoff whose delays are baseNs, 2*baseNs, 4*baseNs,
+// ..., up to maxNs. Assumes 0 < baseNs <= maxNs.
+func NewBackoff(baseNs uint64, maxNs uint64) *Backoff {
+	Assume(0 < baseNs && baseNs <= maxNs)
+	return &Backoff{baseNs: baseNs, maxNs: maxNs, next: baseNs}
+}
+
+// NewBackoffJitter is like NewBackoff, but each delay d is replaced by a
+// value drawn uniformly from [d/2, d] using a generator seeded by seed.
+func NewBackoffJitter(baseNs uint64, maxNs uint64, seed uint64) *Backoff {
+	b := NewBackoff(baseNs, maxNs)
+	b.rng = NewRand(seed)
+	return b
+}
+
+// NextDelay returns the delay before the next retry, in nanoseconds, and
+// advances the backoff.
+func (b *Backoff) NextDelay() uint64 {
+	d := b.next
+	b.next = MinUint64(SatMul(b.next, 2), b.maxNs)
+	if b.rng != nil {
+		lo := d / 2
+		d = lo + b.rng.Uint64n(d-lo+1)
+	}
+	return d
+}
+
+// Reset restarts the backoff from the base delay, for example after a
+// success.
+func (b *Backoff) Reset() {
+	b.next = b.baseNs
+}
+
+// RetryUntil calls f until it returns true, at most attempts times, sleeping
+// for the next delay between attempts. It reports whether f succeeded.
+//
+// Modeled as calling f up to attempts times.
+func (b *Backoff) RetryUntil(f func() bool, attempts uint64) bool {
+	for i := uint64(0); i < attempts; i++ {
+		if f() {
+			return true
+		}
+		if i+1 < attempts {
+			Sleep(b.NextDelay())
+		}
+	}
+	return false
+}
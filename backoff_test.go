@@ -0,0 +1,50 @@
+package primitive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	b := NewBackoff(10, 100)
+	var delays []uint64
+	for i := 0; i < 6; i++ {
+		delays = append(delays, b.NextDelay())
+	}
+	assert.Equal(t, []uint64{10, 20, 40, 80, 100, 100}, delays)
+	b.Reset()
+	assert.Equal(t, uint64(10), b.NextDelay())
+}
+
+func TestBackoffJitter(t *testing.T) {
+	b := NewBackoffJitter(1000, 8000, 1)
+	expected := []uint64{1000, 2000, 4000, 8000, 8000}
+	for _, d := range expected {
+		got := b.NextDelay()
+		assert.GreaterOrEqual(t, got, d/2)
+		assert.LessOrEqual(t, got, d)
+	}
+	// the same seed gives the same delays
+	b1, b2 := NewBackoffJitter(1000, 8000, 7), NewBackoffJitter(1000, 8000, 7)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, b1.NextDelay(), b2.NextDelay())
+	}
+}
+
+func TestRetryUntil(t *testing.T) {
+	b := NewBackoff(1, 10)
+	calls := 0
+	assert.True(t, b.RetryUntil(func() bool {
+		calls++
+		return calls == 3
+	}, 5))
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	assert.False(t, b.RetryUntil(func() bool {
+		calls++
+		return false
+	}, 4))
+	assert.Equal(t, 4, calls)
+}
@@ -0,0 +1,49 @@
+package primitive
+
+import "sync"
+
+// Barrier is a cyclic barrier for n parties: each call to Wait blocks until n
+// parties have arrived, then all of them are released and the barrier resets
+// for the next phase.
+//
+// In the model the barrier tracks the current phase and how many parties have
+// arrived in it; the last arrival advances the phase.
+type Barrier struct {
+	m       sync.Mutex
+	cond    *sync.Cond
+	parties uint64
+	arrived uint64
+	phase   uint64
+}
+
+// NewBarrier creates a barrier for n parties.
+//
+// Assumes n > 0.
+func NewBarrier(n uint64) *Barrier {
+	Assume(n > 0)
+	b := &Barrier{parties: n}
+	b.cond = sync.NewCond(&b.m)
+	return b
+}
+
+// Wait arrives at the barrier and blocks until all parties of the current
+// phase have arrived.
+//
+// Returns true for exactly one party per phase (the last to arrive), which is
+// convenient for doing per-phase work once.
+func (b *Barrier) Wait() bool {
+	b.m.Lock()
+	defer b.m.Unlock()
+	phase := b.phase
+	b.arrived++
+	if b.arrived == b.parties {
+		b.arrived = 0
+		b.phase++
+		b.cond.Broadcast()
+		return true
+	}
+	for b.phase == phase {
+		b.cond.Wait()
+	}
+	return false
+}
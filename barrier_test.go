@@ -0,0 +1,40 @@
+package primitive
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBarrierPhases(t *testing.T) {
+	const parties = 4
+	const phases = 5
+	b := NewBarrier(parties)
+	var counts [phases]atomic.Uint64
+	var leaders atomic.Uint64
+	var wg sync.WaitGroup
+	for i := 0; i < parties; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := 0; p < phases; p++ {
+				counts[p].Add(1)
+				if b.Wait() {
+					leaders.Add(1)
+				}
+				// everyone has finished phase p before anyone continues
+				assert.Equal(t, uint64(parties), counts[p].Load())
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, uint64(phases), leaders.Load())
+}
+
+func TestBarrierSingleParty(t *testing.T) {
+	b := NewBarrier(1)
+	assert.True(t, b.Wait())
+	assert.True(t, b.Wait())
+}
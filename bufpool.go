@@ -0,0 +1,37 @@
+package primitive
+
+import "sync"
+
+// BufPool recycles fixed-size byte buffers (for example, disk blocks) to
+// avoid allocating one per operation.
+//
+// Modeled as an allocator: Get returns a fresh buffer of the pool's size
+// with arbitrary contents, and Put gives up ownership of a buffer. A buffer
+// must not be used after it is Put. It is safe for concurrent use.
+type BufPool struct {
+	size uint64
+	// p holds *[]byte values of length size.
+	p sync.Pool
+}
+
+// NewBufPool creates a pool of buffers of size bytes each.
+func NewBufPool(size uint64) *BufPool {
+	bp := &BufPool{size: size}
+	bp.p.New = func() any {
+		b := make([]byte, size)
+		return &b
+	}
+	return bp
+}
+
+// Get returns a buffer of the pool's size. Its contents are arbitrary: it
+// may hold data from an earlier user.
+func (bp *BufPool) Get() []byte {
+	return *bp.p.Get().(*[]byte)
+}
+
+// Put returns b to the pool. Assumes len(b) is the pool's size.
+func (bp *BufPool) Put(b []byte) {
+	Assume(uint64(len(b)) == bp.size)
+	bp.p.Put(&b)
+}
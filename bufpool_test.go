@@ -0,0 +1,26 @@
+package primitive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBufPool(t *testing.T) {
+	assert := assert.New(t)
+	bp := NewBufPool(4096)
+	b := bp.Get()
+	assert.Len(b, 4096)
+	b[0] = 1
+	bp.Put(b)
+	assert.Len(bp.Get(), 4096)
+	assert.Panics(func() { bp.Put(make([]byte, 10)) })
+}
+
+func BenchmarkBufPool(b *testing.B) {
+	bp := NewBufPool(4096)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		bp.Put(bp.Get())
+	}
+}
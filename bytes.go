@@ -0,0 +1,101 @@
+package primitive
+
+import "bytes"
+
+// Bitwise operations over whole byte slices, for bitmap algebra.
+//
+// Each operation requires its inputs and dst to have equal lengths. dst may be
+// the same slice as any of the inputs (the in-place case), but must not
+// otherwise partially overlap them.
+
+// XorBytes sets dst[i] = a[i] ^ b[i] for every index.
+func XorBytes(dst, a, b []byte) {
+	Assume(len(dst) == len(a) && len(a) == len(b))
+	for i := range dst {
+		dst[i] = a[i] ^ b[i]
+	}
+}
+
+// AndBytes sets dst[i] = a[i] & b[i] for every index.
+func AndBytes(dst, a, b []byte) {
+	Assume(len(dst) == len(a) && len(a) == len(b))
+	for i := range dst {
+		dst[i] = a[i] & b[i]
+	}
+}
+
+// OrBytes sets dst[i] = a[i] | b[i] for every index.
+func OrBytes(dst, a, b []byte) {
+	Assume(len(dst) == len(a) && len(a) == len(b))
+	for i := range dst {
+		dst[i] = a[i] | b[i]
+	}
+}
+
+// NotBytes sets dst[i] = ^a[i] for every index.
+func NotBytes(dst, a []byte) {
+	Assume(len(dst) == len(a))
+	for i := range dst {
+		dst[i] = ^a[i]
+	}
+}
+
+// BytesEqual reports whether a and b have the same length and contents.
+//
+// Modeled as a pure function.
+func BytesEqual(a, b []byte) bool {
+	return bytes.Equal(a, b)
+}
+
+// BytesCompare compares a and b lexicographically, returning -1, 0, or 1 if a
+// is less than, equal to, or greater than b. A prefix is less than any longer
+// slice it is a prefix of.
+//
+// Modeled as a pure function.
+func BytesCompare(a, b []byte) int64 {
+	return int64(bytes.Compare(a, b))
+}
+
+// CompareRecords compares the records (keyA, seqA) and (keyB, seqB), ordering
+// first by key (lexicographically, as bytes) and then by sequence number.
+//
+// Returns -1, 0, or 1 if the first record is less than, equal to, or greater
+// than the second. This is a total order: it returns 0 only if the keys are
+// equal byte-for-byte and the sequence numbers are equal.
+func CompareRecords(keyA []byte, seqA uint64, keyB []byte, seqB uint64) int64 {
+	if c := BytesCompare(keyA, keyB); c != 0 {
+		return c
+	}
+	if seqA < seqB {
+		return -1
+	}
+	if seqA > seqB {
+		return 1
+	}
+	return 0
+}
+
+// SliceFill sets every element of p to v.
+//
+// Modeled as replacing p's contents with len(p) copies of v.
+func SliceFill(p []byte, v byte) {
+	if v == 0 {
+		clear(p)
+		return
+	}
+	if len(p) == 0 {
+		return
+	}
+	// fill by repeated doubling, which copy does much faster than a loop
+	p[0] = v
+	for n := 1; n < len(p); n *= 2 {
+		copy(p[n:], p[:n])
+	}
+}
+
+// ZeroBlock sets every byte of p to 0, for reusing a block buffer.
+//
+// Modeled as SliceFill(p, 0).
+func ZeroBlock(p []byte) {
+	clear(p)
+}
@@ -0,0 +1,112 @@
+package primitive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBitwiseBytesEmpty(t *testing.T) {
+	assert := assert.New(t)
+	var dst []byte
+	assert.NotPanics(func() {
+		XorBytes(dst, nil, nil)
+		AndBytes(dst, nil, nil)
+		OrBytes(dst, nil, nil)
+		NotBytes(dst, nil)
+	})
+}
+
+func TestBitwiseBytes(t *testing.T) {
+	assert := assert.New(t)
+	a := []byte{0b1100, 0xff, 0x00}
+	b := []byte{0b1010, 0x0f, 0xf0}
+	dst := make([]byte, 3)
+
+	XorBytes(dst, a, b)
+	assert.Equal([]byte{0b0110, 0xf0, 0xf0}, dst)
+	AndBytes(dst, a, b)
+	assert.Equal([]byte{0b1000, 0x0f, 0x00}, dst)
+	OrBytes(dst, a, b)
+	assert.Equal([]byte{0b1110, 0xff, 0xf0}, dst)
+	NotBytes(dst, a)
+	assert.Equal([]byte{0xf3, 0x00, 0xff}, dst)
+}
+
+func TestBitwiseBytesInPlace(t *testing.T) {
+	assert := assert.New(t)
+	b := []byte{0b1010, 0x0f}
+
+	a := []byte{0b1100, 0xff}
+	AndBytes(a, a, b)
+	assert.Equal([]byte{0b1000, 0x0f}, a)
+
+	a = []byte{0b1100, 0xff}
+	OrBytes(a, a, b)
+	assert.Equal([]byte{0b1110, 0xff}, a)
+
+	a = []byte{0b1100, 0xff}
+	XorBytes(a, a, b)
+	assert.Equal([]byte{0b0110, 0xf0}, a)
+
+	a = []byte{0b1100, 0xff}
+	NotBytes(a, a)
+	assert.Equal([]byte{0xf3, 0x00}, a)
+}
+
+func TestBitwiseBytesLengthMismatch(t *testing.T) {
+	assert.Panics(t, func() {
+		AndBytes(make([]byte, 2), make([]byte, 2), make([]byte, 3))
+	})
+}
+
+func TestCompareRecords(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(int64(-1), CompareRecords([]byte("a"), 5, []byte("b"), 1))
+	assert.Equal(int64(1), CompareRecords([]byte("b"), 1, []byte("a"), 5))
+	assert.Equal(int64(-1), CompareRecords([]byte("a"), 9, []byte("ab"), 0),
+		"prefix sorts first")
+
+	assert.Equal(int64(-1), CompareRecords([]byte("k"), 1, []byte("k"), 2))
+	assert.Equal(int64(1), CompareRecords([]byte("k"), 2, []byte("k"), 1))
+
+	assert.Equal(int64(0), CompareRecords([]byte("k"), 3, []byte("k"), 3))
+	assert.Equal(int64(0), CompareRecords(nil, 0, []byte{}, 0))
+}
+
+func TestBytesEqual(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(BytesEqual(nil, []byte{}))
+	assert.True(BytesEqual([]byte("abc"), []byte("abc")))
+	assert.False(BytesEqual([]byte("abc"), []byte("abd")))
+	assert.False(BytesEqual([]byte("ab"), []byte("abc")))
+}
+
+func TestBytesCompare(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(int64(0), BytesCompare([]byte("abc"), []byte("abc")))
+	assert.Equal(int64(-1), BytesCompare([]byte("ab"), []byte("abc")))
+	assert.Equal(int64(1), BytesCompare([]byte("b"), []byte("abc")))
+	assert.Equal(int64(-1), BytesCompare(nil, []byte{0}))
+}
+
+func TestSliceFill(t *testing.T) {
+	assert := assert.New(t)
+	for _, n := range []int{0, 1, 2, 7, 4096} {
+		p := make([]byte, n)
+		SliceFill(p, 0xab)
+		for i := range p {
+			if p[i] != 0xab {
+				t.Fatalf("SliceFill of %d bytes left p[%d] = %x", n, i, p[i])
+			}
+		}
+		SliceFill(p, 0)
+		assert.Equal(make([]byte, n), p)
+	}
+}
+
+func TestZeroBlock(t *testing.T) {
+	p := []byte{1, 2, 3}
+	ZeroBlock(p)
+	assert.Equal(t, []byte{0, 0, 0}, p)
+}
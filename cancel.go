@@ -0,0 +1,104 @@
+package primitive
+
+import (
+	"sync"
+	"time"
+)
+
+// CancelCtx carries a cancellation signal through a tree of operations, a
+// lightweight counterpart to context.Context.
+//
+// Modeled as a monotone flag: once canceled, a CancelCtx stays canceled,
+// and canceling it cancels its children too. A deadline is like a Timer: in
+// the model the context may be canceled at any point before it, so code
+// should rely on deadlines for liveness, not safety.
+type CancelCtx struct {
+	m        sync.Mutex
+	canceled bool
+	done     chan struct{}
+	parent   *CancelCtx
+	// children holds the children not yet canceled; a child removes itself
+	// when canceled, so short-lived children of a long-lived context do not
+	// accumulate.
+	children map[*CancelCtx]struct{}
+	timer    *time.Timer
+}
+
+// NewCancelCtx creates a context that is not canceled.
+func NewCancelCtx() *CancelCtx {
+	return &CancelCtx{done: make(chan struct{})}
+}
+
+// Child creates a context that is canceled when c is, and can also be
+// canceled on its own without affecting c.
+func (c *CancelCtx) Child() *CancelCtx {
+	child := NewCancelCtx()
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.canceled {
+		child.Cancel()
+		return child
+	}
+	child.parent = c
+	if c.children == nil {
+		c.children = make(map[*CancelCtx]struct{})
+	}
+	c.children[child] = struct{}{}
+	return child
+}
+
+// WithDeadline creates a child of c (see Child) that is also canceled once
+// TimeNow() reaches deadlineNs.
+func (c *CancelCtx) WithDeadline(deadlineNs uint64) *CancelCtx {
+	child := c.Child()
+	now := TimeNow()
+	if deadlineNs <= now {
+		child.Cancel()
+		return child
+	}
+	t := time.AfterFunc(nsDuration(deadlineNs-now), child.Cancel)
+	child.m.Lock()
+	child.timer = t
+	child.m.Unlock()
+	return child
+}
+
+// Cancel cancels c and all of its descendants. Canceling an already canceled
+// context does nothing.
+func (c *CancelCtx) Cancel() {
+	c.m.Lock()
+	if c.canceled {
+		c.m.Unlock()
+		return
+	}
+	c.canceled = true
+	close(c.done)
+	children := c.children
+	c.children = nil
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	parent := c.parent
+	c.parent = nil
+	c.m.Unlock()
+	for child := range children {
+		child.Cancel()
+	}
+	if parent != nil {
+		parent.m.Lock()
+		delete(parent.children, c)
+		parent.m.Unlock()
+	}
+}
+
+// Done reports whether c has been canceled.
+func (c *CancelCtx) Done() bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.canceled
+}
+
+// Wait blocks until c is canceled.
+func (c *CancelCtx) Wait() {
+	<-c.done
+}
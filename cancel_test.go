@@ -0,0 +1,67 @@
+package primitive
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancelCtx(t *testing.T) {
+	assert := assert.New(t)
+	root := NewCancelCtx()
+	a := root.Child()
+	b := a.Child()
+	assert.False(b.Done())
+
+	b.Cancel()
+	assert.True(b.Done())
+	assert.False(a.Done(), "canceling a child should not cancel its parent")
+
+	c := a.Child()
+	root.Cancel()
+	root.Cancel()
+	assert.True(a.Done())
+	assert.True(c.Done())
+	c.Wait()
+	assert.True(root.Child().Done(), "children of a canceled context start canceled")
+}
+
+func TestCancelCtxDeadline(t *testing.T) {
+	assert := assert.New(t)
+	root := NewCancelCtx()
+	assert.True(root.WithDeadline(TimeNow() - 1).Done())
+
+	d := root.WithDeadline(TimeNow() + 10_000_000)
+	assert.False(d.Done())
+	d.Wait()
+	assert.True(d.Done())
+	assert.False(root.Done())
+
+	far := root.WithDeadline(TimeNow() + 3600_000_000_000)
+	root.Cancel()
+	assert.True(far.Done(), "cancellation should propagate before the deadline")
+}
+
+func TestCancelCtxNoDeadline(t *testing.T) {
+	root := NewCancelCtx()
+	d := root.WithDeadline(math.MaxUint64)
+	time.Sleep(10 * time.Millisecond)
+	assert.False(t, d.Done(), "a far-future deadline should not wrap around")
+	root.Cancel()
+}
+
+func TestCancelCtxChildrenReleased(t *testing.T) {
+	root := NewCancelCtx()
+	for i := 0; i < 100; i++ {
+		root.Child().Cancel()
+		root.WithDeadline(0)
+	}
+	kept := root.Child()
+	root.m.Lock()
+	assert.Len(t, root.children, 1, "canceled children should be removed")
+	root.m.Unlock()
+	root.Cancel()
+	assert.True(t, kept.Done())
+}
@@ -0,0 +1,53 @@
+package primitive
+
+// Chan is a channel carrying values of type T with a fixed-size buffer.
+//
+// In the model a channel is a FIFO queue of at most capacity values plus a
+// closed flag: Send blocks while the queue is full, Receive blocks while it is
+// empty and the channel is open, and once closed, receivers drain the queue
+// before observing the close.
+type Chan[T any] struct {
+	c chan T
+}
+
+// NewChan creates an open channel buffering up to capacity values. A capacity
+// of 0 gives an unbuffered channel, where each Send waits for a matching
+// Receive.
+func NewChan[T any](capacity uint64) *Chan[T] {
+	return &Chan[T]{c: make(chan T, capacity)}
+}
+
+// Send adds v to the channel, blocking while the buffer is full.
+//
+// Sending on a closed channel panics.
+func (c *Chan[T]) Send(v T) {
+	c.c <- v
+}
+
+// Receive removes the oldest value from the channel, blocking while the
+// channel is empty and open. ok is false (and v the zero value) once the
+// channel has been closed and drained.
+func (c *Chan[T]) Receive() (v T, ok bool) {
+	v, ok = <-c.c
+	return
+}
+
+// TryReceive is like Receive but does not block: ok is false if no value is
+// available right now, either because the buffer is empty or because the
+// channel has been closed and drained.
+func (c *Chan[T]) TryReceive() (v T, ok bool) {
+	select {
+	case v, ok = <-c.c:
+		return
+	default:
+		return
+	}
+}
+
+// Close marks the channel as closed. Values already buffered can still be
+// received.
+//
+// Closing a channel twice panics.
+func (c *Chan[T]) Close() {
+	close(c.c)
+}
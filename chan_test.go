@@ -0,0 +1,50 @@
+package primitive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChanBuffered(t *testing.T) {
+	assert := assert.New(t)
+	c := NewChan[uint64](2)
+	_, ok := c.TryReceive()
+	assert.False(ok)
+	c.Send(1)
+	c.Send(2)
+	v, ok := c.TryReceive()
+	assert.True(ok)
+	assert.Equal(uint64(1), v)
+	c.Close()
+	v, ok = c.Receive()
+	assert.True(ok, "buffered value survives close")
+	assert.Equal(uint64(2), v)
+	v, ok = c.Receive()
+	assert.False(ok)
+	assert.Equal(uint64(0), v)
+	assert.Panics(func() { c.Send(3) })
+	assert.Panics(func() { c.Close() })
+}
+
+func TestChanProducerConsumer(t *testing.T) {
+	c := NewChan[uint64](0)
+	go func() {
+		for i := uint64(0); i < 100; i++ {
+			c.Send(i)
+		}
+		c.Close()
+	}()
+	var got []uint64
+	for {
+		v, ok := c.Receive()
+		if !ok {
+			break
+		}
+		got = append(got, v)
+	}
+	assert.Len(t, got, 100)
+	for i, v := range got {
+		assert.Equal(t, uint64(i), v)
+	}
+}
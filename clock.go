@@ -0,0 +1,108 @@
+package primitive
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a source of time that code can be parameterized over, so it can
+// run against real time in production and controlled time in tests.
+//
+// The model quantifies over all clocks whose Now never decreases.
+type Clock interface {
+	// Now returns the current time in nanoseconds.
+	Now() uint64
+	// Sleep blocks until at least ns nanoseconds have passed on the clock.
+	Sleep(ns uint64)
+	// After returns a channel that is closed once ns nanoseconds have passed
+	// on the clock.
+	After(ns uint64) <-chan struct{}
+}
+
+// RealClock is the Clock backed by the system's wall-clock time (TimeNow).
+type RealClock struct{}
+
+var _ Clock = RealClock{}
+
+func (RealClock) Now() uint64 {
+	return TimeNow()
+}
+
+func (RealClock) Sleep(ns uint64) {
+	Sleep(ns)
+}
+
+func (RealClock) After(ns uint64) <-chan struct{} {
+	ch := make(chan struct{})
+	time.AfterFunc(nsDuration(ns), func() { close(ch) })
+	return ch
+}
+
+type clockWaiter struct {
+	deadline uint64
+	ch       chan struct{}
+}
+
+// ManualClock is a Clock whose time only moves when Advance is called, for
+// deterministic tests. It is safe for concurrent use.
+type ManualClock struct {
+	m       sync.Mutex
+	now     uint64
+	waiters []clockWaiter
+}
+
+var _ Clock = (*ManualClock)(nil)
+
+// NewManualClock creates a ManualClock whose time starts at start.
+func NewManualClock(start uint64) *ManualClock {
+	return &ManualClock{now: start}
+}
+
+func (c *ManualClock) Now() uint64 {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.now
+}
+
+func (c *ManualClock) After(ns uint64) <-chan struct{} {
+	c.m.Lock()
+	defer c.m.Unlock()
+	ch := make(chan struct{})
+	deadline := SatAdd(c.now, ns)
+	if deadline <= c.now {
+		close(ch)
+		return ch
+	}
+	c.waiters = append(c.waiters, clockWaiter{deadline: deadline, ch: ch})
+	return ch
+}
+
+// Sleep blocks until another goroutine advances the clock by at least ns.
+func (c *ManualClock) Sleep(ns uint64) {
+	<-c.After(ns)
+}
+
+// Advance moves the clock forward by ns nanoseconds, waking every sleeper
+// whose deadline has been reached.
+func (c *ManualClock) Advance(ns uint64) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.now = SatAdd(c.now, ns)
+	remaining := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline <= c.now {
+			close(w.ch)
+		} else {
+			remaining = append(remaining, w)
+		}
+	}
+	c.waiters = remaining
+}
+
+// Waiters returns the number of pending Sleep and After calls, so tests can
+// wait for a goroutine to block before advancing the clock.
+func (c *ManualClock) Waiters() uint64 {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return uint64(len(c.waiters))
+}
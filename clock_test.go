@@ -0,0 +1,54 @@
+package primitive
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRealClock(t *testing.T) {
+	var c Clock = RealClock{}
+	start := c.Now()
+	c.Sleep(uint64(time.Millisecond))
+	<-c.After(uint64(time.Millisecond))
+	assert.GreaterOrEqual(t, c.Now()-start, uint64(2*time.Millisecond))
+}
+
+func TestManualClockAdvance(t *testing.T) {
+	c := NewManualClock(100)
+	assert.Equal(t, uint64(100), c.Now())
+
+	ch := c.After(10)
+	select {
+	case <-ch:
+		t.Fatal("fired before advancing")
+	default:
+	}
+	c.Advance(9)
+	select {
+	case <-ch:
+		t.Fatal("fired early")
+	default:
+	}
+	c.Advance(1)
+	<-ch
+	assert.Equal(t, uint64(110), c.Now())
+	assert.Equal(t, uint64(0), c.Waiters())
+
+	<-c.After(0)
+}
+
+func TestManualClockSleep(t *testing.T) {
+	c := NewManualClock(0)
+	done := make(chan struct{})
+	go func() {
+		c.Sleep(50)
+		close(done)
+	}()
+	for c.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	c.Advance(100)
+	<-done
+}
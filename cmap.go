@@ -0,0 +1,101 @@
+package primitive
+
+import "sync"
+
+// numMapShards is the number of shards in a ConcurrentMap.
+const numMapShards uint64 = 32
+
+type mapShard[V any] struct {
+	m sync.RWMutex
+	v map[uint64]V
+}
+
+// ConcurrentMap is a map from uint64 keys to values of type V that is safe
+// for concurrent use.
+//
+// In the model it is a single map on which Load, Store, and Delete are each
+// atomic. Internally keys are spread over independently locked shards, so
+// operations on different keys rarely contend.
+type ConcurrentMap[V any] struct {
+	shards [numMapShards]*mapShard[V]
+}
+
+// NewConcurrentMap creates an empty map.
+func NewConcurrentMap[V any]() *ConcurrentMap[V] {
+	cm := &ConcurrentMap[V]{}
+	for i := range cm.shards {
+		cm.shards[i] = &mapShard[V]{v: make(map[uint64]V)}
+	}
+	return cm
+}
+
+func (cm *ConcurrentMap[V]) shard(key uint64) *mapShard[V] {
+	return cm.shards[mix64(key)%numMapShards]
+}
+
+// Load returns the value stored for key. ok is false (and v the zero value)
+// if key is not present.
+func (cm *ConcurrentMap[V]) Load(key uint64) (v V, ok bool) {
+	s := cm.shard(key)
+	s.m.RLock()
+	defer s.m.RUnlock()
+	v, ok = s.v[key]
+	return
+}
+
+// Store sets the value for key to v.
+func (cm *ConcurrentMap[V]) Store(key uint64, v V) {
+	s := cm.shard(key)
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.v[key] = v
+}
+
+// Delete removes key from the map, if present.
+func (cm *ConcurrentMap[V]) Delete(key uint64) {
+	s := cm.shard(key)
+	s.m.Lock()
+	defer s.m.Unlock()
+	delete(s.v, key)
+}
+
+// Range calls f for each key and value in the map, in no particular order,
+// stopping early if f returns false.
+//
+// Range is not an atomic snapshot: each shard is visited atomically, but
+// concurrent updates to other shards may or may not be observed. f must not
+// modify the map.
+func (cm *ConcurrentMap[V]) Range(f func(key uint64, v V) bool) {
+	for _, s := range cm.shards {
+		if !s.rangeShard(f) {
+			return
+		}
+	}
+}
+
+func (s *mapShard[V]) rangeShard(f func(key uint64, v V) bool) bool {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	for k, v := range s.v {
+		if !f(k, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Snapshot returns a copy of the map's contents, a linearizable read of the
+// whole map: all shards are locked while it is copied.
+func (cm *ConcurrentMap[V]) Snapshot() map[uint64]V {
+	for _, s := range cm.shards {
+		s.m.RLock()
+	}
+	out := make(map[uint64]V)
+	for _, s := range cm.shards {
+		for k, v := range s.v {
+			out[k] = v
+		}
+		s.m.RUnlock()
+	}
+	return out
+}
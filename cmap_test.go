@@ -0,0 +1,62 @@
+package primitive
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentMap(t *testing.T) {
+	assert := assert.New(t)
+	cm := NewConcurrentMap[string]()
+	_, ok := cm.Load(1)
+	assert.False(ok)
+	cm.Store(1, "a")
+	cm.Store(2, "b")
+	cm.Store(1, "c")
+	v, ok := cm.Load(1)
+	assert.True(ok)
+	assert.Equal("c", v)
+	cm.Delete(2)
+	cm.Delete(3)
+	assert.Equal(map[uint64]string{1: "c"}, cm.Snapshot())
+}
+
+func TestConcurrentMapRange(t *testing.T) {
+	cm := NewConcurrentMap[uint64]()
+	for i := uint64(0); i < 100; i++ {
+		cm.Store(i, i*2)
+	}
+	var sum, visited uint64
+	cm.Range(func(k, v uint64) bool {
+		assert.Equal(t, k*2, v)
+		sum += k
+		visited++
+		return true
+	})
+	assert.Equal(t, uint64(4950), sum)
+	visited = 0
+	cm.Range(func(k, v uint64) bool {
+		visited++
+		return visited < 10
+	})
+	assert.Equal(t, uint64(10), visited)
+}
+
+func TestConcurrentMapConcurrent(t *testing.T) {
+	cm := NewConcurrentMap[uint64]()
+	var wg sync.WaitGroup
+	for g := uint64(0); g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := uint64(0); i < 200; i++ {
+				cm.Store(g*1000+i, i)
+				cm.Load(i)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, cm.Snapshot(), 1600)
+}
@@ -0,0 +1,57 @@
+package primitive
+
+import (
+	"os"
+	"sync"
+)
+
+// CrashPointEnv is the environment variable naming the MaybeCrash point at
+// which the process should crash, if no policy has been set with
+// SetCrashPolicy.
+const CrashPointEnv = "GOOSE_CRASH_AT"
+
+// CrashExitCode is the exit code of a process crashed by MaybeCrash.
+const CrashExitCode uint64 = 3
+
+var crashPolicy struct {
+	sync.Mutex
+	f func(label string) bool
+}
+
+// crashProcess terminates the process at a crash point. It is a variable so
+// tests can observe crashes without exiting.
+var crashProcess = func() {
+	os.Exit(int(CrashExitCode))
+}
+
+// SetCrashPolicy makes MaybeCrash(label) crash exactly when f(label) returns
+// true, taking precedence over CrashPointEnv. Passing nil restores the default
+// policy.
+func SetCrashPolicy(f func(label string) bool) {
+	crashPolicy.Lock()
+	defer crashPolicy.Unlock()
+	crashPolicy.f = f
+}
+
+// MaybeCrash marks a crash point, for exercising crash-safety by injecting real
+// crashes into extracted code.
+//
+// Modeled as a nondeterministic crash. In Go it terminates the process
+// immediately with CrashExitCode (without running exit hooks or flushing
+// anything) if the policy set by SetCrashPolicy reports true for label, or, if
+// there is no policy, if label equals the value of the CrashPointEnv
+// environment variable. Otherwise it does nothing.
+func MaybeCrash(label string) {
+	crashPolicy.Lock()
+	f := crashPolicy.f
+	crashPolicy.Unlock()
+	var crash bool
+	if f != nil {
+		crash = f(label)
+	} else {
+		crash = label != "" && os.Getenv(CrashPointEnv) == label
+	}
+	if crash {
+		crashProcess()
+	}
+}
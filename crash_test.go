@@ -0,0 +1,45 @@
+package primitive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countCrashes replaces crashProcess with a counter for the duration of the
+// test.
+func countCrashes(t *testing.T) *int {
+	var crashes int
+	orig := crashProcess
+	crashProcess = func() { crashes++ }
+	SetCrashPolicy(nil)
+	t.Cleanup(func() {
+		crashProcess = orig
+		SetCrashPolicy(nil)
+	})
+	return &crashes
+}
+
+func TestMaybeCrashEnv(t *testing.T) {
+	crashes := countCrashes(t)
+	t.Setenv(CrashPointEnv, "after-commit")
+	MaybeCrash("before-commit")
+	assert.Equal(t, 0, *crashes)
+	MaybeCrash("after-commit")
+	assert.Equal(t, 1, *crashes)
+}
+
+func TestMaybeCrashPolicy(t *testing.T) {
+	crashes := countCrashes(t)
+	t.Setenv(CrashPointEnv, "a")
+	var seen []string
+	SetCrashPolicy(func(label string) bool {
+		seen = append(seen, label)
+		return label == "b"
+	})
+	MaybeCrash("a")
+	assert.Equal(t, 0, *crashes, "policy overrides the environment")
+	MaybeCrash("b")
+	assert.Equal(t, 1, *crashes)
+	assert.Equal(t, []string{"a", "b"}, seen)
+}
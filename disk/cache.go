@@ -0,0 +1,238 @@
+package disk
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+// CacheMode selects when a CacheDisk writes to the underlying disk.
+type CacheMode uint64
+
+const (
+	// WriteThrough writes each block to the underlying disk immediately, as
+	// well as caching it.
+	WriteThrough CacheMode = iota
+	// WriteBack only caches written blocks, writing them to the underlying
+	// disk when they are evicted or at the next Barrier.
+	WriteBack
+)
+
+// CacheStats is a snapshot of the counters of a CacheDisk.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	// Writebacks counts dirty blocks written to the underlying disk, by
+	// eviction or Barrier (always 0 in WriteThrough mode).
+	Writebacks uint64
+}
+
+type cacheEntry struct {
+	a     uint64
+	v     Block
+	dirty bool
+}
+
+type blockCache struct {
+	m        sync.Mutex
+	capacity uint64
+	// lru holds *cacheEntry, most recently used first.
+	lru     *list.List
+	entries map[uint64]*list.Element
+	stats   CacheStats
+}
+
+// CacheDisk wraps a Disk with an in-memory LRU cache of up to a fixed number
+// of blocks.
+//
+// In WriteBack mode the cache holds writes that have not reached the
+// underlying disk; this does not change the disk's semantics, since writes
+// are only durable after a Barrier anyway, and Barrier writes back every
+// dirty block before issuing a Barrier to the underlying disk. The cache
+// assumes it is the only writer of the underlying disk.
+//
+// The cache is protected by a single lock, so operations on a CacheDisk are
+// atomic with respect to each other.
+type CacheDisk struct {
+	d    Disk
+	mode CacheMode
+	c    *blockCache
+}
+
+var _ Disk = CacheDisk{}
+
+// NewCacheDisk wraps d with a cache of capacityBlocks blocks, operating in
+// the given mode.
+//
+// Requires capacityBlocks > 0.
+func NewCacheDisk(d Disk, capacityBlocks uint64, mode CacheMode) CacheDisk {
+	requireBlockSize("CacheDisk", d)
+	if capacityBlocks == 0 {
+		panic("cache capacity must be positive")
+	}
+	return CacheDisk{d: d, mode: mode, c: &blockCache{
+		capacity: capacityBlocks,
+		lru:      list.New(),
+		entries:  make(map[uint64]*list.Element),
+	}}
+}
+
+// Stats returns the cache's current statistics.
+func (d CacheDisk) Stats() CacheStats {
+	d.c.m.Lock()
+	defer d.c.m.Unlock()
+	return d.c.stats
+}
+
+// insert caches v for address a as the most recently used block, evicting
+// the least recently used block if the cache is full. The caller must hold
+// the lock and have checked that a is not cached.
+func (d CacheDisk) insert(a uint64, v Block, dirty bool) {
+	c := d.c
+	if uint64(c.lru.Len()) >= c.capacity {
+		e := c.lru.Remove(c.lru.Back()).(*cacheEntry)
+		delete(c.entries, e.a)
+		c.stats.Evictions++
+		if e.dirty {
+			d.d.Write(e.a, e.v)
+			c.stats.Writebacks++
+		}
+	}
+	c.entries[a] = c.lru.PushFront(&cacheEntry{a: a, v: v, dirty: dirty})
+}
+
+func (d CacheDisk) ReadTo(a uint64, buf Block) {
+	if uint64(len(buf)) != BlockSize {
+		panic("buffer is not block-sized")
+	}
+	c := d.c
+	c.m.Lock()
+	defer c.m.Unlock()
+	if el, ok := c.entries[a]; ok {
+		c.stats.Hits++
+		c.lru.MoveToFront(el)
+		copy(buf, el.Value.(*cacheEntry).v)
+		return
+	}
+	c.stats.Misses++
+	d.d.ReadTo(a, buf)
+	d.insert(a, append(Block(nil), buf...), false)
+}
+
+func (d CacheDisk) Read(a uint64) Block {
+	buf := make(Block, BlockSize)
+	d.ReadTo(a, buf)
+	return buf
+}
+
+func (d CacheDisk) Write(a uint64, v Block) {
+	if uint64(len(v)) != BlockSize {
+		panic(fmt.Errorf("v is not block-sized (%d bytes)", len(v)))
+	}
+	c := d.c
+	c.m.Lock()
+	defer c.m.Unlock()
+	if a >= d.d.Size() {
+		panic(fmt.Errorf("out-of-bounds write at %v", a))
+	}
+	if d.mode == WriteThrough {
+		d.d.Write(a, v)
+	}
+	dirty := d.mode == WriteBack
+	if el, ok := c.entries[a]; ok {
+		c.lru.MoveToFront(el)
+		e := el.Value.(*cacheEntry)
+		copy(e.v, v)
+		e.dirty = e.dirty || dirty
+		return
+	}
+	d.insert(a, append(Block(nil), v...), dirty)
+}
+
+// Trim drops block a from the cache, discarding any dirty contents, and
+// trims it on the underlying disk.
+func (d CacheDisk) Trim(a uint64) {
+	c := d.c
+	c.m.Lock()
+	defer c.m.Unlock()
+	if el, ok := c.entries[a]; ok {
+		c.lru.Remove(el)
+		delete(c.entries, a)
+	}
+	d.d.Trim(a)
+}
+
+// Prefetch warms the cache: it reads the blocks of the range that are not
+// cached from the underlying disk, and marks those that are as recently
+// used. At most the cache's capacity of blocks, from the start of the range,
+// are loaded. Blocks loaded this way are not counted as misses.
+func (d CacheDisk) Prefetch(a uint64, count uint64) {
+	c := d.c
+	c.m.Lock()
+	defer c.m.Unlock()
+	a, n := clampRange(a, count, d.d.Size())
+	n = min(n, c.capacity)
+	if n == 0 {
+		return
+	}
+	d.d.Prefetch(a, n)
+	// load the range in reverse so that its first block ends up most recently
+	// used, and the blocks loaded first are not evicted by the later ones
+	for i := n; i > 0; i-- {
+		b := a + i - 1
+		if el, ok := c.entries[b]; ok {
+			c.lru.MoveToFront(el)
+			continue
+		}
+		d.insert(b, d.d.Read(b), false)
+	}
+}
+
+func (d CacheDisk) Size() uint64 {
+	return d.d.Size()
+}
+
+// Resize drops blocks at or past newSize from the cache and resizes the
+// underlying disk.
+func (d CacheDisk) Resize(newSize uint64) {
+	c := d.c
+	c.m.Lock()
+	defer c.m.Unlock()
+	for a, el := range c.entries {
+		if a >= newSize {
+			c.lru.Remove(el)
+			delete(c.entries, a)
+		}
+	}
+	d.d.Resize(newSize)
+}
+
+// flush writes every dirty block to the underlying disk; the caller must hold
+// the lock.
+func (d CacheDisk) flush() {
+	c := d.c
+	for el := c.lru.Front(); el != nil; el = el.Next() {
+		e := el.Value.(*cacheEntry)
+		if e.dirty {
+			d.d.Write(e.a, e.v)
+			e.dirty = false
+			c.stats.Writebacks++
+		}
+	}
+}
+
+// Barrier writes back every dirty block and then issues a Barrier to the
+// underlying disk.
+func (d CacheDisk) Barrier() {
+	d.c.m.Lock()
+	defer d.c.m.Unlock()
+	d.flush()
+	d.d.Barrier()
+}
+
+// Close closes the underlying disk. Dirty blocks are discarded, as a crash
+// would; call Barrier first to keep them.
+func (d CacheDisk) Close() {
+	d.d.Close()
+}
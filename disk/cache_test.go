@@ -0,0 +1,91 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheDiskHitsAndEviction(t *testing.T) {
+	assert := assert.New(t)
+	base := NewMemDisk(10)
+	base.Write(1, mkBlock(1))
+	d := NewCacheDisk(base, 2, WriteThrough)
+
+	assert.Equal(mkBlock(1), d.Read(1))
+	assert.Equal(mkBlock(1), d.Read(1))
+	assert.Equal(CacheStats{Hits: 1, Misses: 1}, d.Stats())
+
+	d.Read(2)
+	d.Read(3) // evicts 1
+	d.Read(1)
+	assert.Equal(CacheStats{Hits: 1, Misses: 4, Evictions: 2}, d.Stats())
+}
+
+func TestCacheDiskPrefetch(t *testing.T) {
+	assert := assert.New(t)
+	base := NewMemDisk(10)
+	base.Write(2, mkBlock(2))
+	d := NewCacheDisk(base, 3, WriteThrough)
+
+	d.Read(9)
+	d.Prefetch(1, 5) // loads only 1-3, evicting 9
+	assert.Equal(CacheStats{Misses: 1, Evictions: 1}, d.Stats())
+	assert.Equal(mkBlock(2), d.Read(2))
+	d.Read(1)
+	d.Read(3)
+	assert.Equal(uint64(3), d.Stats().Hits)
+	d.Read(9)
+	assert.Equal(uint64(2), d.Stats().Misses)
+
+	d.Prefetch(10, 1)
+	assert.Equal(uint64(2), d.Stats().Evictions, "out-of-range prefetch loads nothing")
+}
+
+func TestCacheDiskWriteThrough(t *testing.T) {
+	base := NewMemDisk(10)
+	d := NewCacheDisk(base, 4, WriteThrough)
+	d.Write(1, mkBlock(1))
+	assert.Equal(t, mkBlock(1), base.Read(1))
+	assert.Equal(t, mkBlock(1), d.Read(1))
+	assert.Equal(t, uint64(1), d.Stats().Hits, "written blocks are cached")
+}
+
+func TestCacheDiskWriteBack(t *testing.T) {
+	assert := assert.New(t)
+	base := NewMemDisk(10)
+	d := NewCacheDisk(base, 2, WriteBack)
+	d.Write(1, mkBlock(1))
+	assert.Equal(mkBlock(0), base.Read(1), "write-back should defer the write")
+	assert.Equal(mkBlock(1), d.Read(1))
+
+	d.Write(2, mkBlock(2))
+	d.Read(3) // evicts 1, writing it back
+	assert.Equal(mkBlock(1), base.Read(1))
+	assert.Equal(mkBlock(0), base.Read(2))
+
+	d.Barrier()
+	assert.Equal(mkBlock(2), base.Read(2))
+	assert.Equal(uint64(2), d.Stats().Writebacks)
+	d.Barrier()
+	assert.Equal(uint64(2), d.Stats().Writebacks, "clean blocks are not written again")
+}
+
+func TestCacheDiskTrim(t *testing.T) {
+	base := NewMemDisk(10)
+	d := NewCacheDisk(base, 2, WriteBack)
+	d.Write(1, mkBlock(1))
+	d.Trim(1)
+	assert.Equal(t, mkBlock(0), d.Read(1))
+	d.Barrier()
+	assert.Equal(t, mkBlock(0), base.Read(1), "trim should discard the dirty block")
+}
+
+func TestCacheDiskResize(t *testing.T) {
+	d := NewCacheDisk(NewMemDisk(10), 4, WriteBack)
+	d.Write(8, mkBlock(1))
+	d.Resize(5)
+	d.Resize(10)
+	assert.Equal(t, mkBlock(0), d.Read(8))
+	assert.Panics(t, func() { d.Write(10, mkBlock(1)) })
+}
@@ -0,0 +1,25 @@
+package disk
+
+// DiskError describes a block rejected by CheckDisk.
+type DiskError struct {
+	Addr   uint64
+	Reason string
+}
+
+// CheckDisk runs checker on every block of d, in address order, and reports
+// each block it rejects along with the checker's reason.
+//
+// CheckDisk is read-only: it never writes to d. It does not stop at the first
+// error, so the result lists every rejected block. A disk with no problems
+// yields an empty report.
+func CheckDisk(d Disk, checker func(a uint64, block []byte) (ok bool, reason string)) []DiskError {
+	var errs []DiskError
+	buf := make(Block, BlockSizeOf(d))
+	for a := uint64(0); a < d.Size(); a++ {
+		d.ReadTo(a, buf)
+		if ok, reason := checker(a, buf); !ok {
+			errs = append(errs, DiskError{Addr: a, Reason: reason})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,42 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func nonzeroChecker(a uint64, block []byte) (bool, string) {
+	if block[0] != 0 {
+		return false, "nonzero header"
+	}
+	return true, ""
+}
+
+func TestCheckDiskClean(t *testing.T) {
+	d := NewMemDisk(10)
+	assert.Empty(t, CheckDisk(d, nonzeroChecker))
+}
+
+func TestCheckDiskReportsAll(t *testing.T) {
+	d := NewMemDisk(10)
+	d.Write(2, mkBlock(1))
+	d.Write(7, mkBlock(2))
+	errs := CheckDisk(d, nonzeroChecker)
+	assert.Equal(t, []DiskError{
+		{Addr: 2, Reason: "nonzero header"},
+		{Addr: 7, Reason: "nonzero header"},
+	}, errs)
+}
+
+func TestCheckDiskReadOnly(t *testing.T) {
+	d := NewMemDisk(4)
+	d.Write(1, mkBlock(3))
+	CheckDisk(d, func(a uint64, block []byte) (bool, string) {
+		// mutating the buffer must not affect the disk
+		block[0] = 9
+		return true, ""
+	})
+	assert.Equal(t, mkBlock(3), d.Read(1))
+	assert.Equal(t, mkBlock(0), d.Read(2))
+}
@@ -0,0 +1,175 @@
+package disk
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/goose-lang/primitive"
+)
+
+// A ChecksumDisk stores its n data blocks at the start of the underlying
+// disk, followed by ceil(n/checksumsPerBlock) checksum blocks. The checksum
+// of data block a is stored little-endian at offset 8*(a%checksumsPerBlock)
+// of checksum block a/checksumsPerBlock, as XxHash64(block) XOR
+// XxHash64(zero block); the XOR makes an all-zero disk, including its
+// checksum blocks, valid without formatting.
+
+const checksumsPerBlock = BlockSize / 8
+
+var zeroBlockHash = primitive.XxHash64(make([]byte, BlockSize))
+
+// ChecksumError reports a block whose contents do not match its checksum.
+type ChecksumError struct {
+	Addr uint64
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum mismatch in block %d", e.Addr)
+}
+
+// ChecksumDisk wraps a Disk to store a checksum of every block and verify it
+// on each read, detecting silent corruption.
+//
+// Part of the underlying disk holds the checksums, so the ChecksumDisk is
+// slightly smaller (by 1 block per 512). A Write updates the block and then
+// its checksum under a lock on the block, so concurrent operations on a block
+// are atomic, but a crash between the two leaves a block that fails
+// verification, as does any other corruption. Resize is not
+// supported, since it would move the checksum blocks.
+type ChecksumDisk struct {
+	d Disk
+	n uint64
+	// blocks holds a lock per data block, held across the accesses to the
+	// block and to its checksum so that they are atomic together.
+	blocks *primitive.LockMap
+	// m serializes updates to the checksum blocks, each of which is shared by
+	// many data blocks.
+	m *sync.Mutex
+}
+
+var _ Disk = ChecksumDisk{}
+
+// checksumDataBlocks is the number of data blocks that fit, with their
+// checksum blocks, on a disk of size blocks.
+func checksumDataBlocks(size uint64) uint64 {
+	groups := size / (checksumsPerBlock + 1)
+	n := groups * checksumsPerBlock
+	// a leftover partial group needs its own checksum block
+	if rest := size % (checksumsPerBlock + 1); rest > 1 {
+		n += rest - 1
+	}
+	return n
+}
+
+// NewChecksumDisk wraps d. A zeroed disk is valid; otherwise d must have been
+// written through a ChecksumDisk.
+func NewChecksumDisk(d Disk) ChecksumDisk {
+	requireBlockSize("ChecksumDisk", d)
+	return ChecksumDisk{
+		d:      d,
+		n:      checksumDataBlocks(d.Size()),
+		blocks: primitive.NewLockMap(),
+		m:      new(sync.Mutex),
+	}
+}
+
+func (d ChecksumDisk) sumLocation(a uint64) (block uint64, off uint64) {
+	return d.n + a/checksumsPerBlock, 8 * (a % checksumsPerBlock)
+}
+
+// TryReadTo is like ReadTo, but returns a *ChecksumError rather than
+// panicking if the block fails verification. buf holds the (corrupt)
+// contents in that case.
+func (d ChecksumDisk) TryReadTo(a uint64, buf Block) error {
+	if a >= d.n {
+		panic(fmt.Errorf("out-of-bounds read at %v", a))
+	}
+	d.blocks.Acquire(a)
+	defer d.blocks.Release(a)
+	d.d.ReadTo(a, buf)
+	sb, off := d.sumLocation(a)
+	d.m.Lock()
+	sums := d.d.Read(sb)
+	d.m.Unlock()
+	stored := binary.LittleEndian.Uint64(sums[off:])
+	if primitive.XxHash64(buf)^zeroBlockHash != stored {
+		return &ChecksumError{Addr: a}
+	}
+	return nil
+}
+
+// ReadTo reads block a into buf, panicking with a *ChecksumError if it fails
+// verification.
+func (d ChecksumDisk) ReadTo(a uint64, buf Block) {
+	if err := d.TryReadTo(a, buf); err != nil {
+		panic(err)
+	}
+}
+
+func (d ChecksumDisk) Read(a uint64) Block {
+	buf := make(Block, BlockSize)
+	d.ReadTo(a, buf)
+	return buf
+}
+
+// setSum records sum as the stored checksum of block a.
+func (d ChecksumDisk) setSum(a uint64, sum uint64) {
+	sb, off := d.sumLocation(a)
+	d.m.Lock()
+	defer d.m.Unlock()
+	sums := d.d.Read(sb)
+	binary.LittleEndian.PutUint64(sums[off:], sum)
+	d.d.Write(sb, sums)
+}
+
+func (d ChecksumDisk) Write(a uint64, v Block) {
+	if a >= d.n {
+		panic(fmt.Errorf("out-of-bounds write at %v", a))
+	}
+	d.blocks.Acquire(a)
+	defer d.blocks.Release(a)
+	d.d.Write(a, v)
+	d.setSum(a, primitive.XxHash64(v)^zeroBlockHash)
+}
+
+// Trim trims block a, which then reads (and verifies) as zeros.
+func (d ChecksumDisk) Trim(a uint64) {
+	if a >= d.n {
+		panic(fmt.Errorf("out-of-bounds trim at %v", a))
+	}
+	d.blocks.Acquire(a)
+	defer d.blocks.Release(a)
+	d.d.Trim(a)
+	d.setSum(a, 0)
+}
+
+// Prefetch prefetches blocks [a, a+count) together with the checksum blocks
+// that verify them.
+func (d ChecksumDisk) Prefetch(a uint64, count uint64) {
+	a, n := clampRange(a, count, d.n)
+	if n == 0 {
+		return
+	}
+	d.d.Prefetch(a, n)
+	first, _ := d.sumLocation(a)
+	last, _ := d.sumLocation(a + n - 1)
+	d.d.Prefetch(first, last-first+1)
+}
+
+func (d ChecksumDisk) Size() uint64 {
+	return d.n
+}
+
+// Resize panics: a ChecksumDisk cannot be resized.
+func (d ChecksumDisk) Resize(newSize uint64) {
+	panic("ChecksumDisk does not support Resize")
+}
+
+func (d ChecksumDisk) Barrier() {
+	d.d.Barrier()
+}
+
+func (d ChecksumDisk) Close() {
+	d.d.Close()
+}
@@ -0,0 +1,84 @@
+package disk
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecksumDataBlocks(t *testing.T) {
+	for _, c := range []struct{ size, n uint64 }{
+		{0, 0},
+		{1, 0},
+		{2, 1},
+		{513, 512},
+		{514, 512},
+		{515, 513},
+		{1026, 1024},
+	} {
+		assert.Equal(t, c.n, checksumDataBlocks(c.size), "size %d", c.size)
+	}
+}
+
+func TestChecksumDiskReadWrite(t *testing.T) {
+	assert := assert.New(t)
+	d := NewChecksumDisk(NewMemDisk(600))
+	assert.Equal(uint64(598), d.Size())
+	assert.Equal(mkBlock(0), d.Read(3), "zeroed disk verifies")
+	d.Write(3, mkBlock(1))
+	d.Write(597, mkBlock(2))
+	assert.Equal(mkBlock(1), d.Read(3))
+	assert.Equal(mkBlock(2), d.Read(597))
+	d.Trim(3)
+	assert.Equal(mkBlock(0), d.Read(3))
+	assert.Panics(func() { d.Write(598, mkBlock(1)) })
+}
+
+func TestChecksumDiskDetectsCorruption(t *testing.T) {
+	assert := assert.New(t)
+	base := NewMemDisk(20)
+	d := NewChecksumDisk(base)
+	d.Write(4, mkBlock(1))
+	corrupt := mkBlock(1)
+	corrupt[100] ^= 1
+	base.Write(4, corrupt)
+	err := d.TryReadTo(4, make(Block, BlockSize))
+	assert.Equal(&ChecksumError{Addr: 4}, err)
+	assert.PanicsWithError("checksum mismatch in block 4", func() { d.Read(4) })
+
+	// corruption of an unwritten block is also detected
+	base.Write(5, mkBlock(9))
+	assert.Error(d.TryReadTo(5, make(Block, BlockSize)))
+	assert.NoError(d.TryReadTo(6, make(Block, BlockSize)))
+}
+
+// yieldDisk yields the processor around every write, to widen the windows
+// in which concurrent operations interleave.
+type yieldDisk struct {
+	Disk
+}
+
+func (d yieldDisk) Write(a uint64, v Block) {
+	runtime.Gosched()
+	d.Disk.Write(a, v)
+	runtime.Gosched()
+}
+
+func TestChecksumDiskConcurrentSameBlock(t *testing.T) {
+	d := NewChecksumDisk(yieldDisk{NewMemDisk(10)})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(x byte) {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				d.Write(1, mkBlock(x))
+				assert.NoError(t, d.TryReadTo(1, make(Block, BlockSize)))
+			}
+		}(byte(i + 1))
+	}
+	wg.Wait()
+	assert.NoError(t, d.TryReadTo(1, make(Block, BlockSize)), "last write and its checksum should match")
+}
@@ -0,0 +1,48 @@
+package disk
+
+import (
+	"fmt"
+	"sort"
+)
+
+// WriteRun is a write of consecutive blocks starting at Start, with Data
+// holding the concatenated contents of the blocks.
+type WriteRun struct {
+	Start uint64
+	Data  []byte
+}
+
+// NumBlocks is the number of blocks covered by the run.
+func (r WriteRun) NumBlocks() uint64 {
+	return uint64(len(r.Data)) / BlockSize
+}
+
+// CoalesceWrites groups a set of single-block writes into runs of contiguous
+// addresses.
+//
+// Each maximal run of consecutive addresses becomes one WriteRun, and the runs
+// are sorted by start address. The data is copied, so the result does not
+// alias the blocks in writes. Every block must be BlockSize bytes.
+func CoalesceWrites(writes map[uint64][]byte) []WriteRun {
+	addrs := make([]uint64, 0, len(writes))
+	for a, b := range writes {
+		if uint64(len(b)) != BlockSize {
+			panic(fmt.Errorf("write to %d is not block-sized (%d bytes)", a, len(b)))
+		}
+		addrs = append(addrs, a)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i] < addrs[j] })
+	var runs []WriteRun
+	for i, a := range addrs {
+		if i > 0 && addrs[i-1]+1 == a {
+			r := &runs[len(runs)-1]
+			r.Data = append(r.Data, writes[a]...)
+			continue
+		}
+		runs = append(runs, WriteRun{
+			Start: a,
+			Data:  append([]byte(nil), writes[a]...),
+		})
+	}
+	return runs
+}
@@ -0,0 +1,53 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func concatBlocks(bs ...Block) []byte {
+	var data []byte
+	for _, b := range bs {
+		data = append(data, b...)
+	}
+	return data
+}
+
+func TestCoalesceContiguous(t *testing.T) {
+	runs := CoalesceWrites(map[uint64][]byte{
+		6: mkBlock(6),
+		4: mkBlock(4),
+		5: mkBlock(5),
+	})
+	assert.Equal(t, []WriteRun{
+		{Start: 4, Data: concatBlocks(mkBlock(4), mkBlock(5), mkBlock(6))},
+	}, runs)
+	assert.Equal(t, uint64(3), runs[0].NumBlocks())
+}
+
+func TestCoalesceDisjoint(t *testing.T) {
+	runs := CoalesceWrites(map[uint64][]byte{
+		9: mkBlock(9),
+		1: mkBlock(1),
+		2: mkBlock(2),
+		5: mkBlock(5),
+	})
+	assert.Equal(t, []WriteRun{
+		{Start: 1, Data: concatBlocks(mkBlock(1), mkBlock(2))},
+		{Start: 5, Data: concatBlocks(mkBlock(5))},
+		{Start: 9, Data: concatBlocks(mkBlock(9))},
+	}, runs)
+}
+
+func TestCoalesceSingle(t *testing.T) {
+	b := mkBlock(3)
+	runs := CoalesceWrites(map[uint64][]byte{7: b})
+	assert.Equal(t, []WriteRun{{Start: 7, Data: mkBlock(3)}}, runs)
+	runs[0].Data[0] = 42
+	assert.Equal(t, byte(3), b[0], "runs should not alias input")
+}
+
+func TestCoalesceEmpty(t *testing.T) {
+	assert.Empty(t, CoalesceWrites(nil))
+}
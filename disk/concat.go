@@ -0,0 +1,208 @@
+package disk
+
+import "fmt"
+
+// ConcatDisk presents several disks end to end as one larger disk: its first
+// blocks are those of the first disk, followed by those of the second, and so
+// on.
+type ConcatDisk struct {
+	ds []Disk
+}
+
+var _ Disk = ConcatDisk{}
+
+// NewConcatDisk concatenates ds, in order.
+func NewConcatDisk(ds ...Disk) ConcatDisk {
+	for _, d := range ds {
+		requireBlockSize("ConcatDisk", d)
+	}
+	return ConcatDisk{ds: append([]Disk(nil), ds...)}
+}
+
+// locate maps address a to a component disk and an address within it.
+func (d ConcatDisk) locate(a uint64) (Disk, uint64) {
+	off := a
+	for _, c := range d.ds {
+		if off < c.Size() {
+			return c, off
+		}
+		off -= c.Size()
+	}
+	panic(fmt.Errorf("out-of-bounds access at %v", a))
+}
+
+func (d ConcatDisk) ReadTo(a uint64, buf Block) {
+	c, off := d.locate(a)
+	c.ReadTo(off, buf)
+}
+
+func (d ConcatDisk) Read(a uint64) Block {
+	c, off := d.locate(a)
+	return c.Read(off)
+}
+
+func (d ConcatDisk) Write(a uint64, v Block) {
+	c, off := d.locate(a)
+	c.Write(off, v)
+}
+
+func (d ConcatDisk) Trim(a uint64) {
+	c, off := d.locate(a)
+	c.Trim(off)
+}
+
+func (d ConcatDisk) Prefetch(a uint64, count uint64) {
+	off := a
+	for _, c := range d.ds {
+		if count == 0 {
+			return
+		}
+		size := c.Size()
+		if off >= size {
+			off -= size
+			continue
+		}
+		n := min(count, size-off)
+		c.Prefetch(off, n)
+		off, count = 0, count-n
+	}
+}
+
+func (d ConcatDisk) Size() uint64 {
+	var n uint64
+	for _, c := range d.ds {
+		n += c.Size()
+	}
+	return n
+}
+
+// Resize resizes the last disk, which is the only one whose size can change
+// without moving other blocks.
+//
+// Requires newSize to be at least the total size of the other disks.
+func (d ConcatDisk) Resize(newSize uint64) {
+	if len(d.ds) == 0 {
+		panic("cannot resize an empty ConcatDisk")
+	}
+	last := d.ds[len(d.ds)-1]
+	others := d.Size() - last.Size()
+	if newSize < others {
+		panic(fmt.Errorf("cannot shrink ConcatDisk below %d blocks", others))
+	}
+	last.Resize(newSize - others)
+}
+
+func (d ConcatDisk) Barrier() {
+	for _, c := range d.ds {
+		c.Barrier()
+	}
+}
+
+func (d ConcatDisk) Close() {
+	for _, c := range d.ds {
+		c.Close()
+	}
+}
+
+// StripeDisk stripes blocks across several disks (RAID-0) to aggregate their
+// bandwidth: the disk is divided into stripe units of stripeBlocks blocks,
+// and consecutive units go to consecutive disks, round-robin.
+//
+// Its size is a whole number of stripes across all disks, limited by the
+// smallest one; blocks beyond that on larger disks are unused.
+type StripeDisk struct {
+	stripeBlocks uint64
+	ds           []Disk
+}
+
+var _ Disk = StripeDisk{}
+
+// NewStripeDisk stripes across ds in units of stripeBlocks blocks.
+//
+// Requires stripeBlocks > 0 and at least one disk.
+func NewStripeDisk(stripeBlocks uint64, ds ...Disk) StripeDisk {
+	if stripeBlocks == 0 || len(ds) == 0 {
+		panic("StripeDisk needs a positive stripe size and at least one disk")
+	}
+	for _, d := range ds {
+		requireBlockSize("StripeDisk", d)
+	}
+	return StripeDisk{stripeBlocks: stripeBlocks, ds: append([]Disk(nil), ds...)}
+}
+
+// locate maps address a to a component disk and an address within it.
+func (d StripeDisk) locate(a uint64) (Disk, uint64) {
+	if a >= d.Size() {
+		panic(fmt.Errorf("out-of-bounds access at %v", a))
+	}
+	unit, off := a/d.stripeBlocks, a%d.stripeBlocks
+	n := uint64(len(d.ds))
+	return d.ds[unit%n], (unit/n)*d.stripeBlocks + off
+}
+
+func (d StripeDisk) ReadTo(a uint64, buf Block) {
+	c, off := d.locate(a)
+	c.ReadTo(off, buf)
+}
+
+func (d StripeDisk) Read(a uint64) Block {
+	c, off := d.locate(a)
+	return c.Read(off)
+}
+
+func (d StripeDisk) Write(a uint64, v Block) {
+	c, off := d.locate(a)
+	c.Write(off, v)
+}
+
+func (d StripeDisk) Trim(a uint64) {
+	c, off := d.locate(a)
+	c.Trim(off)
+}
+
+// Prefetch prefetches the range one stripe unit at a time, each from the disk
+// that holds it.
+func (d StripeDisk) Prefetch(a uint64, count uint64) {
+	a, n := clampRange(a, count, d.Size())
+	for n > 0 {
+		c, off := d.locate(a)
+		k := min(n, d.stripeBlocks-a%d.stripeBlocks)
+		c.Prefetch(off, k)
+		a, n = a+k, n-k
+	}
+}
+
+func (d StripeDisk) Size() uint64 {
+	smallest := d.ds[0].Size()
+	for _, c := range d.ds[1:] {
+		smallest = min(smallest, c.Size())
+	}
+	units := smallest / d.stripeBlocks
+	return units * d.stripeBlocks * uint64(len(d.ds))
+}
+
+// Resize resizes every disk to hold newSize blocks of stripes.
+//
+// Requires newSize to be a multiple of the stripe width (stripeBlocks times
+// the number of disks).
+func (d StripeDisk) Resize(newSize uint64) {
+	width := d.stripeBlocks * uint64(len(d.ds))
+	if newSize%width != 0 {
+		panic(fmt.Errorf("size %d is not a multiple of the stripe width %d", newSize, width))
+	}
+	for _, c := range d.ds {
+		c.Resize(newSize / uint64(len(d.ds)))
+	}
+}
+
+func (d StripeDisk) Barrier() {
+	for _, c := range d.ds {
+		c.Barrier()
+	}
+}
+
+func (d StripeDisk) Close() {
+	for _, c := range d.ds {
+		c.Close()
+	}
+}
@@ -0,0 +1,50 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcatDisk(t *testing.T) {
+	assert := assert.New(t)
+	d1, d2 := NewMemDisk(3), NewMemDisk(5)
+	d := NewConcatDisk(d1, d2)
+	assert.Equal(uint64(8), d.Size())
+	d.Write(2, mkBlock(1))
+	d.Write(3, mkBlock(2))
+	d.Write(7, mkBlock(3))
+	assert.Equal(mkBlock(1), d1.Read(2))
+	assert.Equal(mkBlock(2), d2.Read(0))
+	assert.Equal(mkBlock(3), d2.Read(4))
+	assert.Equal(mkBlock(3), d.Read(7))
+	assert.Panics(func() { d.Read(8) })
+
+	d.Resize(10)
+	assert.Equal(uint64(7), d2.Size())
+	assert.Panics(func() { d.Resize(2) })
+}
+
+func TestStripeDisk(t *testing.T) {
+	assert := assert.New(t)
+	d1, d2 := NewMemDisk(5), NewMemDisk(4)
+	d := NewStripeDisk(2, d1, d2)
+	assert.Equal(uint64(8), d.Size(), "two full stripes of 2x2 blocks")
+	for a := uint64(0); a < 8; a++ {
+		d.Write(a, mkBlock(byte(a+1)))
+	}
+	// units: [0,1]->d1[0,1], [2,3]->d2[0,1], [4,5]->d1[2,3], [6,7]->d2[2,3]
+	assert.Equal(mkBlock(2), d1.Read(1))
+	assert.Equal(mkBlock(3), d2.Read(0))
+	assert.Equal(mkBlock(5), d1.Read(2))
+	assert.Equal(mkBlock(8), d2.Read(3))
+	assert.Equal(mkBlock(0), d1.Read(4), "unused tail block")
+	for a := uint64(0); a < 8; a++ {
+		assert.Equal(mkBlock(byte(a+1)), d.Read(a))
+	}
+	assert.Panics(func() { d.Read(8) })
+
+	d.Resize(12)
+	assert.Equal(uint64(12), d.Size())
+	assert.Panics(func() { d.Resize(5) })
+}
@@ -0,0 +1,36 @@
+package disk
+
+import "fmt"
+
+// ConditionalWriter is implemented by disks that can compare-and-write a
+// block atomically with respect to all other operations on the disk, like
+// the NVMe Compare and Write fused command.
+type ConditionalWriter interface {
+	// ConditionalWrite writes v to address a if block a currently holds
+	// expected, and reports whether it did.
+	ConditionalWrite(a uint64, expected Block, v Block) bool
+}
+
+// ConditionalWrite writes v to address a of d if block a currently holds
+// expected, and reports whether it did.
+//
+// Modeled as an atomic compare-and-write. Requires d to implement
+// ConditionalWriter, and panics otherwise; wrap a disk that does not in a
+// LockedDisk, which does.
+func ConditionalWrite(d Disk, a uint64, expected Block, v Block) bool {
+	cw, ok := d.(ConditionalWriter)
+	if !ok {
+		panic(fmt.Errorf("%T does not support ConditionalWrite", d))
+	}
+	return cw.ConditionalWrite(a, expected, v)
+}
+
+// checkConditional validates the buffers passed to a ConditionalWrite.
+func checkConditional(blockSize uint64, expected Block, v Block) {
+	if uint64(len(expected)) != blockSize {
+		panic(fmt.Errorf("expected is not block-sized (%d bytes)", len(expected)))
+	}
+	if uint64(len(v)) != blockSize {
+		panic(fmt.Errorf("v is not block-sized (%d bytes)", len(v)))
+	}
+}
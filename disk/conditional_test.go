@@ -0,0 +1,57 @@
+package disk
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *DiskSuite) TestConditionalWrite() {
+	d := suite.D
+	if _, ok := d.(ConditionalWriter); !ok {
+		suite.Panics(func() { ConditionalWrite(d, 3, block0, block1) })
+		d = NewLockedDisk(d)
+	}
+	d.Write(3, block1)
+	suite.False(ConditionalWrite(d, 3, block2, block0))
+	suite.Equal(block1, d.Read(3), "failed compare should not write")
+	suite.True(ConditionalWrite(d, 3, block1, block2))
+	suite.Equal(block2, d.Read(3))
+	suite.Panics(func() { ConditionalWrite(d, 100, block0, block1) })
+}
+
+// testConditionalCounter increments a counter in the first byte of block 0 from many
+// goroutines using compare-and-write, checking that no increment is lost.
+func testConditionalCounter(t *testing.T, d Disk) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				for {
+					old := d.Read(0)
+					v := append(Block(nil), old...)
+					v[0]++
+					if ConditionalWrite(d, 0, old, v) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, byte(80), d.Read(0)[0])
+}
+
+func TestConditionalWriteConcurrent(t *testing.T) {
+	testConditionalCounter(t, NewMemDisk(1))
+}
+
+func TestConditionalWriteUnsupported(t *testing.T) {
+	// EventDisk does not implement ConditionalWriter
+	d := NewEventDisk(NewMemDisk(1), nil)
+	assert.Panics(t, func() { ConditionalWrite(d, 0, block0, block1) })
+	testConditionalCounter(t, NewLockedDisk(d))
+}
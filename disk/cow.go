@@ -0,0 +1,151 @@
+package disk
+
+import (
+	"fmt"
+	"sync"
+)
+
+// CowDisk is a copy-on-write clone of a read-only base disk.
+//
+// Blocks that have never been written through the CowDisk are read from the
+// base. The first write to a block copies it into the overlay, and from then
+// on reads and writes of that block use only the overlay; the base is never
+// modified. Because writes replace a whole block, the copy is simply the newly
+// written contents.
+//
+// Which blocks have been copied is tracked in memory, so a CowDisk does not
+// survive a crash: after restarting, the overlay's contents cannot be told
+// apart from blocks that were never written.
+type CowDisk struct {
+	base    Disk
+	overlay Disk
+	m       *sync.RWMutex
+	copied  map[uint64]bool
+	// size is the CowDisk's own size, which starts as the base's and then
+	// changes with Resize.
+	size *uint64
+}
+
+var _ Disk = CowDisk{}
+
+// NewCowDisk creates a clone of base that stores modified blocks in overlay.
+//
+// Requires overlay.Size() >= base.Size().
+func NewCowDisk(base Disk, overlay Disk) CowDisk {
+	requireBlockSize("CowDisk", base)
+	requireBlockSize("CowDisk", overlay)
+	if overlay.Size() < base.Size() {
+		panic(fmt.Errorf("overlay too small (%d < %d blocks)",
+			overlay.Size(), base.Size()))
+	}
+	size := base.Size()
+	return CowDisk{
+		base:    base,
+		overlay: overlay,
+		m:       new(sync.RWMutex),
+		copied:  make(map[uint64]bool),
+		size:    &size,
+	}
+}
+
+func (d CowDisk) ReadTo(a uint64, buf Block) {
+	d.m.RLock()
+	defer d.m.RUnlock()
+	if a >= *d.size {
+		panic(fmt.Errorf("out-of-bounds read at %v", a))
+	}
+	if d.copied[a] {
+		d.overlay.ReadTo(a, buf)
+		return
+	}
+	d.base.ReadTo(a, buf)
+}
+
+func (d CowDisk) Read(a uint64) Block {
+	buf := make(Block, BlockSize)
+	d.ReadTo(a, buf)
+	return buf
+}
+
+func (d CowDisk) Write(a uint64, v Block) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if a >= *d.size {
+		panic(fmt.Errorf("out-of-bounds write at %v", a))
+	}
+	d.overlay.Write(a, v)
+	d.copied[a] = true
+}
+
+// Trim trims block a in the overlay; from then on the block is read from the
+// overlay, so the base's contents are no longer visible.
+func (d CowDisk) Trim(a uint64) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if a >= *d.size {
+		panic(fmt.Errorf("out-of-bounds trim at %v", a))
+	}
+	d.overlay.Trim(a)
+	d.copied[a] = true
+}
+
+// Prefetch prefetches each block from wherever it would be read: the overlay
+// for copied blocks and the base for the rest.
+func (d CowDisk) Prefetch(a uint64, count uint64) {
+	d.m.RLock()
+	defer d.m.RUnlock()
+	a, n := clampRange(a, count, *d.size)
+	for n > 0 {
+		// the run of blocks starting at a that are read from the same disk
+		copied := d.copied[a]
+		run := uint64(1)
+		for run < n && d.copied[a+run] == copied {
+			run++
+		}
+		if copied {
+			d.overlay.Prefetch(a, run)
+		} else {
+			d.base.Prefetch(a, run)
+		}
+		a, n = a+run, n-run
+	}
+}
+
+func (d CowDisk) Size() uint64 {
+	d.m.RLock()
+	defer d.m.RUnlock()
+	return *d.size
+}
+
+// Resize changes the size of the clone; the base is not modified.
+//
+// Blocks added by growing are zero-filled in the overlay (which is grown if
+// needed) and treated as copied, so they never expose the base's contents,
+// even for addresses the base has.
+func (d CowDisk) Resize(newSize uint64) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if d.overlay.Size() < newSize {
+		d.overlay.Resize(newSize)
+	}
+	for a := range d.copied {
+		if a >= newSize {
+			delete(d.copied, a)
+		}
+	}
+	zero := make(Block, BlockSize)
+	for a := *d.size; a < newSize; a++ {
+		d.overlay.Write(a, zero)
+		d.copied[a] = true
+	}
+	*d.size = newSize
+}
+
+func (d CowDisk) Barrier() {
+	d.overlay.Barrier()
+}
+
+// Close closes the overlay. The base is shared and is left open.
+func (d CowDisk) Close() {
+	d.overlay.Close()
+}
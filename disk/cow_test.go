@@ -0,0 +1,73 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCowDiskReadThrough(t *testing.T) {
+	base := NewMemDisk(10)
+	base.Write(1, mkBlock(1))
+	d := NewCowDisk(base, NewMemDisk(10))
+	assert.Equal(t, mkBlock(1), d.Read(1))
+	assert.Equal(t, mkBlock(0), d.Read(2))
+	assert.Equal(t, uint64(10), d.Size())
+}
+
+func TestCowDiskWriteCopies(t *testing.T) {
+	assert := assert.New(t)
+	base := NewMemDisk(10)
+	base.Write(1, mkBlock(1))
+	base.Write(2, mkBlock(2))
+	overlay := NewMemDisk(10)
+	d := NewCowDisk(base, overlay)
+
+	d.Write(1, mkBlock(3))
+	assert.Equal(mkBlock(3), d.Read(1))
+	assert.Equal(mkBlock(3), overlay.Read(1), "write should be copied to the overlay")
+	assert.Equal(mkBlock(1), base.Read(1), "base should be unchanged")
+	assert.Equal(mkBlock(2), d.Read(2), "unwritten blocks read through")
+
+	// later base changes are not visible for copied blocks
+	base.Write(1, mkBlock(4))
+	assert.Equal(mkBlock(3), d.Read(1))
+}
+
+func TestCowDiskOverlayTooSmall(t *testing.T) {
+	assert.Panics(t, func() { NewCowDisk(NewMemDisk(10), NewMemDisk(5)) })
+}
+
+func TestCowDiskWriteOob(t *testing.T) {
+	d := NewCowDisk(NewMemDisk(5), NewMemDisk(10))
+	assert.Panics(t, func() { d.Write(5, mkBlock(1)) })
+}
+
+func TestCowDiskResize(t *testing.T) {
+	assert := assert.New(t)
+	base := NewMemDisk(5)
+	base.Write(3, mkBlock(1))
+	base.Write(4, mkBlock(2))
+	d := NewCowDisk(base, NewMemDisk(5))
+
+	d.Resize(4)
+	assert.Equal(uint64(4), d.Size())
+	assert.Panics(func() { d.Read(4) })
+
+	d.Resize(8)
+	assert.Equal(uint64(8), d.Size())
+	assert.Equal(mkBlock(1), d.Read(3))
+	assert.Equal(mkBlock(0), d.Read(4), "regrown block should not expose the base")
+	d.Write(7, mkBlock(3))
+	assert.Equal(mkBlock(3), d.Read(7))
+	assert.Equal(uint64(5), base.Size(), "base should be unchanged")
+}
+
+func TestCowDiskTrim(t *testing.T) {
+	base := NewMemDisk(5)
+	base.Write(1, mkBlock(1))
+	d := NewCowDisk(base, NewMemDisk(5))
+	d.Trim(1)
+	assert.Equal(t, mkBlock(0), d.Read(1))
+	assert.Equal(t, mkBlock(1), base.Read(1), "base should be unchanged")
+}
@@ -0,0 +1,228 @@
+package disk
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// cowFanout is the number of children of each interior node of a
+// COWMemDisk's tree.
+const cowFanout = 64
+
+// cowNode is a node of a COWMemDisk's tree: an interior node (children) or,
+// at height 0, a leaf holding one block. A nil child reads as zeros.
+type cowNode struct {
+	// owner is the id of the only disk that may modify the node in place;
+	// any other disk sharing it must copy it first.
+	owner    uint64
+	children [cowFanout]*cowNode
+	block    []byte
+}
+
+// cowIDs hands out owner ids; 0 is never used.
+var cowIDs atomic.Uint64
+
+type cowState struct {
+	m      sync.RWMutex
+	root   *cowNode
+	height uint64
+	size   uint64
+	owner  uint64
+}
+
+// COWMemDisk is an in-memory disk whose Clone takes constant time: the clone
+// shares all of its blocks with the original, and each disk copies a block
+// only when it writes to it.
+//
+// The blocks are held in a 64-ary tree that is copied along the path to a
+// block on its first write after a Clone, so reads and writes take time
+// logarithmic in the disk's size, and a branch costs memory proportional to
+// the blocks written on it. Unwritten and trimmed blocks take no memory.
+//
+// Blocks are always BlockSize bytes; unlike MemDisk there is no constructor
+// for other block sizes, and COWMemDisk does not implement BlockSizer.
+type COWMemDisk struct {
+	s *cowState
+}
+
+var _ Disk = COWMemDisk{}
+
+// NewCOWMemDisk creates a zero-filled disk of numBlocks blocks.
+func NewCOWMemDisk(numBlocks uint64) COWMemDisk {
+	s := &cowState{owner: cowIDs.Add(1)}
+	s.grow(numBlocks)
+	return COWMemDisk{s: s}
+}
+
+// span returns the number of blocks under a node of height h.
+func cowSpan(h uint64) uint64 {
+	span := uint64(1)
+	for ; h > 0; h-- {
+		span *= cowFanout
+	}
+	return span
+}
+
+// grow raises the tree's height until it can address size blocks, and sets
+// the size; the caller must hold the lock for writing, and size must be at
+// least the current size.
+func (s *cowState) grow(size uint64) {
+	for cowSpan(s.height) < size {
+		if s.root != nil {
+			s.root = &cowNode{owner: s.owner, children: [cowFanout]*cowNode{s.root}}
+		}
+		s.height++
+	}
+	s.size = size
+}
+
+// own makes *n a node the disk may modify, copying it if it is shared (or
+// creating it if it is nil).
+func (s *cowState) own(n **cowNode) *cowNode {
+	if *n == nil {
+		*n = &cowNode{owner: s.owner}
+	} else if (*n).owner != s.owner {
+		c := **n
+		c.owner = s.owner
+		if c.block != nil {
+			c.block = append([]byte(nil), c.block...)
+		}
+		*n = &c
+	}
+	return *n
+}
+
+// leaf returns the slot holding block a, owning each node along the path;
+// the caller must hold the lock for writing.
+func (s *cowState) leaf(a uint64) **cowNode {
+	n := &s.root
+	for h := s.height; h > 0; h-- {
+		span := cowSpan(h - 1)
+		n = &s.own(n).children[(a/span)%cowFanout]
+	}
+	return n
+}
+
+// lookupLeaf returns the leaf holding block a, or nil if it is zero; the
+// caller must hold the lock.
+func (s *cowState) lookupLeaf(a uint64) *cowNode {
+	n := s.root
+	for h := s.height; h > 0 && n != nil; h-- {
+		n = n.children[(a/cowSpan(h-1))%cowFanout]
+	}
+	return n
+}
+
+// lookup returns the block at a, or nil if it is zero; the caller must hold
+// the lock.
+func (s *cowState) lookup(a uint64) []byte {
+	if n := s.lookupLeaf(a); n != nil {
+		return n.block
+	}
+	return nil
+}
+
+// truncate drops every block at or past size from the subtree *n of height h
+// whose first block is base.
+func (s *cowState) truncate(n **cowNode, h uint64, base uint64, size uint64) {
+	if *n == nil || base+cowSpan(h) <= size {
+		return
+	}
+	if base >= size {
+		*n = nil
+		return
+	}
+	node := s.own(n)
+	span := cowSpan(h - 1)
+	for i := range node.children {
+		s.truncate(&node.children[i], h-1, base+uint64(i)*span, size)
+	}
+}
+
+func (d COWMemDisk) ReadTo(a uint64, buf Block) {
+	if uint64(len(buf)) != BlockSize {
+		panic("buffer is not block-sized")
+	}
+	d.s.m.RLock()
+	defer d.s.m.RUnlock()
+	if a >= d.s.size {
+		panic(fmt.Errorf("out-of-bounds read at %v", a))
+	}
+	if b := d.s.lookup(a); b != nil {
+		copy(buf, b)
+	} else {
+		clear(buf)
+	}
+}
+
+func (d COWMemDisk) Read(a uint64) Block {
+	buf := make(Block, BlockSize)
+	d.ReadTo(a, buf)
+	return buf
+}
+
+func (d COWMemDisk) Write(a uint64, v Block) {
+	if uint64(len(v)) != BlockSize {
+		panic(fmt.Errorf("v is not block-sized (%d bytes)", len(v)))
+	}
+	d.s.m.Lock()
+	defer d.s.m.Unlock()
+	if a >= d.s.size {
+		panic(fmt.Errorf("out-of-bounds write at %v", a))
+	}
+	n := d.s.leaf(a)
+	if *n != nil && (*n).owner == d.s.owner {
+		copy((*n).block, v)
+		return
+	}
+	*n = &cowNode{owner: d.s.owner, block: append([]byte(nil), v...)}
+}
+
+// Trim zeroes block a, releasing its memory if no clone shares it.
+func (d COWMemDisk) Trim(a uint64) {
+	d.s.m.Lock()
+	defer d.s.m.Unlock()
+	if a >= d.s.size {
+		panic(fmt.Errorf("out-of-bounds trim at %v", a))
+	}
+	*d.s.leaf(a) = nil
+}
+
+// Prefetch does nothing, since the whole disk is already in memory.
+func (d COWMemDisk) Prefetch(a uint64, count uint64) {}
+
+func (d COWMemDisk) Size() uint64 {
+	d.s.m.RLock()
+	defer d.s.m.RUnlock()
+	return d.s.size
+}
+
+func (d COWMemDisk) Resize(newSize uint64) {
+	s := d.s
+	s.m.Lock()
+	defer s.m.Unlock()
+	if newSize < s.size {
+		s.truncate(&s.root, s.height, 0, newSize)
+		s.size = newSize
+		return
+	}
+	s.grow(newSize)
+}
+
+// Clone returns an independent copy of the disk in constant time. Later
+// writes to either disk do not affect the other; the first write to each
+// shared block copies it.
+func (d COWMemDisk) Clone() COWMemDisk {
+	s := d.s
+	s.m.Lock()
+	defer s.m.Unlock()
+	c := &cowState{root: s.root, height: s.height, size: s.size, owner: cowIDs.Add(1)}
+	// the nodes are now shared, so neither disk may modify them in place
+	s.owner = cowIDs.Add(1)
+	return COWMemDisk{s: c}
+}
+
+func (d COWMemDisk) Barrier() {}
+
+func (d COWMemDisk) Close() {}
@@ -0,0 +1,95 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCOWMemDisk(t *testing.T) {
+	assert := assert.New(t)
+	d := NewCOWMemDisk(10000)
+	assert.Equal(uint64(10000), d.Size())
+	assert.Equal(mkBlock(0), d.Read(9999))
+	d.Write(9999, mkBlock(1))
+	d.Write(9999, mkBlock(2))
+	assert.Equal(mkBlock(2), d.Read(9999))
+	d.Trim(9999)
+	assert.Equal(mkBlock(0), d.Read(9999))
+	assert.Panics(func() { d.Write(10000, mkBlock(1)) })
+}
+
+func TestCOWMemDiskClone(t *testing.T) {
+	assert := assert.New(t)
+	d := NewCOWMemDisk(100)
+	d.Write(1, mkBlock(1))
+	c := d.Clone()
+	assert.Equal(mkBlock(1), c.Read(1))
+
+	d.Write(1, mkBlock(2))
+	c.Write(2, mkBlock(3))
+	assert.Equal(mkBlock(1), c.Read(1), "clone is unaffected by later writes")
+	assert.Equal(mkBlock(0), d.Read(2), "original is unaffected by writes to the clone")
+
+	// branching repeatedly from the same state
+	var branches []COWMemDisk
+	for i := 0; i < 10; i++ {
+		b := d.Clone()
+		b.Write(5, mkBlock(byte(i)))
+		branches = append(branches, b)
+	}
+	for i, b := range branches {
+		assert.Equal(mkBlock(byte(i)), b.Read(5))
+		assert.Equal(mkBlock(2), b.Read(1))
+	}
+	assert.Equal(mkBlock(0), d.Read(5))
+}
+
+func TestCOWMemDiskResize(t *testing.T) {
+	assert := assert.New(t)
+	d := NewCOWMemDisk(5)
+	d.Write(4, mkBlock(1))
+	c := d.Clone()
+
+	d.Resize(1000)
+	d.Write(999, mkBlock(2))
+	assert.Equal(mkBlock(1), d.Read(4), "growing keeps the contents")
+	assert.Equal(uint64(5), c.Size())
+
+	d.Resize(4)
+	assert.Panics(func() { d.Read(4) })
+	d.Resize(1000)
+	assert.Equal(mkBlock(0), d.Read(4), "regrown blocks read as zeros")
+	assert.Equal(mkBlock(0), d.Read(999))
+	assert.Equal(mkBlock(1), c.Read(4), "the clone keeps its blocks")
+}
+
+func TestCOWMemDiskRandom(t *testing.T) {
+	// compare against MemDisk under random writes, trims and clones
+	d := NewCOWMemDisk(300)
+	ref := NewMemDisk(300)
+	var clones []COWMemDisk
+	var refs []MemDisk
+	x := uint64(1)
+	for i := 0; i < 2000; i++ {
+		x = x*6364136223846793005 + 1442695040888963407
+		a := (x >> 33) % 300
+		switch (x >> 20) % 8 {
+		case 0:
+			d.Trim(a)
+			ref.Trim(a)
+		case 1:
+			clones = append(clones, d.Clone())
+			refs = append(refs, ref.Clone())
+		default:
+			d.Write(a, mkBlock(byte(i)))
+			ref.Write(a, mkBlock(byte(i)))
+		}
+	}
+	for a := uint64(0); a < 300; a++ {
+		assert.Equal(t, ref.Read(a), d.Read(a))
+		for i := range clones {
+			assert.Equal(t, refs[i].Read(a), clones[i].Read(a))
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package disk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDelayDiskFixed(t *testing.T) {
+	ms := uint64(time.Millisecond)
+	fixed := FixedLatency(0, 0, 5*ms)
+	var delays []uint64
+	d := NewDelayDisk(NewMemDisk(10), func(op DiskOp, a uint64) uint64 {
+		ns := fixed(op, a)
+		delays = append(delays, ns)
+		return ns
+	})
+	d.Write(1, mkBlock(1))
+	assert.Equal(t, mkBlock(1), d.Read(1))
+	assert.Equal(t, []uint64{0, 0}, delays, "reads and writes are not delayed")
+	start := time.Now()
+	d.Barrier()
+	assert.GreaterOrEqual(t, time.Since(start), 5*time.Millisecond)
+	assert.Equal(t, []uint64{0, 0, 5 * ms}, delays)
+}
+
+func TestDelayDiskHook(t *testing.T) {
+	var ops []DiskOp
+	d := NewDelayDisk(NewMemDisk(10), func(op DiskOp, a uint64) uint64 {
+		ops = append(ops, op)
+		return 0
+	})
+	d.Write(1, mkBlock(1))
+	d.ReadTo(1, make(Block, BlockSize))
+	d.Trim(1)
+	d.Barrier()
+	assert.Equal(t, []DiskOp{OpWrite, OpRead, OpTrim, OpBarrier}, ops)
+}
+
+func TestUniformLatency(t *testing.T) {
+	f := UniformLatency(10, 20, 1)
+	seen := map[uint64]bool{}
+	for i := 0; i < 1000; i++ {
+		ns := f(OpRead, 0)
+		assert.GreaterOrEqual(t, ns, uint64(10))
+		assert.LessOrEqual(t, ns, uint64(20))
+		seen[ns] = true
+	}
+	assert.Len(t, seen, 11)
+	assert.Equal(t, uint64(7), UniformLatency(7, 7, 1)(OpWrite, 0))
+}
@@ -0,0 +1,184 @@
+package disk
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// DiskDiff returns, in increasing order, the addresses of the blocks whose
+// contents differ between a and b. If the disks have different sizes, every
+// block past the end of the smaller one counts as different.
+//
+// Two MemDisks are compared directly in memory, and two COWMemDisks skip
+// the subtrees they share, so comparing a COWMemDisk with a clone of it takes
+// time proportional to the blocks written since the Clone. (The comparison
+// clones both disks, so their next write to each block copies it.) Other
+// disks are compared a block at a time. The disks should not be written
+// during the comparison.
+func DiskDiff(a Disk, b Disk) []uint64 {
+	na, nb := a.Size(), b.Size()
+	n := min(na, nb)
+	var diff []uint64
+	switch {
+	case isMemPair(a, b):
+		diff = memDiff(a.(MemDisk), b.(MemDisk), n)
+	case isCOWPair(a, b):
+		diff = cowDiff(a.(COWMemDisk), b.(COWMemDisk), n)
+	default:
+		bufA := make(Block, BlockSizeOf(a))
+		bufB := make(Block, BlockSizeOf(b))
+		for addr := uint64(0); addr < n; addr++ {
+			a.ReadTo(addr, bufA)
+			b.ReadTo(addr, bufB)
+			if !bytes.Equal(bufA, bufB) {
+				diff = append(diff, addr)
+			}
+		}
+	}
+	for addr := n; addr < max(na, nb); addr++ {
+		diff = append(diff, addr)
+	}
+	return diff
+}
+
+// ApplyBlocks copies the blocks at addrs from src to dst, as if by
+// dst.Write(a, src.Read(a)) for each address in turn, so that applying the
+// result of DiskDiff(dst, src) makes dst's blocks match src's (up to the
+// smaller size).
+//
+// Between two COWMemDisks the blocks are shared rather than copied. Like
+// WriteVec, the writes are not atomic. Expects every address to be below the
+// size of both disks.
+func ApplyBlocks(dst Disk, addrs []uint64, src Disk) {
+	if isCOWPair(dst, src) {
+		cowApply(dst.(COWMemDisk), addrs, src.(COWMemDisk))
+		return
+	}
+	buf := make(Block, BlockSizeOf(src))
+	for _, a := range addrs {
+		src.ReadTo(a, buf)
+		dst.Write(a, buf)
+	}
+}
+
+func isMemPair(a Disk, b Disk) bool {
+	ma, okA := a.(MemDisk)
+	mb, okB := b.(MemDisk)
+	return okA && okB && ma.blockSize == mb.blockSize
+}
+
+func isCOWPair(a Disk, b Disk) bool {
+	_, okA := a.(COWMemDisk)
+	_, okB := b.(COWMemDisk)
+	return okA && okB
+}
+
+// memDiffChunk is how many blocks memDiff copies out of one disk at a time.
+const memDiffChunk = 64
+
+// memDiff compares the first n blocks of two MemDisks. It copies a chunk of
+// a at a time and compares it against b, so that it never holds both disks'
+// locks at once.
+func memDiff(a MemDisk, b MemDisk, n uint64) []uint64 {
+	if a.data == b.data {
+		return nil
+	}
+	bs := a.blockSize
+	chunk := make([]byte, memDiffChunk*bs)
+	var diff []uint64
+	for start := uint64(0); start < n; start += memDiffChunk {
+		k := min(memDiffChunk, n-start)
+		a.l.RLock()
+		copy(chunk, (*a.data)[start*bs:(start+k)*bs])
+		a.l.RUnlock()
+		b.l.RLock()
+		for i := uint64(0); i < k; i++ {
+			if !bytes.Equal(chunk[i*bs:(i+1)*bs], b.block(start+i)) {
+				diff = append(diff, start+i)
+			}
+		}
+		b.l.RUnlock()
+	}
+	return diff
+}
+
+// cowDiff compares the first n blocks of two COWMemDisks. It works on clones,
+// whose nodes are immutable, so the comparison needs no locks and a subtree
+// shared by both disks can be skipped without looking inside it.
+func cowDiff(a COWMemDisk, b COWMemDisk, n uint64) []uint64 {
+	sa, sb := a.Clone().s, b.Clone().s
+	ra, rb := sa.root, sb.root
+	h := max(sa.height, sb.height)
+	ra = cowLift(ra, sa.height, h)
+	rb = cowLift(rb, sb.height, h)
+	var diff []uint64
+	cowDiffNodes(ra, rb, h, 0, n, &diff)
+	return diff
+}
+
+// cowLift returns a tree of height h with the same blocks as the tree n of
+// height from, by adding interior nodes above it.
+func cowLift(n *cowNode, from uint64, h uint64) *cowNode {
+	for ; from < h && n != nil; from++ {
+		n = &cowNode{children: [cowFanout]*cowNode{n}}
+	}
+	return n
+}
+
+// cowDiffNodes appends to diff the addresses below n at which the subtrees x
+// and y, of height h and with first block base, differ.
+func cowDiffNodes(x *cowNode, y *cowNode, h uint64, base uint64, n uint64, diff *[]uint64) {
+	if x == y || base >= n {
+		return
+	}
+	if h == 0 {
+		if !cowBlockEqual(x, y) {
+			*diff = append(*diff, base)
+		}
+		return
+	}
+	span := cowSpan(h - 1)
+	for i := uint64(0); i < cowFanout; i++ {
+		var cx, cy *cowNode
+		if x != nil {
+			cx = x.children[i]
+		}
+		if y != nil {
+			cy = y.children[i]
+		}
+		cowDiffNodes(cx, cy, h-1, base+i*span, n, diff)
+	}
+}
+
+// cowBlockEqual compares two leaves, either of which may be nil (zero).
+func cowBlockEqual(x *cowNode, y *cowNode) bool {
+	var bx, by []byte
+	if x != nil {
+		bx = x.block
+	}
+	if y != nil {
+		by = y.block
+	}
+	if bx == nil {
+		bx, by = by, bx
+	}
+	if by == nil {
+		return bx == nil || isZero(bx)
+	}
+	return bytes.Equal(bx, by)
+}
+
+// cowApply makes the blocks of dst at addrs point to src's leaves, which are
+// immutable in a clone of src, and so can be shared without copying.
+func cowApply(dst COWMemDisk, addrs []uint64, src COWMemDisk) {
+	ss := src.Clone().s
+	s := dst.s
+	s.m.Lock()
+	defer s.m.Unlock()
+	for _, a := range addrs {
+		if a >= s.size || a >= ss.size {
+			panic(fmt.Errorf("out-of-bounds write at %v", a))
+		}
+		*s.leaf(a) = ss.lookupLeaf(a)
+	}
+}
@@ -0,0 +1,72 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskDiffApply(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		newPair func(n uint64) (Disk, Disk)
+	}{
+		{"MemDisk", func(n uint64) (Disk, Disk) { return NewMemDisk(n), NewMemDisk(n) }},
+		{"COWMemDisk", func(n uint64) (Disk, Disk) {
+			d := NewCOWMemDisk(n)
+			return d, d.Clone()
+		}},
+		{"Mixed", func(n uint64) (Disk, Disk) { return NewMemDisk(n), NewCOWMemDisk(n) }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			a, b := tc.newPair(200)
+			assert.Empty(DiskDiff(a, b))
+
+			a.Write(3, mkBlock(1))
+			b.Write(3, mkBlock(1))
+			b.Write(5, mkBlock(2))
+			b.Write(150, mkBlock(3))
+			a.Write(199, mkBlock(4))
+			b.Write(70, make(Block, BlockSize)) // equal to the unwritten block
+			diff := DiskDiff(a, b)
+			assert.Equal([]uint64{5, 150, 199}, diff)
+			assert.Equal(diff, DiskDiff(b, a))
+
+			ApplyBlocks(a, diff, b)
+			assert.Empty(DiskDiff(a, b))
+			assert.Equal(mkBlock(2), a.Read(5))
+			assert.Equal(mkBlock(0), a.Read(199))
+
+			b.Write(5, mkBlock(5))
+			assert.Equal(mkBlock(2), a.Read(5), "applied blocks are independent")
+		})
+	}
+}
+
+func TestDiskDiffSizes(t *testing.T) {
+	a := NewCOWMemDisk(5)
+	b := NewCOWMemDisk(5000) // a taller tree
+	a.Write(1, mkBlock(1))
+	b.Write(1, mkBlock(1))
+	b.Write(2, mkBlock(2))
+	diff := DiskDiff(a, b)
+	assert.Equal(t, uint64(5000-5+1), uint64(len(diff)))
+	assert.Equal(t, []uint64{2, 5, 6}, diff[:3])
+	assert.Equal(t, []uint64{1, 2}, DiskDiff(NewMemDisk(1), NewMemDisk(3)))
+}
+
+func TestDiskDiffCOWTrim(t *testing.T) {
+	a := NewCOWMemDisk(10)
+	a.Write(4, mkBlock(1))
+	b := a.Clone()
+	b.Trim(4)
+	assert.Equal(t, []uint64{4}, DiskDiff(a, b))
+	a.Write(4, make(Block, BlockSize))
+	assert.Empty(t, DiskDiff(a, b), "a trimmed block equals a zero one")
+}
+
+func TestApplyBlocksOob(t *testing.T) {
+	assert.Panics(t, func() { ApplyBlocks(NewCOWMemDisk(5), []uint64{5}, NewCOWMemDisk(10)) })
+	assert.Panics(t, func() { ApplyBlocks(NewMemDisk(5), []uint64{5}, NewMemDisk(10)) })
+}
@@ -1,11 +1,74 @@
 package disk
 
+import "fmt"
+
 // Block is a 4096-byte buffer
+//
+// Disks created with a custom block size (see NewMemDiskWithBlockSize and
+// NewFileDiskWithBlockSize) use blocks of that size instead.
 type Block = []byte
 
+// BlockSize is the default size of a block, and the size assumed by the
+// wrappers and helpers in this package.
 const BlockSize uint64 = 4096
 
+// BlockSizer is implemented by disks whose block size may differ from
+// BlockSize.
+//
+// The wrappers that pass blocks through unchanged (StatsDisk, EventDisk,
+// FaultDisk, LockedDisk, ReadOnlyDisk, QuotaDisk, DelayDisk and OffsetDisk)
+// report the block size of the disk they wrap. The others lay out or
+// transform blocks assuming they are BlockSize bytes, and panic if
+// constructed over a disk with any other block size.
+type BlockSizer interface {
+	// BlockSize returns the size of the disk's blocks, in bytes.
+	BlockSize() uint64
+}
+
+// BlockSizeOf returns the block size of d: d.BlockSize() if d implements
+// BlockSizer, and BlockSize otherwise.
+func BlockSizeOf(d Disk) uint64 {
+	if bs, ok := d.(BlockSizer); ok {
+		return bs.BlockSize()
+	}
+	return BlockSize
+}
+
+// requireBlockSize panics unless d uses blocks of BlockSize bytes, for the
+// constructor of the wrapper what.
+func requireBlockSize(what string, d Disk) {
+	if bs := BlockSizeOf(d); bs != BlockSize {
+		panic(fmt.Errorf("%s requires %d-byte blocks, not %d", what, BlockSize, bs))
+	}
+}
+
+// BlockRangeToBytes converts the block range [start, start+count) to the byte
+// extent it occupies, as an offset and length in bytes.
+//
+// Requires that the end of the extent, (start+count)*BlockSize, fits in a
+// uint64; panics otherwise rather than returning a wrapped-around result.
+func BlockRangeToBytes(start uint64, count uint64) (offset uint64, length uint64) {
+	return blockRangeToBytes(BlockSize, start, count)
+}
+
+// blockRangeToBytes is BlockRangeToBytes for blocks of blockSize bytes.
+func blockRangeToBytes(blockSize uint64, start uint64, count uint64) (offset uint64, length uint64) {
+	maxBlocks := ^uint64(0) / blockSize
+	if start > maxBlocks || count > maxBlocks-start {
+		panic(fmt.Errorf("block range [%d, +%d) overflows byte offsets", start, count))
+	}
+	return start * blockSize, count * blockSize
+}
+
 // Disk provides access to a logical block-based disk
+//
+// Implementations may be used from several goroutines at once. Operations on
+// different blocks are independent, but a Read that runs concurrently with a
+// Write (or Trim) of the same block may observe a mix of the old and new
+// contents on some implementations (FileDisk, for example), and concurrent
+// Writes to the same block may leave such a mix. Callers must serialize
+// conflicting accesses to a block, for example with LockedDisk; Resize must
+// not run concurrently with any other operation.
 type Disk interface {
 	// Read reads a disk block by address
 	//
@@ -25,6 +88,32 @@ type Disk interface {
 	// Size reports how big the disk is, in blocks
 	Size() uint64
 
+	// Resize changes the size of the disk to newSize blocks.
+	//
+	// Blocks below min(Size(), newSize) are unchanged. When growing, the new
+	// blocks read as zeros; when shrinking, blocks at or past newSize are
+	// discarded. Like Write, the new size is only guaranteed to be durable
+	// after a Barrier.
+	//
+	// Not every disk can change size: wrappers with a fixed layout, such as
+	// OffsetDisk and ChecksumDisk, and read-only ones such as ReadOnlyDisk,
+	// panic instead.
+	Resize(newSize uint64)
+
+	// Trim tells the disk that the contents of block a are no longer needed,
+	// so the device may reclaim its storage.
+	//
+	// Modeled as writing an unspecified value to the block; implementations in
+	// this package read trimmed blocks as zeros. Expects a < Size().
+	Trim(a uint64)
+
+	// Prefetch hints that the blocks [a, a+count) will be read soon, so the
+	// disk may start fetching them.
+	//
+	// Modeled as a no-op: it never changes the contents of the disk, and
+	// blocks past Size() are ignored rather than being an error.
+	Prefetch(a uint64, count uint64)
+
 	// Barrier ensures data is persisted.
 	//
 	// When it returns, all outstanding writes are guaranteed to be durably on
@@ -35,6 +124,22 @@ type Disk interface {
 	Close()
 }
 
+// TrimRange trims the blocks [start, start+count) of d.
+func TrimRange(d Disk, start uint64, count uint64) {
+	for i := uint64(0); i < count; i++ {
+		d.Trim(start + i)
+	}
+}
+
+// clampRange returns the part of the block range [a, a+count) below size, as
+// its start and length; the length is 0 if none of the range is.
+func clampRange(a uint64, count uint64, size uint64) (uint64, uint64) {
+	if a >= size {
+		return a, 0
+	}
+	return a, min(count, size-a)
+}
+
 var implicitDisk Disk
 
 // Init sets up the global disk
@@ -1,18 +1,22 @@
 package disk
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
 type DiskSuite struct {
 	suite.Suite
-	mem bool
-	D   Disk
+	mem  bool
+	mmap bool
+	D    Disk
 }
 
 func TestMemDisk(t *testing.T) {
@@ -23,6 +27,10 @@ func TestFileDisk(t *testing.T) {
 	suite.Run(t, &DiskSuite{mem: false})
 }
 
+func TestMmapDisk(t *testing.T) {
+	suite.Run(t, &DiskSuite{mmap: true})
+}
+
 var diskPath = "/tmp/test-disk"
 
 const diskSize uint64 = 100
@@ -31,6 +39,12 @@ func (suite *DiskSuite) SetupTest() {
 	var d Disk
 	if suite.mem {
 		d = NewMemDisk(diskSize)
+	} else if suite.mmap {
+		var err error
+		d, err = NewMmapDisk(diskPath, diskSize)
+		if err != nil {
+			panic(err)
+		}
 	} else {
 		var err error
 		d, err = NewFileDisk(diskPath, diskSize)
@@ -87,6 +101,61 @@ func (suite *DiskSuite) TestSize() {
 	suite.Equal(uint64(100), Size())
 }
 
+func (suite *DiskSuite) TestResize() {
+	d := suite.D
+	d.Write(3, block1)
+	d.Write(4, block2)
+	d.Resize(4)
+	suite.Equal(uint64(4), d.Size())
+	suite.Equal(block1, d.Read(3))
+	suite.Panics(func() { d.Read(4) }, "read past shrunk size")
+
+	d.Resize(10)
+	suite.Equal(uint64(10), d.Size())
+	suite.Equal(block1, d.Read(3))
+	suite.Equal(block0, d.Read(4), "discarded block should come back zeroed")
+	d.Write(9, block2)
+	suite.Equal(block2, d.Read(9))
+}
+
+func (suite *DiskSuite) TestTrim() {
+	d := suite.D
+	for a := uint64(2); a < 6; a++ {
+		d.Write(a, block1)
+	}
+	d.Trim(2)
+	TrimRange(d, 4, 2)
+	suite.Equal(block0, d.Read(2))
+	suite.Equal(block1, d.Read(3))
+	suite.Equal(block0, d.Read(4))
+	suite.Equal(block0, d.Read(5))
+	suite.Equal(diskSize, d.Size(), "trim should not change the size")
+	suite.Panics(func() { d.Trim(diskSize) }, "out-of-bounds trim")
+}
+
+func (suite *DiskSuite) TestPrefetch() {
+	d := suite.D
+	d.Write(1, block1)
+	d.Prefetch(0, 3)
+	d.Prefetch(diskSize-1, 5)
+	d.Prefetch(diskSize+1, 1)
+	suite.Equal(block1, d.Read(1))
+	suite.Equal(block0, d.Read(2))
+}
+
+func (suite *DiskSuite) TestReadTo() {
+	d := suite.D
+	d.Write(1, block1)
+	d.Write(2, block2)
+	buf := make(Block, BlockSize)
+	d.ReadTo(1, buf)
+	suite.Equal(block1, buf)
+	// the same buffer can be reused
+	d.ReadTo(2, buf)
+	suite.Equal(block2, buf)
+	suite.Panics(func() { d.ReadTo(1, make(Block, 10)) }, "short buffer")
+}
+
 func (suite *DiskSuite) TestBarrier() {
 	Write(99, block1)
 	Barrier()
@@ -138,3 +207,157 @@ func (suite *DiskSuite) TestReadOob() {
 func (suite *DiskSuite) TestWriteOob() {
 	suite.Panics(func() { Write(diskSize, block0) }, "out-of-bounds write")
 }
+
+func TestFileDiskExclusive(t *testing.T) {
+	path := diskPath + ".excl"
+	defer os.Remove(path)
+	d, err := NewFileDiskExclusive(path, diskSize)
+	require.NoError(t, err)
+	_, err = NewFileDiskExclusive(path, diskSize)
+	assert.Error(t, err, "second exclusive open should fail")
+	d.Close()
+
+	d, err = NewFileDiskExclusive(path, diskSize)
+	require.NoError(t, err, "lock should be released by Close")
+	d.Close()
+}
+
+func TestBlockRangeToBytes(t *testing.T) {
+	assert := assert.New(t)
+	off, length := BlockRangeToBytes(3, 1)
+	assert.Equal([2]uint64{3 * 4096, 4096}, [2]uint64{off, length}, "single block")
+	off, length = BlockRangeToBytes(10, 5)
+	assert.Equal([2]uint64{10 * 4096, 5 * 4096}, [2]uint64{off, length}, "multi-block")
+	maxBlocks := ^uint64(0) / BlockSize
+	off, length = BlockRangeToBytes(maxBlocks-1, 1)
+	assert.Equal([2]uint64{(maxBlocks - 1) * 4096, 4096}, [2]uint64{off, length}, "near boundary")
+}
+
+func TestBlockRangeToBytesOverflow(t *testing.T) {
+	maxBlocks := ^uint64(0) / BlockSize
+	for _, r := range [][2]uint64{
+		{maxBlocks, 1},
+		{maxBlocks + 1, 0},
+		{0, maxBlocks + 1},
+	} {
+		assert.Panics(t, func() { BlockRangeToBytes(r[0], r[1]) }, "range %v should overflow", r)
+	}
+}
+
+func TestCustomBlockSize(t *testing.T) {
+	assert := assert.New(t)
+	path := diskPath + ".bs"
+	defer os.Remove(path)
+	fd, err := NewFileDiskWithBlockSize(path, 8, 512)
+	require.NoError(t, err)
+	defer fd.Close()
+	for _, d := range []Disk{NewMemDiskWithBlockSize(8, 512), fd} {
+		require.Equal(t, uint64(512), BlockSizeOf(d), "%T", d)
+		b := make(Block, 512)
+		b[0] = 1
+		b[511] = 2
+		d.Write(7, b)
+		WriteMulti(d, 0, []Block{b, b})
+		for _, a := range []uint64{0, 1, 7} {
+			assert.Equal(b, d.Read(a), "%T: block %d", d, a)
+		}
+		assert.Equal(make(Block, 512), d.Read(6), "%T: block 6 should be a zero block", d)
+		assert.Panics(func() { d.Write(0, make(Block, BlockSize)) },
+			"%T: write of a 4096-byte block should fail", d)
+	}
+	st, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(int64(8*512), st.Size())
+	small := NewMemDiskWithBlockSize(4, 512)
+	assert.Equal(uint64(512), BlockSizeOf(NewEventDisk(NewOffsetDisk(small, 1, 2), nil)),
+		"pass-through wrappers should report the wrapped block size")
+	assert.Equal(BlockSize, BlockSizeOf(NewEventDisk(NewMemDisk(1), nil)),
+		"wrappers of default disks use the default block size")
+	assert.Panics(func() { NewCacheDisk(small, 2, WriteThrough) },
+		"CacheDisk over 512-byte blocks should panic")
+	testHelpersBlockSize(t)
+}
+
+// testHelpersBlockSize runs the free-function helpers over a disk with
+// 512-byte blocks.
+func testHelpersBlockSize(t *testing.T) {
+	assert := assert.New(t)
+	d := NewMemDiskWithBlockSize(4, 512)
+	for a := uint64(0); a < 4; a++ {
+		b := make(Block, 512)
+		b[0] = byte(a)
+		b[511] = byte(a)
+		d.Write(a, b)
+	}
+	assert.True(ValidateWrites(d, map[uint64][]byte{0: make([]byte, 512)}))
+	assert.False(ValidateWrites(d, map[uint64][]byte{0: make([]byte, BlockSize)}))
+	assert.Empty(CheckDisk(d, func(a uint64, block []byte) (bool, string) {
+		return uint64(len(block)) == 512 && block[0] == byte(a), "wrong block"
+	}))
+	blocks := ReadScatter(d, []uint64{2, 2})
+	assert.Equal(d.Read(2), blocks[0])
+	assert.Equal(blocks[0], blocks[1])
+	var lasts []byte
+	EachRecord(d, 256, func(i uint64, record []byte) bool {
+		lasts = append(lasts, record[255])
+		return true
+	})
+	assert.Equal([]byte{0, 0, 0, 1, 0, 2, 0, 3}, lasts)
+	a, found := BinarySearchBlocks(d, 0, 4, []byte{3}, func(block []byte) []byte { return block[:1] })
+	assert.True(found)
+	assert.Equal(uint64(3), a)
+	root := MerkleRoot(d)
+	assert.True(VerifyMerkleProof(root, 1, d.Read(1), MerkleProof(d, 1)))
+	n, err := LoadDiskFromReader(d, bytes.NewReader(make([]byte, 700)))
+	assert.NoError(err)
+	assert.Equal(uint64(2), n)
+	assert.Equal(make(Block, 512), d.Read(1))
+	assert.Panics(func() { NewRingLog(d, 0, 4) })
+}
+
+func TestFileDiskOptsSync(t *testing.T) {
+	path := diskPath + ".sync"
+	defer os.Remove(path)
+	d, err := NewFileDiskOpts(path, 10, FileDiskOpts{Sync: true})
+	require.NoError(t, err)
+	d.Write(3, block1)
+	d.Close()
+	d, err = NewFileDisk(path, 10)
+	require.NoError(t, err)
+	defer d.Close()
+	assert.Equal(t, block1, d.Read(3), "write not persisted")
+}
+
+func TestFileDiskOptsDirect(t *testing.T) {
+	assert := assert.New(t)
+	_, err := NewFileDiskOpts(diskPath+".bad", 1,
+		FileDiskOpts{Direct: true, BlockSize: 512})
+	assert.Error(err, "unaligned block size should be rejected")
+	// /tmp is often tmpfs, which has no O_DIRECT, so use the working directory
+	path := fmt.Sprintf("test-disk-direct.%d", time.Now().UnixNano())
+	defer os.Remove(path)
+	d, err := NewFileDiskOpts(path, 10, FileDiskOpts{Direct: true})
+	if err != nil {
+		t.Skipf("O_DIRECT unavailable: %v", err)
+	}
+	defer d.Close()
+	// an unaligned source buffer goes through a bounce buffer
+	unaligned := make([]byte, BlockSize+1)[1:]
+	copy(unaligned, block2)
+	d.Write(4, unaligned)
+	WriteMulti(d, 5, []Block{block1, block2})
+	got := d.Read(4)
+	assert.True(isAligned(got), "Read should return an aligned block")
+	assert.Equal(block2, got, "unaligned write not persisted")
+	assert.Equal(block2, d.Read(6), "WriteMulti not persisted")
+	d.ReadTo(5, unaligned)
+	assert.Equal(block1, unaligned, "unaligned ReadTo")
+}
+
+func TestAlignedBuf(t *testing.T) {
+	for _, n := range []uint64{1, 512, 4096, 3 * 4096} {
+		b := alignedBuf(n)
+		assert.Equal(t, n, uint64(len(b)), "alignedBuf(%d) length", n)
+		assert.True(t, isAligned(b), "alignedBuf(%d) aligned", n)
+	}
+}
@@ -0,0 +1,206 @@
+// Package disktest checks that a disk.Disk implementation satisfies the
+// interface contract assumed by the model of the disk package.
+//
+// Implementations of disk.Disk outside this repository (remote disks,
+// wrappers) can run the same checks as the ones here with
+//
+//	func TestMyDisk(t *testing.T) {
+//		disktest.RunDiskTests(t, func(numBlocks uint64) disk.Disk {
+//			return NewMyDisk(numBlocks)
+//		})
+//	}
+package disktest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/goose-lang/primitive/disk"
+)
+
+// diskSize is the size of the disks the tests create, in blocks.
+const diskSize uint64 = 64
+
+// RunDiskTests runs the conformance tests as subtests of t. newDisk must
+// return a fresh, zero-filled disk of numBlocks blocks of disk.BlockSize
+// bytes; each subtest closes the disk it creates.
+func RunDiskTests(t *testing.T, newDisk func(numBlocks uint64) disk.Disk) {
+	for _, tc := range []struct {
+		name string
+		test func(t *testing.T, d disk.Disk)
+	}{
+		{"ReadWrite", testReadWrite},
+		{"ReadTo", testReadTo},
+		{"Size", testSize},
+		{"Barrier", testBarrier},
+		{"OutOfBounds", testOutOfBounds},
+		{"Trim", testTrim},
+		{"Prefetch", testPrefetch},
+		{"Resize", testResize},
+		{"Concurrent", testConcurrent},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := newDisk(diskSize)
+			defer d.Close()
+			tc.test(t, d)
+		})
+	}
+}
+
+func mkBlock(x byte) disk.Block {
+	b := make(disk.Block, disk.BlockSize)
+	for i := range b {
+		b[i] = x + byte(i)
+	}
+	return b
+}
+
+func checkBlock(t *testing.T, d disk.Disk, a uint64, expected disk.Block) {
+	t.Helper()
+	if string(d.Read(a)) != string(expected) {
+		t.Errorf("block %d has the wrong contents", a)
+	}
+}
+
+// panics reports whether f panics.
+func panics(f func()) (panicked bool) {
+	defer func() {
+		if recover() != nil {
+			panicked = true
+		}
+	}()
+	f()
+	return false
+}
+
+func testReadWrite(t *testing.T, d disk.Disk) {
+	checkBlock(t, d, 0, make(disk.Block, disk.BlockSize))
+	d.Write(0, mkBlock(1))
+	d.Write(diskSize-1, mkBlock(2))
+	checkBlock(t, d, 0, mkBlock(1))
+	checkBlock(t, d, diskSize-1, mkBlock(2))
+	checkBlock(t, d, 1, make(disk.Block, disk.BlockSize))
+
+	d.Write(0, mkBlock(3))
+	checkBlock(t, d, 0, mkBlock(3))
+
+	// the disk must not retain the caller's buffers
+	b := mkBlock(4)
+	d.Write(5, b)
+	b[0]++
+	checkBlock(t, d, 5, mkBlock(4))
+	r := d.Read(5)
+	r[0]++
+	checkBlock(t, d, 5, mkBlock(4))
+}
+
+func testReadTo(t *testing.T, d disk.Disk) {
+	d.Write(3, mkBlock(1))
+	buf := mkBlock(7)
+	d.ReadTo(3, buf)
+	if string(buf) != string(mkBlock(1)) {
+		t.Error("ReadTo returned the wrong contents")
+	}
+}
+
+func testSize(t *testing.T, d disk.Disk) {
+	if d.Size() != diskSize {
+		t.Errorf("Size() = %d, want %d", d.Size(), diskSize)
+	}
+}
+
+func testBarrier(t *testing.T, d disk.Disk) {
+	d.Write(1, mkBlock(1))
+	d.Barrier()
+	d.Write(1, mkBlock(2))
+	checkBlock(t, d, 1, mkBlock(2))
+	d.Barrier()
+	d.Barrier()
+	checkBlock(t, d, 1, mkBlock(2))
+}
+
+func testOutOfBounds(t *testing.T, d disk.Disk) {
+	for name, f := range map[string]func(){
+		"Read":   func() { d.Read(diskSize) },
+		"ReadTo": func() { d.ReadTo(diskSize, make(disk.Block, disk.BlockSize)) },
+		"Write":  func() { d.Write(diskSize, mkBlock(1)) },
+		"Trim":   func() { d.Trim(diskSize) },
+	} {
+		if !panics(f) {
+			t.Errorf("out-of-bounds %s did not panic", name)
+		}
+	}
+	if !panics(func() { d.Write(0, make(disk.Block, disk.BlockSize-1)) }) {
+		t.Error("Write of a short block did not panic")
+	}
+	if d.Size() != diskSize {
+		t.Error("out-of-bounds access changed the size")
+	}
+}
+
+func testTrim(t *testing.T, d disk.Disk) {
+	d.Write(2, mkBlock(1))
+	d.Write(3, mkBlock(2))
+	d.Trim(2)
+	// the model allows any contents for a trimmed block; only check that its
+	// neighbours are unaffected and that it can be written again
+	checkBlock(t, d, 3, mkBlock(2))
+	d.Write(2, mkBlock(3))
+	checkBlock(t, d, 2, mkBlock(3))
+}
+
+func testPrefetch(t *testing.T, d disk.Disk) {
+	d.Write(1, mkBlock(1))
+	d.Write(2, mkBlock(2))
+	before := d.Read(3)
+	d.Prefetch(0, 4)
+	// ranges that extend past the end, or start there, are not errors
+	d.Prefetch(diskSize-1, 10)
+	d.Prefetch(diskSize, 1)
+	d.Prefetch(1, ^uint64(0))
+	d.Prefetch(0, 0)
+	checkBlock(t, d, 1, mkBlock(1))
+	checkBlock(t, d, 2, mkBlock(2))
+	checkBlock(t, d, 3, before)
+}
+
+func testResize(t *testing.T, d disk.Disk) {
+	d.Write(1, mkBlock(1))
+	d.Write(diskSize-1, mkBlock(2))
+	d.Resize(diskSize / 2)
+	if d.Size() != diskSize/2 {
+		t.Fatalf("Size() = %d after shrinking to %d", d.Size(), diskSize/2)
+	}
+	if !panics(func() { d.Read(diskSize / 2) }) {
+		t.Error("read past the new size did not panic")
+	}
+	d.Resize(diskSize)
+	if d.Size() != diskSize {
+		t.Fatalf("Size() = %d after growing to %d", d.Size(), diskSize)
+	}
+	checkBlock(t, d, 1, mkBlock(1))
+	checkBlock(t, d, diskSize-1, make(disk.Block, disk.BlockSize))
+}
+
+func testConcurrent(t *testing.T, d disk.Disk) {
+	// goroutines access disjoint blocks, which the contract requires to be
+	// independent
+	const workers = 8
+	var wg sync.WaitGroup
+	for w := uint64(0); w < workers; w++ {
+		wg.Add(1)
+		go func(w uint64) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				for a := w; a < diskSize; a += workers {
+					d.Write(a, mkBlock(byte(a)+byte(i)))
+					d.Read(a)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	for a := uint64(0); a < diskSize; a++ {
+		checkBlock(t, d, a, mkBlock(byte(a)+19))
+	}
+}
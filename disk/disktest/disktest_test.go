@@ -0,0 +1,53 @@
+package disktest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/goose-lang/primitive/disk"
+)
+
+func TestMemDisk(t *testing.T) {
+	RunDiskTests(t, func(numBlocks uint64) disk.Disk {
+		return disk.NewMemDisk(numBlocks)
+	})
+}
+
+func TestCOWMemDisk(t *testing.T) {
+	RunDiskTests(t, func(numBlocks uint64) disk.Disk {
+		return disk.NewCOWMemDisk(numBlocks)
+	})
+}
+
+func TestFileDisk(t *testing.T) {
+	RunDiskTests(t, func(numBlocks uint64) disk.Disk {
+		d, err := disk.NewFileDisk(filepath.Join(t.TempDir(), "disk.img"), numBlocks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	})
+}
+
+func TestWrappers(t *testing.T) {
+	for name, wrap := range map[string]func(disk.Disk) disk.Disk{
+		"LockedDisk": func(d disk.Disk) disk.Disk { return disk.NewLockedDisk(d) },
+		"CacheDisk":  func(d disk.Disk) disk.Disk { return disk.NewCacheDisk(d, 16, disk.WriteBack) },
+		"StatsDisk":  func(d disk.Disk) disk.Disk { return disk.NewStatsDisk(d) },
+		"EncryptedDisk": func(d disk.Disk) disk.Disk {
+			key := make([]byte, 32)
+			key[0] = 1
+			e, err := disk.NewEncryptedDisk(d, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return e
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			RunDiskTests(t, func(numBlocks uint64) disk.Disk {
+				return wrap(disk.NewMemDisk(numBlocks))
+			})
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package disk
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+)
+
+// EncryptedDisk wraps a Disk to encrypt blocks at rest with AES-XTS (IEEE
+// 1619), the standard mode for disk encryption. Each block is one XTS data
+// unit whose tweak is its address, so identical plaintexts at different
+// addresses encrypt differently.
+//
+// XTS provides confidentiality only: there is no integrity check. A block
+// that is modified, or copied to another address, decrypts to garbage, and
+// nothing detects it; reads simply return that garbage (combine with
+// ChecksumDisk to detect corruption). The mapping is length-preserving, so
+// sizes and addresses are unchanged and code can reason about the
+// EncryptedDisk exactly as about the underlying disk.
+//
+// A trimmed block of the underlying disk reads as zeros, which would not
+// decrypt to zeros; the EncryptedDisk therefore returns an all-zero
+// plaintext for an all-zero ciphertext (which a real encryption produces with
+// negligible probability), so Trim keeps its meaning.
+type EncryptedDisk struct {
+	d Disk
+	// data encrypts the blocks, tweak encrypts the addresses.
+	data  cipher.Block
+	tweak cipher.Block
+}
+
+var _ Disk = EncryptedDisk{}
+
+// NewEncryptedDisk wraps d to encrypt its blocks with key, which must be 32
+// or 64 bytes long (for AES-128 or AES-256): the first half is the data key
+// and the second half the tweak key, and the two halves must differ.
+func NewEncryptedDisk(d Disk, key []byte) (EncryptedDisk, error) {
+	requireBlockSize("EncryptedDisk", d)
+	if len(key) != 32 && len(key) != 64 {
+		return EncryptedDisk{}, fmt.Errorf("XTS key must be 32 or 64 bytes, not %d", len(key))
+	}
+	k1, k2 := key[:len(key)/2], key[len(key)/2:]
+	if bytes.Equal(k1, k2) {
+		return EncryptedDisk{}, fmt.Errorf("XTS key halves must differ")
+	}
+	data, err := aes.NewCipher(k1)
+	if err != nil {
+		return EncryptedDisk{}, err
+	}
+	tweak, err := aes.NewCipher(k2)
+	if err != nil {
+		return EncryptedDisk{}, err
+	}
+	return EncryptedDisk{d: d, data: data, tweak: tweak}, nil
+}
+
+// xts encrypts (or decrypts) src into dst as the XTS data unit with sequence
+// number unit. len(src) must be a multiple of aes.BlockSize, so ciphertext
+// stealing is never needed.
+func xts(data, tweak cipher.Block, decrypt bool, unit uint64, dst, src []byte) {
+	var t [aes.BlockSize]byte
+	binary.LittleEndian.PutUint64(t[:8], unit)
+	tweak.Encrypt(t[:], t[:])
+	var x [aes.BlockSize]byte
+	for i := 0; i < len(src); i += aes.BlockSize {
+		for j := range x {
+			x[j] = src[i+j] ^ t[j]
+		}
+		if decrypt {
+			data.Decrypt(x[:], x[:])
+		} else {
+			data.Encrypt(x[:], x[:])
+		}
+		for j := range x {
+			dst[i+j] = x[j] ^ t[j]
+		}
+		mulAlpha(&t)
+	}
+}
+
+// mulAlpha multiplies the tweak t by the primitive element of GF(2^128), in
+// XTS's little-endian representation.
+func mulAlpha(t *[aes.BlockSize]byte) {
+	lo := binary.LittleEndian.Uint64(t[:8])
+	hi := binary.LittleEndian.Uint64(t[8:])
+	carry := hi >> 63
+	hi = hi<<1 | lo>>63
+	lo = lo<<1 ^ carry*0x87
+	binary.LittleEndian.PutUint64(t[:8], lo)
+	binary.LittleEndian.PutUint64(t[8:], hi)
+}
+
+func (d EncryptedDisk) ReadTo(a uint64, buf Block) {
+	d.d.ReadTo(a, buf)
+	if isZero(buf) {
+		return
+	}
+	xts(d.data, d.tweak, true, a, buf, buf)
+}
+
+func (d EncryptedDisk) Read(a uint64) Block {
+	buf := make(Block, BlockSize)
+	d.ReadTo(a, buf)
+	return buf
+}
+
+func (d EncryptedDisk) Write(a uint64, v Block) {
+	if uint64(len(v)) != BlockSize {
+		panic(fmt.Errorf("v is not block-sized (%d bytes)", len(v)))
+	}
+	buf := make(Block, BlockSize)
+	xts(d.data, d.tweak, false, a, buf, v)
+	d.d.Write(a, buf)
+}
+
+func (d EncryptedDisk) Trim(a uint64) {
+	d.d.Trim(a)
+}
+
+func (d EncryptedDisk) Prefetch(a uint64, count uint64) {
+	d.d.Prefetch(a, count)
+}
+
+func (d EncryptedDisk) Size() uint64 {
+	return d.d.Size()
+}
+
+func (d EncryptedDisk) Resize(newSize uint64) {
+	d.d.Resize(newSize)
+}
+
+func (d EncryptedDisk) Barrier() {
+	d.d.Barrier()
+}
+
+func (d EncryptedDisk) Close() {
+	d.d.Close()
+}
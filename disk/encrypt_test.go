@@ -0,0 +1,73 @@
+package disk
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testKey() []byte {
+	key := make([]byte, 64)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	return key
+}
+
+func TestXtsVectors(t *testing.T) {
+	// IEEE 1619-2007, Annex B, vectors 1 and 2
+	for _, tc := range []struct {
+		k1, k2     byte
+		unit       uint64
+		plain      byte
+		ciphertext string
+	}{
+		{0x00, 0x00, 0, 0x00, "917cf69ebd68b2ec9b9fe9a3eadda692cd43d2f59598ed858c02c2652fbf922e"},
+		{0x11, 0x22, 0x3333333333, 0x44, "c454185e6a16936e39334038acef838bfb186fff7480adc4289382ecd6d394f0"},
+	} {
+		data, _ := aes.NewCipher(bytes.Repeat([]byte{tc.k1}, 16))
+		tweak, _ := aes.NewCipher(bytes.Repeat([]byte{tc.k2}, 16))
+		plain := bytes.Repeat([]byte{tc.plain}, 32)
+		out := make([]byte, 32)
+		xts(data, tweak, false, tc.unit, out, plain)
+		assert.Equal(t, tc.ciphertext, hex.EncodeToString(out))
+		xts(data, tweak, true, tc.unit, out, out)
+		assert.Equal(t, plain, out)
+	}
+}
+
+func TestEncryptedDisk(t *testing.T) {
+	assert := assert.New(t)
+	base := NewMemDisk(10)
+	d, err := NewEncryptedDisk(base, testKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Write(1, mkBlock(1))
+	d.Write(2, mkBlock(1))
+	assert.Equal(mkBlock(1), d.Read(1))
+	assert.NotEqual(mkBlock(1), base.Read(1), "the block should be stored encrypted")
+	assert.NotEqual(base.Read(1), base.Read(2), "ciphertext should depend on the address")
+
+	d.Write(3, make(Block, BlockSize))
+	assert.Equal(make(Block, BlockSize), d.Read(3))
+	assert.Equal(make(Block, BlockSize), d.Read(4), "unwritten blocks read as zeros")
+	d.Trim(1)
+	assert.Equal(make(Block, BlockSize), d.Read(1))
+
+	// a different key cannot read the data
+	key := testKey()
+	key[0]++
+	other, _ := NewEncryptedDisk(base, key)
+	assert.NotEqual(mkBlock(1), other.Read(2))
+}
+
+func TestEncryptedDiskBadKey(t *testing.T) {
+	_, err := NewEncryptedDisk(NewMemDisk(1), make([]byte, 16))
+	assert.Error(t, err)
+	_, err = NewEncryptedDisk(NewMemDisk(1), make([]byte, 32))
+	assert.Error(t, err, "equal key halves should be rejected")
+}
@@ -0,0 +1,113 @@
+package disk
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDisk is implemented by disks whose operations can report failures as
+// errors rather than panicking, so that callers can retry or degrade
+// gracefully on transient device errors.
+//
+// Each method behaves like the corresponding Disk method when it returns nil.
+// Misuse that is a bug in the caller rather than a device failure, such as a
+// wrongly-sized buffer, still panics.
+type ErrDisk interface {
+	// TryReadTo is like ReadTo. If it fails, the contents of buf are
+	// unspecified.
+	TryReadTo(a uint64, buf Block) error
+	// TryWrite is like Write. If it fails, block a holds either its old
+	// contents or v.
+	TryWrite(a uint64, v Block) error
+	// TryTrim is like Trim.
+	TryTrim(a uint64) error
+	// TryBarrier is like Barrier. If it fails, writes since the last
+	// successful Barrier may or may not be durable.
+	TryBarrier() error
+}
+
+// OutOfBoundsError is the error of an access past the end of a disk.
+type OutOfBoundsError struct {
+	Op   DiskOp
+	Addr uint64
+}
+
+func (e *OutOfBoundsError) Error() string {
+	return fmt.Sprintf("out-of-bounds %v at %v", e.Op, e.Addr)
+}
+
+// IOError is the error of an operation the underlying device or file system
+// failed.
+type IOError struct {
+	Op   DiskOp
+	Addr uint64
+	Err  error
+}
+
+func (e *IOError) Error() string {
+	return fmt.Sprintf("%v failed: %v", e.Op, e.Err)
+}
+
+func (e *IOError) Unwrap() error {
+	return e.Err
+}
+
+// AsErrDisk returns an error-returning view of d: d itself if it implements
+// ErrDisk, and otherwise an adapter that converts the panics of d's
+// operations into errors, when they are failures: an *OutOfBoundsError,
+// *IOError, *ChecksumError or *FaultError. Other panics propagate.
+func AsErrDisk(d Disk) ErrDisk {
+	if ed, ok := d.(ErrDisk); ok {
+		return ed
+	}
+	return panicErrDisk{d: d}
+}
+
+// panicErrDisk implements ErrDisk for a Disk that reports failures by
+// panicking.
+type panicErrDisk struct {
+	d Disk
+}
+
+// isFailure reports whether err, the value of a panic, is a failure that
+// ErrDisk reports as an error, rather than misuse by the caller.
+func isFailure(err error) bool {
+	var oob *OutOfBoundsError
+	var ioe *IOError
+	var cke *ChecksumError
+	var fe *FaultError
+	return errors.As(err, &oob) || errors.As(err, &ioe) ||
+		errors.As(err, &cke) || errors.As(err, &fe)
+}
+
+// catch runs f, returning the failure it panics with as an error. Any other
+// panic, such as one for a wrongly-sized buffer, propagates.
+func catch(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok && isFailure(e) {
+				err = e
+				return
+			}
+			panic(r)
+		}
+	}()
+	f()
+	return nil
+}
+
+func (d panicErrDisk) TryReadTo(a uint64, buf Block) error {
+	return catch(func() { d.d.ReadTo(a, buf) })
+}
+
+func (d panicErrDisk) TryWrite(a uint64, v Block) error {
+	return catch(func() { d.d.Write(a, v) })
+}
+
+func (d panicErrDisk) TryTrim(a uint64) error {
+	return catch(func() { d.d.Trim(a) })
+}
+
+func (d panicErrDisk) TryBarrier() error {
+	return catch(d.d.Barrier)
+}
@@ -0,0 +1,55 @@
+package disk
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileDiskErrors(t *testing.T) {
+	assert := assert.New(t)
+	d := tempFileDisk(t, 4)
+	defer d.Close()
+	var ed ErrDisk = d
+
+	assert.NoError(ed.TryWrite(1, mkBlock(1)))
+	buf := make(Block, BlockSize)
+	assert.NoError(ed.TryReadTo(1, buf))
+	assert.Equal(mkBlock(1), buf)
+	assert.NoError(ed.TryBarrier())
+
+	var oob *OutOfBoundsError
+	err := ed.TryWrite(4, mkBlock(1))
+	assert.True(errors.As(err, &oob))
+	assert.Equal(OutOfBoundsError{Op: OpWrite, Addr: 4}, *oob)
+	assert.Error(ed.TryReadTo(4, buf))
+	assert.Error(ed.TryTrim(4))
+	assert.Panics(func() { d.Read(4) }, "the panicking API is unchanged")
+}
+
+func TestAsErrDisk(t *testing.T) {
+	assert := assert.New(t)
+	d := AsErrDisk(NewMemDisk(4))
+	assert.NoError(d.TryWrite(1, mkBlock(1)))
+	assert.EqualError(d.TryWrite(4, mkBlock(1)), "out-of-bounds write at 4")
+	assert.Error(d.TryReadTo(4, make(Block, BlockSize)))
+	assert.NoError(d.TryBarrier())
+
+	var oob *OutOfBoundsError
+	assert.True(errors.As(d.TryReadTo(4, make(Block, BlockSize)), &oob))
+	assert.Equal(OutOfBoundsError{Op: OpRead, Addr: 4}, *oob)
+	assert.Panics(func() { d.TryWrite(1, make(Block, 10)) }, "misuse still panics")
+
+	fd := NewFaultDisk(NewMemDisk(4), 0)
+	assert.Equal(ErrDisk(fd), AsErrDisk(fd), "native implementations are used directly")
+}
+
+func TestAsErrDiskChecksum(t *testing.T) {
+	base := NewMemDisk(10)
+	d := NewChecksumDisk(base)
+	d.Write(0, mkBlock(1))
+	base.Write(0, mkBlock(2))
+	var cerr *ChecksumError
+	assert.True(t, errors.As(AsErrDisk(d).TryReadTo(0, make(Block, BlockSize)), &cerr))
+}
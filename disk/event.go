@@ -0,0 +1,113 @@
+package disk
+
+import "time"
+
+// DiskOp identifies a kind of disk operation.
+type DiskOp uint8
+
+const (
+	OpRead DiskOp = iota
+	OpWrite
+	OpBarrier
+	OpTrim
+	OpResize
+)
+
+func (op DiskOp) String() string {
+	switch op {
+	case OpRead:
+		return "read"
+	case OpWrite:
+		return "write"
+	case OpBarrier:
+		return "barrier"
+	case OpTrim:
+		return "trim"
+	case OpResize:
+		return "resize"
+	}
+	return "invalid"
+}
+
+// DiskEvent records one operation on an EventDisk.
+type DiskEvent struct {
+	Op DiskOp
+	// Addr is the block address (0 for barriers).
+	Addr uint64
+	// Time is when the operation completed, in nanoseconds since the Unix
+	// epoch.
+	Time uint64
+}
+
+// EventDisk wraps a Disk and publishes an event for every read, write, trim
+// and barrier to a channel.
+//
+// Sends never block: if the channel is full the event is dropped, so a slow
+// or absent monitor cannot stall or deadlock disk operations. Events are
+// sent after the operation completes, and operations from a single goroutine
+// produce events in order.
+type EventDisk struct {
+	d      Disk
+	events chan<- DiskEvent
+}
+
+var _ Disk = EventDisk{}
+
+// NewEventDisk wraps d to send events to events.
+func NewEventDisk(d Disk, events chan<- DiskEvent) EventDisk {
+	return EventDisk{d: d, events: events}
+}
+
+// BlockSize returns the block size of the wrapped disk.
+func (d EventDisk) BlockSize() uint64 {
+	return BlockSizeOf(d.d)
+}
+
+func (d EventDisk) emit(op DiskOp, a uint64) {
+	select {
+	case d.events <- DiskEvent{Op: op, Addr: a, Time: uint64(time.Now().UnixNano())}:
+	default:
+	}
+}
+
+func (d EventDisk) ReadTo(a uint64, buf Block) {
+	d.d.ReadTo(a, buf)
+	d.emit(OpRead, a)
+}
+
+func (d EventDisk) Read(a uint64) Block {
+	b := d.d.Read(a)
+	d.emit(OpRead, a)
+	return b
+}
+
+func (d EventDisk) Write(a uint64, v Block) {
+	d.d.Write(a, v)
+	d.emit(OpWrite, a)
+}
+
+func (d EventDisk) Trim(a uint64) {
+	d.d.Trim(a)
+	d.emit(OpTrim, a)
+}
+
+func (d EventDisk) Prefetch(a uint64, count uint64) {
+	d.d.Prefetch(a, count)
+}
+
+func (d EventDisk) Size() uint64 {
+	return d.d.Size()
+}
+
+func (d EventDisk) Resize(newSize uint64) {
+	d.d.Resize(newSize)
+}
+
+func (d EventDisk) Barrier() {
+	d.d.Barrier()
+	d.emit(OpBarrier, 0)
+}
+
+func (d EventDisk) Close() {
+	d.d.Close()
+}
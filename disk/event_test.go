@@ -0,0 +1,43 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventDiskOrder(t *testing.T) {
+	assert := assert.New(t)
+	events := make(chan DiskEvent, 10)
+	d := NewEventDisk(NewMemDisk(10), events)
+	d.Write(3, mkBlock(1))
+	d.Read(3)
+	d.ReadTo(4, make(Block, BlockSize))
+	d.Trim(5)
+	d.Barrier()
+	close(events)
+
+	var ops []DiskOp
+	var addrs []uint64
+	var last uint64
+	for e := range events {
+		ops = append(ops, e.Op)
+		addrs = append(addrs, e.Addr)
+		assert.GreaterOrEqual(e.Time, last)
+		last = e.Time
+	}
+	assert.Equal([]DiskOp{OpWrite, OpRead, OpRead, OpTrim, OpBarrier}, ops)
+	assert.Equal([]uint64{3, 3, 4, 5, 0}, addrs)
+	assert.Equal("trim", OpTrim.String())
+}
+
+func TestEventDiskFullChannel(t *testing.T) {
+	events := make(chan DiskEvent, 1)
+	d := NewEventDisk(NewMemDisk(10), events)
+	for i := 0; i < 10; i++ {
+		d.Write(1, mkBlock(byte(i)))
+	}
+	assert.Equal(t, mkBlock(9), d.Read(1))
+	assert.Len(t, events, 1)
+	assert.Equal(t, OpWrite, (<-events).Op)
+}
@@ -0,0 +1,224 @@
+package disk
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/goose-lang/primitive"
+)
+
+// FaultKind is a kind of fault a FaultDisk can inject.
+type FaultKind uint8
+
+const (
+	// FaultFail makes the operation fail without taking effect: the Disk
+	// methods panic with a *FaultError, and the Try methods return it.
+	FaultFail FaultKind = iota
+	// FaultDropWrite makes a write or trim silently do nothing, so later
+	// reads return stale data.
+	FaultDropWrite
+	// FaultBitFlip flips one bit of the data: for a read, in the returned
+	// block only; for a write, in the block as stored.
+	FaultBitFlip
+)
+
+// AnyAddr matches every address in a Fault.
+const AnyAddr uint64 = ^uint64(0)
+
+// Fault schedules faults on a FaultDisk.
+type Fault struct {
+	Op   DiskOp
+	Addr uint64 // AnyAddr to match every address
+	Kind FaultKind
+	// Times is how many matching operations to affect; 0 means all of them.
+	Times uint64
+}
+
+// FaultError is the error of an operation failed by a FaultFail fault.
+type FaultError struct {
+	Op   DiskOp
+	Addr uint64
+}
+
+func (e *FaultError) Error() string {
+	return fmt.Sprintf("injected %v fault at %d", e.Op, e.Addr)
+}
+
+// FaultDisk wraps a Disk and injects faults into the operations that match
+// a schedule of Faults.
+//
+// When several scheduled faults match an operation, the one injected first
+// applies. Bit positions for FaultBitFlip are chosen by a generator seeded at
+// construction, so a run can be reproduced.
+type FaultDisk struct {
+	d      Disk
+	m      *sync.Mutex
+	rng    *primitive.Rand
+	faults *[]Fault
+}
+
+var _ Disk = FaultDisk{}
+
+// NewFaultDisk wraps d with an empty fault schedule.
+func NewFaultDisk(d Disk, seed uint64) FaultDisk {
+	return FaultDisk{
+		d:      d,
+		m:      new(sync.Mutex),
+		rng:    primitive.NewRand(seed),
+		faults: new([]Fault),
+	}
+}
+
+// BlockSize returns the block size of the wrapped disk.
+func (d FaultDisk) BlockSize() uint64 {
+	return BlockSizeOf(d.d)
+}
+
+// Inject adds f to the schedule.
+//
+// Requires f.Kind to make sense for f.Op: FaultDropWrite applies to writes
+// and trims, and FaultBitFlip to reads and writes.
+func (d FaultDisk) Inject(f Fault) {
+	switch f.Kind {
+	case FaultDropWrite:
+		primitive.Assume(f.Op == OpWrite || f.Op == OpTrim)
+	case FaultBitFlip:
+		primitive.Assume(f.Op == OpRead || f.Op == OpWrite)
+	}
+	d.m.Lock()
+	defer d.m.Unlock()
+	*d.faults = append(*d.faults, f)
+}
+
+// ClearFaults removes every scheduled fault.
+func (d FaultDisk) ClearFaults() {
+	d.m.Lock()
+	defer d.m.Unlock()
+	*d.faults = nil
+}
+
+// take finds the fault to inject into an operation, consuming one of its
+// uses. If there is one, it returns its kind and a random bit index into a
+// block.
+func (d FaultDisk) take(op DiskOp, a uint64) (kind FaultKind, bit uint64, ok bool) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	faults := *d.faults
+	for i, f := range faults {
+		if f.Op != op || (f.Addr != AnyAddr && f.Addr != a) {
+			continue
+		}
+		if f.Times == 1 {
+			*d.faults = append(faults[:i:i], faults[i+1:]...)
+		} else if f.Times > 1 {
+			faults[i].Times--
+		}
+		return f.Kind, d.rng.Uint64n(BlockSizeOf(d.d) * 8), true
+	}
+	return 0, 0, false
+}
+
+func flipBit(b Block, bit uint64) {
+	b[bit/8] ^= 1 << (bit % 8)
+}
+
+// TryReadTo is like ReadTo, but returns the error of an injected failure
+// rather than panicking.
+func (d FaultDisk) TryReadTo(a uint64, buf Block) error {
+	kind, bit, ok := d.take(OpRead, a)
+	if ok && kind == FaultFail {
+		return &FaultError{Op: OpRead, Addr: a}
+	}
+	d.d.ReadTo(a, buf)
+	if ok && kind == FaultBitFlip {
+		flipBit(buf, bit)
+	}
+	return nil
+}
+
+// TryWrite is like Write, but returns the error of an injected failure rather
+// than panicking.
+func (d FaultDisk) TryWrite(a uint64, v Block) error {
+	kind, bit, ok := d.take(OpWrite, a)
+	if !ok {
+		d.d.Write(a, v)
+		return nil
+	}
+	switch kind {
+	case FaultFail:
+		return &FaultError{Op: OpWrite, Addr: a}
+	case FaultBitFlip:
+		corrupt := append(Block(nil), v...)
+		flipBit(corrupt, bit)
+		d.d.Write(a, corrupt)
+	}
+	return nil
+}
+
+// TryTrim is like Trim, but returns the error of an injected failure rather
+// than panicking.
+func (d FaultDisk) TryTrim(a uint64) error {
+	kind, _, ok := d.take(OpTrim, a)
+	if !ok {
+		d.d.Trim(a)
+		return nil
+	}
+	if kind == FaultFail {
+		return &FaultError{Op: OpTrim, Addr: a}
+	}
+	return nil
+}
+
+// TryBarrier is like Barrier, but returns the error of an injected failure
+// rather than panicking.
+func (d FaultDisk) TryBarrier() error {
+	if _, _, ok := d.take(OpBarrier, 0); ok {
+		return &FaultError{Op: OpBarrier}
+	}
+	d.d.Barrier()
+	return nil
+}
+
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (d FaultDisk) ReadTo(a uint64, buf Block) {
+	must(d.TryReadTo(a, buf))
+}
+
+func (d FaultDisk) Read(a uint64) Block {
+	buf := make(Block, BlockSizeOf(d.d))
+	d.ReadTo(a, buf)
+	return buf
+}
+
+func (d FaultDisk) Write(a uint64, v Block) {
+	must(d.TryWrite(a, v))
+}
+
+func (d FaultDisk) Trim(a uint64) {
+	must(d.TryTrim(a))
+}
+
+func (d FaultDisk) Prefetch(a uint64, count uint64) {
+	d.d.Prefetch(a, count)
+}
+
+func (d FaultDisk) Size() uint64 {
+	return d.d.Size()
+}
+
+func (d FaultDisk) Resize(newSize uint64) {
+	d.d.Resize(newSize)
+}
+
+func (d FaultDisk) Barrier() {
+	must(d.TryBarrier())
+}
+
+func (d FaultDisk) Close() {
+	d.d.Close()
+}
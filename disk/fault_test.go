@@ -0,0 +1,59 @@
+package disk
+
+import (
+	"math/bits"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bitsDiffer(a, b Block) int {
+	n := 0
+	for i := range a {
+		n += bits.OnesCount8(a[i] ^ b[i])
+	}
+	return n
+}
+
+func TestFaultDiskFail(t *testing.T) {
+	assert := assert.New(t)
+	d := NewFaultDisk(NewMemDisk(10), 1)
+	d.Inject(Fault{Op: OpWrite, Addr: 3, Kind: FaultFail, Times: 1})
+	err := d.TryWrite(3, mkBlock(1))
+	assert.Equal(&FaultError{Op: OpWrite, Addr: 3}, err)
+	assert.Equal(mkBlock(0), d.Read(3), "failed write has no effect")
+	assert.NoError(d.TryWrite(3, mkBlock(1)), "one-shot fault is used up")
+
+	d.Inject(Fault{Op: OpBarrier, Kind: FaultFail})
+	assert.PanicsWithError("injected barrier fault at 0", d.Barrier)
+	assert.Panics(d.Barrier, "fault with Times 0 persists")
+	d.ClearFaults()
+	d.Barrier()
+}
+
+func TestFaultDiskDropWrite(t *testing.T) {
+	d := NewFaultDisk(NewMemDisk(10), 1)
+	d.Write(2, mkBlock(1))
+	d.Inject(Fault{Op: OpWrite, Addr: AnyAddr, Kind: FaultDropWrite, Times: 2})
+	d.Write(2, mkBlock(2))
+	d.Write(4, mkBlock(2))
+	d.Write(5, mkBlock(2))
+	assert.Equal(t, mkBlock(1), d.Read(2), "stale data")
+	assert.Equal(t, mkBlock(0), d.Read(4))
+	assert.Equal(t, mkBlock(2), d.Read(5))
+}
+
+func TestFaultDiskBitFlip(t *testing.T) {
+	assert := assert.New(t)
+	base := NewMemDisk(10)
+	d := NewFaultDisk(base, 1)
+	d.Write(1, mkBlock(1))
+	d.Inject(Fault{Op: OpRead, Addr: 1, Kind: FaultBitFlip, Times: 1})
+	assert.Equal(1, bitsDiffer(mkBlock(1), d.Read(1)))
+	assert.Equal(mkBlock(1), d.Read(1), "read corruption is transient")
+
+	d.Inject(Fault{Op: OpWrite, Addr: 1, Kind: FaultBitFlip, Times: 1})
+	d.Write(1, mkBlock(2))
+	assert.Equal(1, bitsDiffer(mkBlock(2), base.Read(1)), "write corruption persists")
+	assert.Panics(func() { d.Inject(Fault{Op: OpBarrier, Kind: FaultBitFlip}) })
+}
@@ -2,81 +2,426 @@ package disk
 
 import (
 	"fmt"
+	"sync/atomic"
+	"unsafe"
 
 	"golang.org/x/sys/unix"
 )
 
+// FileDisk is a disk backed by a file or block device, accessed with
+// pread(2) and pwrite(2). It is safe for concurrent use with the caveats of
+// the Disk interface: accesses to the same block are not atomic with respect
+// to each other.
 type FileDisk struct {
 	fd        int
-	numBlocks uint64
+	blockSize uint64
+	// direct is set if fd was opened with O_DIRECT, requiring aligned I/O
+	// buffers.
+	direct bool
+	// sparse is set if writes of zero blocks should punch holes.
+	sparse bool
+	// numBlocks is shared by all copies of the FileDisk, so Resize is visible
+	// through each of them.
+	numBlocks *atomic.Uint64
 }
 
+// FileDiskOpts configures how NewFileDiskOpts opens a file.
+type FileDiskOpts struct {
+	// BlockSize is the size of the disk's blocks, in bytes; 0 means the
+	// default BlockSize.
+	BlockSize uint64
+
+	// Direct opens the file with O_DIRECT, bypassing the OS page cache so
+	// that reads and writes go to the device. Only supported on Linux, and
+	// only on file systems that implement it (tmpfs, for example, does not);
+	// BlockSize must then be a multiple of DirectIOAlignment. Buffers passed
+	// to the disk need not be aligned: unaligned ones are copied through an
+	// aligned buffer, and blocks returned by Read are already aligned.
+	Direct bool
+
+	// Sync opens the file with O_DSYNC, so each write is durable when it
+	// returns and Barrier has nothing left to flush (it still issues an
+	// fsync).
+	Sync bool
+
+	// Sparse makes writes of all-zero blocks deallocate the block's storage
+	// (as Trim does) rather than writing zeros, so that a mostly-empty disk
+	// uses little space on the host. New files are always created sparse; this
+	// keeps them that way. It is incompatible with Sync, since deallocation is
+	// only made durable by Barrier.
+	Sparse bool
+}
+
+// DirectIOAlignment is the alignment of buffers, offsets and lengths used for
+// I/O on a disk opened with FileDiskOpts.Direct.
+const DirectIOAlignment uint64 = 4096
+
 func NewFileDisk(path string, numBlocks uint64) (FileDisk, error) {
-	fd, err := unix.Open(path, unix.O_RDWR|unix.O_CREAT, 0666)
+	return NewFileDiskOpts(path, numBlocks, FileDiskOpts{})
+}
+
+// NewFileDiskWithBlockSize is like NewFileDisk, but the disk has blocks of
+// blockSize bytes rather than BlockSize. The same block size must be used
+// every time a file is opened.
+func NewFileDiskWithBlockSize(path string, numBlocks uint64, blockSize uint64) (FileDisk, error) {
+	if blockSize == 0 {
+		return FileDisk{}, fmt.Errorf("block size must be positive")
+	}
+	return NewFileDiskOpts(path, numBlocks, FileDiskOpts{BlockSize: blockSize})
+}
+
+// NewFileDiskOpts is like NewFileDisk, with the file opened as configured by
+// opts.
+func NewFileDiskOpts(path string, numBlocks uint64, opts FileDiskOpts) (FileDisk, error) {
+	blockSize := opts.BlockSize
+	if blockSize == 0 {
+		blockSize = BlockSize
+	}
+	flags := unix.O_RDWR | unix.O_CREAT
+	if opts.Direct {
+		if directFlag == 0 {
+			return FileDisk{}, fmt.Errorf("O_DIRECT is not supported on this platform")
+		}
+		if blockSize%DirectIOAlignment != 0 {
+			return FileDisk{}, fmt.Errorf("block size %d is not a multiple of %d, as O_DIRECT requires",
+				blockSize, DirectIOAlignment)
+		}
+		flags |= directFlag
+	}
+	if opts.Sync {
+		if opts.Sparse {
+			return FileDisk{}, fmt.Errorf("Sparse cannot be combined with Sync")
+		}
+		flags |= dsyncFlag
+	}
+	_, length := blockRangeToBytes(blockSize, 0, numBlocks)
+	fd, err := unix.Open(path, flags, 0666)
 	if err != nil {
 		return FileDisk{}, err
 	}
 	var stat unix.Stat_t
 	err = unix.Fstat(fd, &stat)
 	if err != nil {
+		unix.Close(fd)
 		return FileDisk{}, err
 	}
-	if (stat.Mode&unix.S_IFREG) != 0 && uint64(stat.Size) != numBlocks {
-		err = unix.Ftruncate(fd, int64(numBlocks*BlockSize))
+	if (stat.Mode&unix.S_IFREG) != 0 && uint64(stat.Size) != length {
+		err = unix.Ftruncate(fd, int64(length))
 		if err != nil {
+			unix.Close(fd)
 			return FileDisk{}, err
 		}
 	}
-	return FileDisk{fd, numBlocks}, nil
+	d := FileDisk{
+		fd:        fd,
+		blockSize: blockSize,
+		direct:    opts.Direct,
+		sparse:    opts.Sparse,
+		numBlocks: new(atomic.Uint64),
+	}
+	d.numBlocks.Store(numBlocks)
+	return d, nil
+}
+
+// alignedBuf allocates a zeroed buffer of n bytes aligned to
+// DirectIOAlignment.
+func alignedBuf(n uint64) []byte {
+	buf := make([]byte, n+DirectIOAlignment)
+	off := DirectIOAlignment - uint64(uintptr(unsafe.Pointer(&buf[0])))%DirectIOAlignment
+	return buf[off : off+n : off+n]
+}
+
+func isAligned(b []byte) bool {
+	return len(b) == 0 || uint64(uintptr(unsafe.Pointer(&b[0])))%DirectIOAlignment == 0
+}
+
+// pread reads into buf from offset off of the file, using an aligned bounce
+// buffer if the file requires one.
+func (d FileDisk) pread(buf []byte, off int64) error {
+	if d.direct && !isAligned(buf) {
+		tmp := alignedBuf(uint64(len(buf)))
+		_, err := unix.Pread(d.fd, tmp, off)
+		copy(buf, tmp)
+		return err
+	}
+	_, err := unix.Pread(d.fd, buf, off)
+	return err
+}
+
+// pwrite writes buf to offset off of the file, using an aligned bounce buffer
+// if the file requires one.
+func (d FileDisk) pwrite(buf []byte, off int64) error {
+	if d.direct && !isAligned(buf) {
+		tmp := alignedBuf(uint64(len(buf)))
+		copy(tmp, buf)
+		buf = tmp
+	}
+	_, err := unix.Pwrite(d.fd, buf, off)
+	return err
+}
+
+// NewFileDiskExclusive is like NewFileDisk, but also takes an exclusive
+// advisory lock (flock(2)) on the file, returning an error if another open
+// FileDisk already holds it. The lock is released by Close.
+//
+// The lock is advisory: it only excludes other users of NewFileDiskExclusive
+// (or flock), not processes that open the file directly. flock is supported
+// on Linux, macOS and the BSDs.
+func NewFileDiskExclusive(path string, numBlocks uint64) (FileDisk, error) {
+	d, err := NewFileDisk(path, numBlocks)
+	if err != nil {
+		return FileDisk{}, err
+	}
+	err = unix.Flock(d.fd, unix.LOCK_EX|unix.LOCK_NB)
+	if err != nil {
+		unix.Close(d.fd)
+		if err == unix.EWOULDBLOCK {
+			return FileDisk{}, fmt.Errorf("%s is locked by another FileDisk", path)
+		}
+		return FileDisk{}, err
+	}
+	return d, nil
 }
 
 var _ Disk = FileDisk{}
 
-func (d FileDisk) ReadTo(a uint64, buf Block) {
-	if uint64(len(buf)) != BlockSize {
+// BlockSize returns the size of the disk's blocks, in bytes.
+func (d FileDisk) BlockSize() uint64 {
+	return d.blockSize
+}
+
+var _ ErrDisk = FileDisk{}
+
+// TryReadTo is like ReadTo, but returns an *OutOfBoundsError or *IOError
+// rather than panicking.
+func (d FileDisk) TryReadTo(a uint64, buf Block) error {
+	if uint64(len(buf)) != d.blockSize {
 		panic("buffer is not block-sized")
 	}
-	if a >= d.numBlocks {
-		panic(fmt.Errorf("out-of-bounds read at %v", a))
+	if a >= d.Size() {
+		return &OutOfBoundsError{Op: OpRead, Addr: a}
 	}
-	_, err := unix.Pread(d.fd, buf, int64(a*BlockSize))
+	err := d.pread(buf, int64(a*d.blockSize))
 	if err != nil {
-		panic("read failed: " + err.Error())
+		return &IOError{Op: OpRead, Addr: a, Err: err}
 	}
+	return nil
+}
+
+func (d FileDisk) ReadTo(a uint64, buf Block) {
+	must(d.TryReadTo(a, buf))
 }
 
 func (d FileDisk) Read(a uint64) Block {
-	buf := make([]byte, BlockSize)
+	var buf []byte
+	if d.direct {
+		buf = alignedBuf(d.blockSize)
+	} else {
+		buf = make([]byte, d.blockSize)
+	}
 	d.ReadTo(a, buf)
 	return buf
 }
 
-func (d FileDisk) Write(a uint64, v Block) {
-	if uint64(len(v)) != BlockSize {
+// TryWrite is like Write, but returns an *OutOfBoundsError or *IOError
+// rather than panicking.
+func (d FileDisk) TryWrite(a uint64, v Block) error {
+	if uint64(len(v)) != d.blockSize {
 		panic(fmt.Errorf("v is not block sized (%d bytes)", len(v)))
 	}
-	if a >= d.numBlocks {
-		panic(fmt.Errorf("out-of-bounds write at %v", a))
+	if a >= d.Size() {
+		return &OutOfBoundsError{Op: OpWrite, Addr: a}
+	}
+	var err error
+	if d.sparse && isZero(v) {
+		err = punchHole(d.fd, int64(a*d.blockSize), int64(d.blockSize))
+	} else {
+		err = d.pwrite(v, int64(a*d.blockSize))
+	}
+	if err != nil {
+		return &IOError{Op: OpWrite, Addr: a, Err: err}
+	}
+	return nil
+}
+
+func (d FileDisk) Write(a uint64, v Block) {
+	must(d.TryWrite(a, v))
+}
+
+func isZero(b []byte) bool {
+	for _, x := range b {
+		if x != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+var _ MultiWriter = FileDisk{}
+
+// WriteMulti writes the blocks with a single pwrite(2).
+func (d FileDisk) WriteMulti(a uint64, blocks []Block) {
+	checkMulti(d.blockSize, d.Size(), a, blocks)
+	buf := alignedBuf(uint64(len(blocks)) * d.blockSize)
+	for i, v := range blocks {
+		copy(buf[uint64(i)*d.blockSize:], v)
 	}
-	_, err := unix.Pwrite(d.fd, v, int64(a*BlockSize))
+	err := d.pwrite(buf, int64(a*d.blockSize))
 	if err != nil {
 		panic("write failed: " + err.Error())
 	}
 }
 
+// maxIovecs is the most buffers passed to one preadv or pwritev (Linux's
+// IOV_MAX).
+const maxIovecs = 1024
+
+var _ VecDisk = FileDisk{}
+
+// ReadVec reads each run of consecutive addresses with a single preadv(2)
+// (on platforms without it, one pread per block).
+func (d FileDisk) ReadVec(addrs []uint64) []Block {
+	size := d.Size()
+	for _, a := range addrs {
+		if a >= size {
+			panic(fmt.Errorf("out-of-bounds read at %v", a))
+		}
+	}
+	bufs := make(map[uint64]Block, len(addrs))
+	for _, run := range vecRuns(addrs, maxIovecs) {
+		iovs := make([][]byte, len(run))
+		for i, a := range run {
+			iovs[i] = alignedBuf(d.blockSize)
+			bufs[a] = iovs[i]
+		}
+		err := preadv(d.fd, iovs, int64(run[0]*d.blockSize))
+		if err != nil {
+			panic("read failed: " + err.Error())
+		}
+	}
+	blocks := make([]Block, len(addrs))
+	used := make(map[uint64]bool, len(bufs))
+	for i, a := range addrs {
+		if used[a] {
+			// a duplicate: give it its own copy
+			blocks[i] = append(alignedBuf(d.blockSize)[:0], bufs[a]...)
+			continue
+		}
+		blocks[i] = bufs[a]
+		used[a] = true
+	}
+	return blocks
+}
+
+// WriteVec writes each run of consecutive addresses with a single
+// pwritev(2) (on platforms without it, one pwrite per block).
+func (d FileDisk) WriteVec(addrs []uint64, blocks []Block) {
+	checkVec(d.blockSize, d.Size(), addrs, blocks)
+	last := make(map[uint64]Block, len(addrs))
+	for i, a := range addrs {
+		last[a] = blocks[i]
+	}
+	for _, run := range vecRuns(addrs, maxIovecs) {
+		iovs := make([][]byte, len(run))
+		for i, a := range run {
+			iovs[i] = last[a]
+			if d.direct && !isAligned(iovs[i]) {
+				iovs[i] = append(alignedBuf(d.blockSize)[:0], iovs[i]...)
+			}
+		}
+		err := pwritev(d.fd, iovs, int64(run[0]*d.blockSize))
+		if err != nil {
+			panic("write failed: " + err.Error())
+		}
+	}
+}
+
+// Trim releases the storage for block a, so that it reads as zeros. See
+// punchHole for how this is done on each platform.
+func (d FileDisk) Trim(a uint64) {
+	must(d.TryTrim(a))
+}
+
+// TryTrim is like Trim, but returns an *OutOfBoundsError or *IOError rather
+// than panicking.
+func (d FileDisk) TryTrim(a uint64) error {
+	if a >= d.Size() {
+		return &OutOfBoundsError{Op: OpTrim, Addr: a}
+	}
+	err := punchHole(d.fd, int64(a*d.blockSize), int64(d.blockSize))
+	if err != nil {
+		return &IOError{Op: OpTrim, Addr: a, Err: err}
+	}
+	return nil
+}
+
+// Prefetch asks the host to start reading the range into its page cache
+// (with posix_fadvise(2) on Linux). As a hint, it ignores any error.
+func (d FileDisk) Prefetch(a uint64, count uint64) {
+	a, n := clampRange(a, count, d.Size())
+	if n == 0 {
+		return
+	}
+	off, length := blockRangeToBytes(d.blockSize, a, n)
+	_ = readahead(d.fd, int64(off), int64(length))
+}
+
 func (d FileDisk) Size() uint64 {
-	return d.numBlocks
+	return d.numBlocks.Load()
+}
+
+// AllocatedBytes returns the amount of storage the host file system has
+// allocated for the disk's file, which for a sparse file can be much less
+// than its size. It also counts any metadata blocks the file system charges
+// to the file.
+func (d FileDisk) AllocatedBytes() uint64 {
+	var stat unix.Stat_t
+	err := unix.Fstat(d.fd, &stat)
+	if err != nil {
+		panic("stat failed: " + err.Error())
+	}
+	// st_blocks is in 512-byte units regardless of the file system's block
+	// size.
+	return uint64(stat.Blocks) * 512
+}
+
+// Resize changes the size of the disk by truncating the underlying file.
+//
+// For a disk backed by a device rather than a regular file, Resize only
+// changes the size reported by Size; the caller is responsible for the
+// device actually having that many blocks.
+func (d FileDisk) Resize(newSize uint64) {
+	var stat unix.Stat_t
+	err := unix.Fstat(d.fd, &stat)
+	if err != nil {
+		panic("resize failed: " + err.Error())
+	}
+	if (stat.Mode & unix.S_IFREG) != 0 {
+		_, length := blockRangeToBytes(d.blockSize, 0, newSize)
+		err = unix.Ftruncate(d.fd, int64(length))
+		if err != nil {
+			panic("resize failed: " + err.Error())
+		}
+	}
+	d.numBlocks.Store(newSize)
 }
 
 func (d FileDisk) Barrier() {
+	must(d.TryBarrier())
+}
+
+// TryBarrier is like Barrier, but returns an *IOError rather than panicking
+// if the fsync fails.
+func (d FileDisk) TryBarrier() error {
 	// NOTE: on macOS, this flushes to the drive but doesn't actually issue a
 	// disk barrier; see https://golang.org/src/internal/poll/fd_fsync_darwin.go
 	// for more details. The correct replacement is to issue a fcntl syscall with
 	// cmd F_FULLFSYNC.
 	err := unix.Fsync(d.fd)
 	if err != nil {
-		panic("file sync failed: " + err.Error())
+		return &IOError{Op: OpBarrier, Err: err}
 	}
+	return nil
 }
 
 func (d FileDisk) Close() {
@@ -85,3 +430,10 @@ func (d FileDisk) Close() {
 		panic(err)
 	}
 }
+
+// zeroRange overwrites the byte range [off, off+length) of fd's file with
+// zeros.
+func zeroRange(fd int, off int64, length int64) error {
+	_, err := unix.Pwrite(fd, alignedBuf(uint64(length)), off)
+	return err
+}
@@ -0,0 +1,40 @@
+package disk
+
+import "golang.org/x/sys/unix"
+
+// punchHole deallocates the byte range [off, off+length) of fd's file without
+// changing its size, so the range reads as zeros.
+//
+// If the file system does not support hole punching, the range is
+// overwritten with zeros instead.
+func punchHole(fd int, off int64, length int64) error {
+	err := unix.Fallocate(fd, unix.FALLOC_FL_PUNCH_HOLE|unix.FALLOC_FL_KEEP_SIZE, off, length)
+	if err == unix.EOPNOTSUPP || err == unix.ENOSYS {
+		return zeroRange(fd, off, length)
+	}
+	return err
+}
+
+// directFlag is the open(2) flag for FileDiskOpts.Direct.
+const directFlag = unix.O_DIRECT
+
+// dsyncFlag is the open(2) flag for FileDiskOpts.Sync.
+const dsyncFlag = unix.O_DSYNC
+
+// preadv reads into iovs from offset off of fd's file with one system call.
+func preadv(fd int, iovs [][]byte, off int64) error {
+	_, err := unix.Preadv(fd, iovs, off)
+	return err
+}
+
+// pwritev writes iovs to offset off of fd's file with one system call.
+func pwritev(fd int, iovs [][]byte, off int64) error {
+	_, err := unix.Pwritev(fd, iovs, off)
+	return err
+}
+
+// readahead asks the kernel to start reading the byte range [off, off+length)
+// of fd's file into the page cache.
+func readahead(fd int, off int64, length int64) error {
+	return unix.Fadvise(fd, off, length, unix.FADV_WILLNEED)
+}
@@ -0,0 +1,49 @@
+//go:build !linux
+
+package disk
+
+import "golang.org/x/sys/unix"
+
+// punchHole overwrites the byte range [off, off+length) of fd's file with
+// zeros. Portable hole punching is not available, so unlike on Linux this
+// does not free any storage.
+func punchHole(fd int, off int64, length int64) error {
+	return zeroRange(fd, off, length)
+}
+
+// directFlag is the open(2) flag for FileDiskOpts.Direct, which is not
+// supported on this platform.
+const directFlag = 0
+
+// dsyncFlag is the open(2) flag for FileDiskOpts.Sync. O_DSYNC is not
+// available everywhere, so this uses the stronger O_SYNC.
+const dsyncFlag = unix.O_SYNC
+
+// preadv reads into iovs from offset off of fd's file, one pread per
+// buffer, since preadv is not available everywhere.
+func preadv(fd int, iovs [][]byte, off int64) error {
+	for _, iov := range iovs {
+		if _, err := unix.Pread(fd, iov, off); err != nil {
+			return err
+		}
+		off += int64(len(iov))
+	}
+	return nil
+}
+
+// pwritev writes iovs to offset off of fd's file, one pwrite per buffer.
+func pwritev(fd int, iovs [][]byte, off int64) error {
+	for _, iov := range iovs {
+		if _, err := unix.Pwrite(fd, iov, off); err != nil {
+			return err
+		}
+		off += int64(len(iov))
+	}
+	return nil
+}
+
+// readahead does nothing: posix_fadvise is not available everywhere, and a
+// background read could race with Close and read from a reused descriptor.
+func readahead(fd int, off int64, length int64) error {
+	return nil
+}
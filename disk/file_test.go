@@ -0,0 +1,37 @@
+package disk
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileDiskSparse(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "disk.img")
+	d, err := NewFileDiskOpts(path, 1000, FileDiskOpts{Sparse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	empty := d.AllocatedBytes()
+	assert.Less(empty, 1000*BlockSize, "a new file should be sparse")
+
+	d.Write(5, mkBlock(1))
+	written := d.AllocatedBytes()
+	assert.Greater(written, empty)
+
+	d.Write(5, make(Block, BlockSize))
+	assert.Equal(make(Block, BlockSize), d.Read(5))
+	if runtime.GOOS == "linux" {
+		assert.Less(d.AllocatedBytes(), written, "a zero write should free the block")
+	}
+}
+
+func TestFileDiskSparseSync(t *testing.T) {
+	_, err := NewFileDiskOpts(filepath.Join(t.TempDir(), "disk.img"), 1,
+		FileDiskOpts{Sparse: true, Sync: true})
+	assert.Error(t, err)
+}
@@ -0,0 +1,196 @@
+package disk
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+// A JournalDisk lays out the underlying disk as
+//
+//	block 0                            journal header
+//	blocks [1, 1+MaxJournalTxn)        journal: data of the committed transaction
+//	blocks [1+MaxJournalTxn, ...)      the JournalDisk's own blocks
+//
+// The header holds the number n of blocks in the committed transaction,
+// little-endian, followed by their n addresses; n = 0 means the journal is
+// empty. Writing the header is the commit point, so single-block writes must
+// be atomic (as the disk model assumes).
+
+// MaxJournalTxn is the largest number of blocks in one AtomicWrite, limited
+// by how many addresses fit in the journal header.
+const MaxJournalTxn uint64 = (BlockSize - 8) / 8
+
+const journalOverhead = 1 + MaxJournalTxn
+
+// JournalDisk wraps a Disk to provide atomic multi-block writes: after a
+// crash, either all or none of the blocks of each AtomicWrite are updated.
+//
+// The first 1+MaxJournalTxn blocks of the underlying disk hold the journal,
+// and the JournalDisk's address 0 is the underlying disk's block
+// 1+MaxJournalTxn. Each AtomicWrite issues four barriers, and operations on a
+// JournalDisk are serialized.
+type JournalDisk struct {
+	d Disk
+	m *sync.Mutex
+}
+
+var _ Disk = JournalDisk{}
+
+// NewJournalDisk opens a journaled disk over d, first completing any
+// transaction that was committed but not yet installed when the system last
+// crashed. A fresh (zeroed) disk has an empty journal and needs no
+// formatting.
+//
+// Requires d.Size() >= 1+MaxJournalTxn.
+func NewJournalDisk(d Disk) JournalDisk {
+	requireBlockSize("JournalDisk", d)
+	if d.Size() < journalOverhead {
+		panic(fmt.Errorf("disk too small for a journal (%d < %d blocks)",
+			d.Size(), journalOverhead))
+	}
+	jd := JournalDisk{d: d, m: new(sync.Mutex)}
+	jd.recover()
+	return jd
+}
+
+// recover installs the committed transaction, if any.
+func (jd JournalDisk) recover() {
+	hdr := jd.d.Read(0)
+	n := binary.LittleEndian.Uint64(hdr[0:8])
+	if n == 0 {
+		return
+	}
+	if n > MaxJournalTxn {
+		panic(fmt.Errorf("corrupt journal header (%d blocks)", n))
+	}
+	addrs := make([]uint64, n)
+	for i := range addrs {
+		addrs[i] = binary.LittleEndian.Uint64(hdr[8+8*i:])
+	}
+	jd.install(addrs)
+}
+
+// install copies the journaled blocks of addrs to their home locations and
+// then empties the journal.
+func (jd JournalDisk) install(addrs []uint64) {
+	buf := make(Block, BlockSize)
+	for i, a := range addrs {
+		jd.d.ReadTo(1+uint64(i), buf)
+		jd.d.Write(journalOverhead+a, buf)
+	}
+	jd.d.Barrier()
+	jd.d.Write(0, make(Block, BlockSize))
+	jd.d.Barrier()
+}
+
+// AtomicWrite writes blocks[i] to addrs[i] for each i, atomically with
+// respect to crashes, and durably: once it returns, the writes survive a
+// crash. If an address appears more than once, the last block for it wins.
+//
+// Requires len(addrs) == len(blocks) <= MaxJournalTxn, each address to be
+// < Size(), and each block to be BlockSize bytes.
+func (jd JournalDisk) AtomicWrite(addrs []uint64, blocks []Block) {
+	if len(addrs) != len(blocks) {
+		panic(fmt.Errorf("%d addresses for %d blocks", len(addrs), len(blocks)))
+	}
+	if uint64(len(addrs)) > MaxJournalTxn {
+		panic(fmt.Errorf("transaction too large (%d blocks)", len(addrs)))
+	}
+	jd.m.Lock()
+	defer jd.m.Unlock()
+	size := jd.size()
+	for i, a := range addrs {
+		if a >= size {
+			panic(fmt.Errorf("out-of-bounds write at %v", a))
+		}
+		if uint64(len(blocks[i])) != BlockSize {
+			panic(fmt.Errorf("v is not block-sized (%d bytes)", len(blocks[i])))
+		}
+	}
+	if len(addrs) == 0 {
+		return
+	}
+	for i, b := range blocks {
+		jd.d.Write(1+uint64(i), b)
+	}
+	jd.d.Barrier()
+	hdr := make(Block, BlockSize)
+	binary.LittleEndian.PutUint64(hdr[0:8], uint64(len(addrs)))
+	for i, a := range addrs {
+		binary.LittleEndian.PutUint64(hdr[8+8*i:], a)
+	}
+	jd.d.Write(0, hdr)
+	jd.d.Barrier()
+	jd.install(addrs)
+}
+
+// size is the JournalDisk's size; the caller must hold jd.m.
+func (jd JournalDisk) size() uint64 {
+	return jd.d.Size() - journalOverhead
+}
+
+func (jd JournalDisk) checkAddr(a uint64) {
+	if a >= jd.size() {
+		panic(fmt.Errorf("out-of-bounds access at %v", a))
+	}
+}
+
+func (jd JournalDisk) ReadTo(a uint64, buf Block) {
+	jd.m.Lock()
+	defer jd.m.Unlock()
+	jd.checkAddr(a)
+	jd.d.ReadTo(journalOverhead+a, buf)
+}
+
+func (jd JournalDisk) Read(a uint64) Block {
+	buf := make(Block, BlockSize)
+	jd.ReadTo(a, buf)
+	return buf
+}
+
+// Write writes a single block. It is atomic without going through the
+// journal, but like a Write to the underlying disk it is only durable after a
+// Barrier.
+func (jd JournalDisk) Write(a uint64, v Block) {
+	jd.m.Lock()
+	defer jd.m.Unlock()
+	jd.checkAddr(a)
+	jd.d.Write(journalOverhead+a, v)
+}
+
+func (jd JournalDisk) Trim(a uint64) {
+	jd.m.Lock()
+	defer jd.m.Unlock()
+	jd.checkAddr(a)
+	jd.d.Trim(journalOverhead + a)
+}
+
+func (jd JournalDisk) Prefetch(a uint64, count uint64) {
+	jd.m.Lock()
+	defer jd.m.Unlock()
+	a, n := clampRange(a, count, jd.size())
+	if n > 0 {
+		jd.d.Prefetch(journalOverhead+a, n)
+	}
+}
+
+func (jd JournalDisk) Size() uint64 {
+	jd.m.Lock()
+	defer jd.m.Unlock()
+	return jd.size()
+}
+
+func (jd JournalDisk) Resize(newSize uint64) {
+	jd.m.Lock()
+	defer jd.m.Unlock()
+	jd.d.Resize(journalOverhead + newSize)
+}
+
+func (jd JournalDisk) Barrier() {
+	jd.d.Barrier()
+}
+
+func (jd JournalDisk) Close() {
+	jd.d.Close()
+}
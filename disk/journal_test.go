@@ -0,0 +1,67 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// crashAfterDisk simulates a crash by panicking on the write after the first
+// writesLeft writes.
+type crashAfterDisk struct {
+	Disk
+	writesLeft *int
+}
+
+func (d crashAfterDisk) Write(a uint64, v Block) {
+	if *d.writesLeft == 0 {
+		panic("crash")
+	}
+	*d.writesLeft--
+	d.Disk.Write(a, v)
+}
+
+const journalTestSize = 10
+
+func TestJournalDiskAtomicWrite(t *testing.T) {
+	assert := assert.New(t)
+	base := NewMemDisk(journalOverhead + journalTestSize)
+	jd := NewJournalDisk(base)
+	assert.Equal(uint64(journalTestSize), jd.Size())
+	jd.AtomicWrite([]uint64{1, 5, 1}, []Block{mkBlock(1), mkBlock(2), mkBlock(3)})
+	assert.Equal(mkBlock(3), jd.Read(1), "last write to an address wins")
+	assert.Equal(mkBlock(2), jd.Read(5))
+	assert.Equal(mkBlock(2), base.Read(journalOverhead+5))
+	assert.Panics(func() { jd.AtomicWrite([]uint64{journalTestSize}, []Block{mkBlock(1)}) })
+}
+
+func TestJournalDiskCrashAtomicity(t *testing.T) {
+	addrs := []uint64{2, 3, 7}
+	blocks := []Block{mkBlock(1), mkBlock(2), mkBlock(3)}
+	// crash after every possible number of underlying writes
+	for k := 0; ; k++ {
+		base := NewMemDisk(journalOverhead + journalTestSize)
+		left := k
+		jd := NewJournalDisk(crashAfterDisk{Disk: base, writesLeft: &left})
+		crashed := func() (crashed bool) {
+			defer func() { crashed = recover() != nil }()
+			jd.AtomicWrite(addrs, blocks)
+			return
+		}()
+
+		jd = NewJournalDisk(base)
+		var updated int
+		for _, a := range addrs {
+			if jd.Read(a)[0] != 0 {
+				updated++
+			}
+		}
+		if updated != 0 && updated != len(addrs) {
+			t.Fatalf("crash after %d writes: %d of %d blocks updated", k, updated, len(addrs))
+		}
+		if !crashed {
+			assert.Equal(t, len(addrs), updated)
+			break
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package disk
+
+import "github.com/goose-lang/primitive"
+
+// MeasureBarrierLatency returns the average latency of a Barrier on d, in
+// nanoseconds, over samples trials.
+//
+// Each trial writes block 0 and then issues a Barrier, so that the barrier has
+// something to persist; only the barrier is timed. The write stores block 0's
+// existing contents, so the data on the disk is unchanged, but the disk is
+// written. Timings use TimeMonotonicNow, so wall-clock adjustments do not
+// affect them, but they include scheduling noise; use enough samples to
+// average it out.
+//
+// Requires samples > 0 and d.Size() > 0.
+func MeasureBarrierLatency(d Disk, samples uint64) uint64 {
+	primitive.Assume(samples > 0)
+	b := d.Read(0)
+	var total uint64
+	for i := uint64(0); i < samples; i++ {
+		d.Write(0, b)
+		start := primitive.TimeMonotonicNow()
+		d.Barrier()
+		total += primitive.TimeMonotonicNow() - start
+	}
+	return total / samples
+}
@@ -0,0 +1,29 @@
+package disk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type slowBarrierDisk struct {
+	Disk
+	delay time.Duration
+}
+
+func (d slowBarrierDisk) Barrier() {
+	time.Sleep(d.delay)
+	d.Disk.Barrier()
+}
+
+func TestMeasureBarrierLatency(t *testing.T) {
+	d := NewMemDisk(10)
+	d.Write(0, mkBlock(1))
+	fast := MeasureBarrierLatency(d, 10)
+	assert.Equal(t, mkBlock(1), d.Read(0), "contents are preserved")
+
+	slow := MeasureBarrierLatency(slowBarrierDisk{Disk: d, delay: time.Millisecond}, 5)
+	assert.GreaterOrEqual(t, slow, uint64(time.Millisecond))
+	assert.Greater(t, slow, fast)
+}
@@ -0,0 +1,44 @@
+package disk
+
+import (
+	"fmt"
+	"io"
+)
+
+// LoadDiskFromReader copies the contents of r onto consecutive blocks of d,
+// starting at block 0, and returns the number of blocks written.
+//
+// Data is read a block (of d's block size) at a time, tolerating short reads
+// from r. If the
+// stream ends partway through a block, that final block is zero-padded and
+// still written. If r holds more than d.Size() blocks of data, the first
+// d.Size() blocks are written and an error is returned. Errors from r other
+// than io.EOF are returned along with the number of blocks written so far.
+func LoadDiskFromReader(d Disk, r io.Reader) (uint64, error) {
+	buf := make(Block, BlockSizeOf(d))
+	var n uint64
+	for ; n < d.Size(); n++ {
+		m, err := io.ReadFull(r, buf)
+		if err == io.EOF {
+			return n, nil
+		}
+		if err == io.ErrUnexpectedEOF {
+			clear(buf[m:])
+			d.Write(n, buf)
+			return n + 1, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		d.Write(n, buf)
+	}
+	var extra [1]byte
+	m, err := io.ReadFull(r, extra[:])
+	if m > 0 {
+		return n, fmt.Errorf("input is larger than the disk (%d blocks)", d.Size())
+	}
+	if err != io.EOF {
+		return n, err
+	}
+	return n, nil
+}
@@ -0,0 +1,50 @@
+package disk
+
+import (
+	"bytes"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadDiskFromReader(t *testing.T) {
+	assert := assert.New(t)
+	src := make([]byte, 2*BlockSize+100)
+	for i := range src {
+		src[i] = byte(i%255 + 1)
+	}
+	d := NewMemDisk(5)
+	n, err := LoadDiskFromReader(d, bytes.NewReader(src))
+	assert.NoError(err)
+	assert.Equal(uint64(3), n)
+	assert.Equal(src[:BlockSize], d.Read(0))
+	assert.Equal(src[BlockSize:2*BlockSize], d.Read(1))
+	tail := d.Read(2)
+	assert.Equal(src[2*BlockSize:], tail[:100])
+	assert.Equal(make([]byte, BlockSize-100), tail[100:], "padded tail")
+	assert.Equal(mkBlock(0), d.Read(3))
+}
+
+func TestLoadDiskFromReaderShortReads(t *testing.T) {
+	src := bytes.Repeat([]byte{7}, int(2*BlockSize))
+	d := NewMemDisk(5)
+	n, err := LoadDiskFromReader(d, iotest.HalfReader(bytes.NewReader(src)))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), n)
+	assert.Equal(t, src[BlockSize:], d.Read(1))
+}
+
+func TestLoadDiskFromReaderExactFit(t *testing.T) {
+	d := NewMemDisk(2)
+	n, err := LoadDiskFromReader(d, bytes.NewReader(make([]byte, 2*BlockSize)))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), n)
+}
+
+func TestLoadDiskFromReaderTooLarge(t *testing.T) {
+	d := NewMemDisk(2)
+	n, err := LoadDiskFromReader(d, bytes.NewReader(make([]byte, 2*BlockSize+1)))
+	assert.Error(t, err)
+	assert.Equal(t, uint64(2), n)
+}
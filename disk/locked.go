@@ -0,0 +1,110 @@
+package disk
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/goose-lang/primitive"
+)
+
+// LockedDisk wraps a Disk to make every operation atomic with respect to the
+// others, so the Disk concurrency rules hold without external locking: each
+// operation holds a lock on the block it accesses, so conflicting accesses to
+// a block are serialized while accesses to different blocks proceed in
+// parallel. Resize and Close exclude every other operation.
+type LockedDisk struct {
+	d      Disk
+	blocks *primitive.LockMap
+	// whole is held shared by other operations and exclusively by Resize and
+	// Close.
+	whole *sync.RWMutex
+}
+
+var _ Disk = LockedDisk{}
+
+// NewLockedDisk wraps d to lock each access.
+func NewLockedDisk(d Disk) LockedDisk {
+	return LockedDisk{d: d, blocks: primitive.NewLockMap(), whole: new(sync.RWMutex)}
+}
+
+// BlockSize returns the block size of the wrapped disk.
+func (d LockedDisk) BlockSize() uint64 {
+	return BlockSizeOf(d.d)
+}
+
+// lock acquires the lock on block a, returning a function to release it.
+func (d LockedDisk) lock(a uint64) func() {
+	d.whole.RLock()
+	d.blocks.Acquire(a)
+	return func() {
+		d.blocks.Release(a)
+		d.whole.RUnlock()
+	}
+}
+
+func (d LockedDisk) ReadTo(a uint64, buf Block) {
+	defer d.lock(a)()
+	d.d.ReadTo(a, buf)
+}
+
+func (d LockedDisk) Read(a uint64) Block {
+	defer d.lock(a)()
+	return d.d.Read(a)
+}
+
+func (d LockedDisk) Write(a uint64, v Block) {
+	defer d.lock(a)()
+	d.d.Write(a, v)
+}
+
+func (d LockedDisk) Trim(a uint64) {
+	defer d.lock(a)()
+	d.d.Trim(a)
+}
+
+func (d LockedDisk) Prefetch(a uint64, count uint64) {
+	d.whole.RLock()
+	defer d.whole.RUnlock()
+	d.d.Prefetch(a, count)
+}
+
+var _ ConditionalWriter = LockedDisk{}
+
+// ConditionalWrite compares and writes block a under the block's lock, so it
+// is atomic with respect to every other operation on the LockedDisk.
+func (d LockedDisk) ConditionalWrite(a uint64, expected Block, v Block) bool {
+	bs := BlockSizeOf(d.d)
+	checkConditional(bs, expected, v)
+	defer d.lock(a)()
+	buf := make(Block, bs)
+	d.d.ReadTo(a, buf)
+	if !bytes.Equal(buf, expected) {
+		return false
+	}
+	d.d.Write(a, v)
+	return true
+}
+
+func (d LockedDisk) Size() uint64 {
+	d.whole.RLock()
+	defer d.whole.RUnlock()
+	return d.d.Size()
+}
+
+func (d LockedDisk) Resize(newSize uint64) {
+	d.whole.Lock()
+	defer d.whole.Unlock()
+	d.d.Resize(newSize)
+}
+
+func (d LockedDisk) Barrier() {
+	d.whole.RLock()
+	defer d.whole.RUnlock()
+	d.d.Barrier()
+}
+
+func (d LockedDisk) Close() {
+	d.whole.Lock()
+	defer d.whole.Unlock()
+	d.d.Close()
+}
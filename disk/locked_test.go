@@ -0,0 +1,64 @@
+package disk
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockedDisk(t *testing.T) {
+	assert := assert.New(t)
+	d := NewLockedDisk(NewMemDisk(10))
+	d.Write(1, mkBlock(1))
+	assert.Equal(mkBlock(1), d.Read(1))
+	d.Resize(20)
+	assert.Equal(uint64(20), d.Size())
+	assert.Panics(func() { d.Read(20) })
+	// the lock should be released after a panic
+	d.Write(19, mkBlock(2))
+	assert.Equal(mkBlock(2), d.Read(19))
+}
+
+func TestLockedDiskNoTornReads(t *testing.T) {
+	// FileDisk does not make same-block accesses atomic, but LockedDisk does
+	d := NewLockedDisk(tempFileDisk(t, 1))
+	defer d.Close()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(x byte) {
+			defer wg.Done()
+			b := make(Block, BlockSize)
+			for j := range b {
+				b[j] = x
+			}
+			for j := 0; j < 100; j++ {
+				d.Write(0, b)
+				v := d.Read(0)
+				for _, y := range v {
+					if y != v[0] {
+						t.Error("torn read")
+						return
+					}
+				}
+			}
+		}(byte(i))
+	}
+	wg.Wait()
+}
+
+func TestLockedDiskConditionalWrite(t *testing.T) {
+	d := NewLockedDisk(tempFileDisk(t, 1))
+	defer d.Close()
+	testConditionalCounter(t, d)
+}
+
+func tempFileDisk(t *testing.T, numBlocks uint64) FileDisk {
+	d, err := NewFileDisk(filepath.Join(t.TempDir(), "disk.img"), numBlocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
@@ -1,52 +1,212 @@
 package disk
 
 import (
+	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 )
 
 type MemDisk struct {
-	l      *sync.RWMutex
-	blocks [][BlockSize]byte
+	l         *sync.RWMutex
+	blockSize uint64
+	// data holds the blocks back to back. It is shared by all copies of the
+	// MemDisk, so Resize is visible through each of them.
+	data *[]byte
 }
 
 var _ Disk = MemDisk{}
 
 func NewMemDisk(numBlocks uint64) MemDisk {
-	blocks := make([][BlockSize]byte, numBlocks)
-	return MemDisk{l: new(sync.RWMutex), blocks: blocks}
+	return NewMemDiskWithBlockSize(numBlocks, BlockSize)
+}
+
+// NewMemDiskWithBlockSize creates a zero-filled in-memory disk of numBlocks
+// blocks of blockSize bytes each. Reads and writes then use blockSize-byte
+// buffers rather than BlockSize ones.
+//
+// Requires blockSize > 0.
+func NewMemDiskWithBlockSize(numBlocks uint64, blockSize uint64) MemDisk {
+	if blockSize == 0 {
+		panic("block size must be positive")
+	}
+	_, length := blockRangeToBytes(blockSize, 0, numBlocks)
+	data := make([]byte, length)
+	return MemDisk{l: new(sync.RWMutex), blockSize: blockSize, data: &data}
+}
+
+// BlockSize returns the size of the disk's blocks, in bytes.
+func (d MemDisk) BlockSize() uint64 {
+	return d.blockSize
+}
+
+// block returns the storage for block a; the caller must hold d.l.
+func (d MemDisk) block(a uint64) []byte {
+	return (*d.data)[a*d.blockSize : (a+1)*d.blockSize]
+}
+
+// numBlocks returns the size of the disk; the caller must hold d.l.
+func (d MemDisk) numBlocks() uint64 {
+	return uint64(len(*d.data)) / d.blockSize
 }
 
 func (d MemDisk) ReadTo(a uint64, buf Block) {
+	if uint64(len(buf)) != d.blockSize {
+		panic("buffer is not block-sized")
+	}
 	d.l.RLock()
 	defer d.l.RUnlock()
-	if a >= uint64(len(d.blocks)) {
-		panic(fmt.Errorf("out-of-bounds read at %v", a))
+	if a >= d.numBlocks() {
+		panic(&OutOfBoundsError{Op: OpRead, Addr: a})
 	}
-	copy(buf, d.blocks[a][:])
+	copy(buf, d.block(a))
 }
 
 func (d MemDisk) Read(a uint64) Block {
-	buf := make(Block, BlockSize)
+	buf := make(Block, d.blockSize)
 	d.ReadTo(a, buf)
 	return buf
 }
 
 func (d MemDisk) Write(a uint64, v Block) {
-	if uint64(len(v)) != BlockSize {
+	if uint64(len(v)) != d.blockSize {
 		panic(fmt.Errorf("v is not block-sized (%d bytes)", len(v)))
 	}
 	d.l.Lock()
 	defer d.l.Unlock()
-	if a >= uint64(len(d.blocks)) {
-		panic(fmt.Errorf("out-of-bounds write at %v", a))
+	if a >= d.numBlocks() {
+		panic(&OutOfBoundsError{Op: OpWrite, Addr: a})
+	}
+	copy(d.block(a), v)
+}
+
+// Trim zeroes block a.
+func (d MemDisk) Trim(a uint64) {
+	d.l.Lock()
+	defer d.l.Unlock()
+	if a >= d.numBlocks() {
+		panic(&OutOfBoundsError{Op: OpTrim, Addr: a})
+	}
+	clear(d.block(a))
+}
+
+// Prefetch does nothing, since the whole disk is already in memory.
+func (d MemDisk) Prefetch(a uint64, count uint64) {}
+
+var _ MultiWriter = MemDisk{}
+
+// WriteMulti writes the blocks under a single acquisition of the lock, so
+// concurrent readers see either none or all of them.
+func (d MemDisk) WriteMulti(a uint64, blocks []Block) {
+	d.l.Lock()
+	defer d.l.Unlock()
+	checkMulti(d.blockSize, d.numBlocks(), a, blocks)
+	for i, v := range blocks {
+		copy(d.block(a+uint64(i)), v)
+	}
+}
+
+var _ ConditionalWriter = MemDisk{}
+
+// ConditionalWrite compares and writes block a under the disk's lock.
+func (d MemDisk) ConditionalWrite(a uint64, expected Block, v Block) bool {
+	checkConditional(d.blockSize, expected, v)
+	d.l.Lock()
+	defer d.l.Unlock()
+	if a >= d.numBlocks() {
+		panic(&OutOfBoundsError{Op: OpWrite, Addr: a})
+	}
+	if !bytes.Equal(d.block(a), expected) {
+		return false
 	}
-	copy(d.blocks[a][:], v)
+	copy(d.block(a), v)
+	return true
 }
 
 func (d MemDisk) Size() uint64 {
-	// this never changes so we assume it's safe to run lock-free
-	return uint64(len(d.blocks))
+	d.l.RLock()
+	defer d.l.RUnlock()
+	return d.numBlocks()
+}
+
+// Resize changes the size of the disk to newSize blocks, discarding blocks
+// past the end when shrinking and adding zero blocks when growing.
+func (d MemDisk) Resize(newSize uint64) {
+	d.l.Lock()
+	defer d.l.Unlock()
+	_, length := blockRangeToBytes(d.blockSize, 0, newSize)
+	data := *d.data
+	if length <= uint64(len(data)) {
+		*d.data = data[:length]
+		return
+	}
+	*d.data = append(data, make([]byte, length-uint64(len(data)))...)
+}
+
+// Clone returns an independent copy of the disk: a point-in-time snapshot of
+// its current contents (taken atomically with respect to writes) that later
+// writes to either disk do not affect.
+//
+// The copy takes time and memory proportional to the disk's size.
+func (d MemDisk) Clone() MemDisk {
+	d.l.RLock()
+	defer d.l.RUnlock()
+	data := append([]byte(nil), *d.data...)
+	return MemDisk{l: new(sync.RWMutex), blockSize: d.blockSize, data: &data}
+}
+
+// SaveTo writes the disk's contents to the file at path as a raw image, the
+// same layout a FileDisk uses, replacing any existing file. The image is a
+// consistent snapshot, and is written to a temporary file and renamed into
+// place so an interrupted save leaves the old file intact.
+func (d MemDisk) SaveTo(path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	d.l.RLock()
+	_, err = f.Write(*d.data)
+	d.l.RUnlock()
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// LoadMemDisk creates an in-memory disk holding the raw image at path, as
+// written by SaveTo (or a FileDisk). The file's size must be a multiple of
+// BlockSize. Later writes do not affect the file.
+//
+// The raw image does not record a block size; use LoadMemDiskWithBlockSize
+// to load the image of a disk with a different one.
+func LoadMemDisk(path string) (MemDisk, error) {
+	return LoadMemDiskWithBlockSize(path, BlockSize)
+}
+
+// LoadMemDiskWithBlockSize is like LoadMemDisk, but creates a disk of
+// blockSize-byte blocks; the file's size must be a multiple of blockSize.
+//
+// Requires blockSize > 0.
+func LoadMemDiskWithBlockSize(path string, blockSize uint64) (MemDisk, error) {
+	if blockSize == 0 {
+		panic("block size must be positive")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return MemDisk{}, err
+	}
+	if uint64(len(data))%blockSize != 0 {
+		return MemDisk{}, fmt.Errorf("image size %d is not a multiple of the block size %d", len(data), blockSize)
+	}
+	return MemDisk{l: new(sync.RWMutex), blockSize: blockSize, data: &data}, nil
 }
 
 func (d MemDisk) Barrier() {}
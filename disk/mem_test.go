@@ -0,0 +1,75 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemDiskClone(t *testing.T) {
+	assert := assert.New(t)
+	d := NewMemDisk(10)
+	d.Write(1, mkBlock(1))
+	c := d.Clone()
+	assert.Equal(mkBlock(1), c.Read(1))
+
+	d.Write(1, mkBlock(2))
+	c.Write(2, mkBlock(3))
+	assert.Equal(mkBlock(1), c.Read(1), "clone is unaffected by later writes")
+	assert.Equal(mkBlock(0), d.Read(2), "original is unaffected by writes to the clone")
+
+	c.Resize(5)
+	assert.Equal(uint64(10), d.Size())
+}
+
+func TestMemDiskCloneBlockSize(t *testing.T) {
+	c := NewMemDiskWithBlockSize(4, 512).Clone()
+	assert.Equal(t, uint64(512), c.BlockSize())
+	assert.Equal(t, uint64(4), c.Size())
+}
+
+func TestMemDiskSaveLoad(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "disk.img")
+	d := NewMemDisk(10)
+	d.Write(3, mkBlock(1))
+	assert.NoError(d.SaveTo(path))
+
+	d.Write(3, mkBlock(2))
+	l, err := LoadMemDisk(path)
+	assert.NoError(err)
+	assert.Equal(uint64(10), l.Size())
+	assert.Equal(mkBlock(1), l.Read(3), "load should see the saved contents")
+
+	f, err := NewFileDisk(path, 10)
+	assert.NoError(err)
+	defer f.Close()
+	assert.Equal(mkBlock(1), f.Read(3), "the image should be usable as a FileDisk")
+}
+
+func TestMemDiskSaveLoadBlockSize(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "disk.img")
+	d := NewMemDiskWithBlockSize(6, 512)
+	b := make(Block, 512)
+	b[0], b[511] = 1, 2
+	d.Write(5, b)
+	assert.NoError(d.SaveTo(path))
+
+	l, err := LoadMemDiskWithBlockSize(path, 512)
+	assert.NoError(err)
+	assert.Equal(uint64(512), l.BlockSize())
+	assert.Equal(uint64(6), l.Size())
+	assert.Equal(b, l.Read(5))
+	_, err = LoadMemDisk(path)
+	assert.Error(err, "3072 bytes are not whole 4096-byte blocks")
+}
+
+func TestLoadMemDiskBadSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "disk.img")
+	assert.NoError(t, os.WriteFile(path, make([]byte, 100), 0644))
+	_, err := LoadMemDisk(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,109 @@
+package disk
+
+import "crypto/sha256"
+
+// Merkle trees over the blocks of a disk.
+//
+// Leaves are the SHA-256 hashes of each block prefixed with a 0x00 byte, and
+// interior nodes are the SHA-256 of 0x01 followed by the left and right child
+// hashes; the distinct prefixes keep a leaf from being confused with an
+// interior node. The tree is built bottom-up: whenever a level has an odd
+// number of nodes, the last node is paired with itself. A disk of one block
+// has its leaf hash as the root, and an empty disk has the all-zero root.
+//
+// With this shape, a proof for block a is the list of sibling hashes from the
+// leaf up to the root, and the bits of a (least significant first) say whether
+// each sibling is on the right (0) or the left (1). Because of the duplication,
+// a proof for the last block of a level can also verify at the address just
+// past it, so verifiers should separately check that a < Size().
+
+func merkleLeaf(block []byte) [32]byte {
+	h := sha256.New()
+	h.Write([]byte{0x00})
+	h.Write(block)
+	var out [32]byte
+	h.Sum(out[:0])
+	return out
+}
+
+func merkleNode(left, right [32]byte) [32]byte {
+	h := sha256.New()
+	h.Write([]byte{0x01})
+	h.Write(left[:])
+	h.Write(right[:])
+	var out [32]byte
+	h.Sum(out[:0])
+	return out
+}
+
+func merkleLeaves(d Disk) [][32]byte {
+	leaves := make([][32]byte, d.Size())
+	buf := make(Block, BlockSizeOf(d))
+	for a := range leaves {
+		d.ReadTo(uint64(a), buf)
+		leaves[a] = merkleLeaf(buf)
+	}
+	return leaves
+}
+
+func merkleParents(level [][32]byte) [][32]byte {
+	parents := make([][32]byte, (len(level)+1)/2)
+	for i := range parents {
+		left := level[2*i]
+		right := left
+		if 2*i+1 < len(level) {
+			right = level[2*i+1]
+		}
+		parents[i] = merkleNode(left, right)
+	}
+	return parents
+}
+
+// MerkleRoot returns the root of the Merkle tree over all of d's blocks.
+func MerkleRoot(d Disk) [32]byte {
+	level := merkleLeaves(d)
+	if len(level) == 0 {
+		return [32]byte{}
+	}
+	for len(level) > 1 {
+		level = merkleParents(level)
+	}
+	return level[0]
+}
+
+// MerkleProof returns the sibling hashes on the path from block a to the root.
+//
+// Expects a < d.Size().
+func MerkleProof(d Disk, a uint64) [][32]byte {
+	level := merkleLeaves(d)
+	if a >= uint64(len(level)) {
+		panic("out-of-bounds Merkle proof")
+	}
+	var proof [][32]byte
+	i := a
+	for len(level) > 1 {
+		sib := i ^ 1
+		if sib >= uint64(len(level)) {
+			sib = i
+		}
+		proof = append(proof, level[sib])
+		level = merkleParents(level)
+		i /= 2
+	}
+	return proof
+}
+
+// VerifyMerkleProof checks that block is the contents of address a in a disk
+// with Merkle root root, using proof from MerkleProof.
+func VerifyMerkleProof(root [32]byte, a uint64, block []byte, proof [][32]byte) bool {
+	h := merkleLeaf(block)
+	for _, sib := range proof {
+		if a&1 == 0 {
+			h = merkleNode(h, sib)
+		} else {
+			h = merkleNode(sib, h)
+		}
+		a >>= 1
+	}
+	return a == 0 && h == root
+}
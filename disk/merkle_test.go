@@ -0,0 +1,52 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func merkleTestDisk(size uint64) MemDisk {
+	d := NewMemDisk(size)
+	for a := uint64(0); a < size; a++ {
+		d.Write(a, mkBlock(byte(a+1)))
+	}
+	return d
+}
+
+func TestMerkleProofs(t *testing.T) {
+	for _, size := range []uint64{1, 2, 5, 8} {
+		d := merkleTestDisk(size)
+		root := MerkleRoot(d)
+		for a := uint64(0); a < size; a++ {
+			proof := MerkleProof(d, a)
+			assert.True(t, VerifyMerkleProof(root, a, d.Read(a), proof),
+				"size %d block %d", size, a)
+		}
+	}
+}
+
+func TestMerkleRejectsTampered(t *testing.T) {
+	d := merkleTestDisk(5)
+	root := MerkleRoot(d)
+	proof := MerkleProof(d, 3)
+	tampered := d.Read(3)
+	tampered[100] ^= 1
+	assert.False(t, VerifyMerkleProof(root, 3, tampered, proof))
+	assert.False(t, VerifyMerkleProof(root, 2, d.Read(3), proof),
+		"proof is bound to the address")
+}
+
+func TestMerkleRootChanges(t *testing.T) {
+	d := merkleTestDisk(5)
+	root := MerkleRoot(d)
+	assert.Equal(t, root, MerkleRoot(d), "root is deterministic")
+	d.Write(4, mkBlock(42))
+	assert.NotEqual(t, root, MerkleRoot(d))
+}
+
+func TestMerkleSingleBlock(t *testing.T) {
+	d := merkleTestDisk(1)
+	assert.Empty(t, MerkleProof(d, 0))
+	assert.Equal(t, merkleLeaf(d.Read(0)), MerkleRoot(d))
+}
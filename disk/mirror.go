@@ -0,0 +1,174 @@
+package disk
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MirrorDisk replicates a disk on two devices (RAID-1).
+//
+// Writes, trims and barriers go to both replicas, and reads alternate
+// between them. A replica whose operation fails (panics with one of the
+// failures AsErrDisk recognizes, such as an *IOError or *FaultError) is
+// marked failed and no longer used, so the MirrorDisk keeps working as long
+// as one replica does; operations only panic once both have failed. Other
+// panics, such as one for a wrongly-sized block, propagate without marking
+// either replica failed. A failed replica's contents
+// are stale and must be rebuilt from the other before it is used again.
+type MirrorDisk struct {
+	replicas [2]Disk
+	m        *sync.Mutex
+	failed   *[2]bool
+	next     *uint64
+	// size is the MirrorDisk's size, kept here so that Size (and the bounds
+	// checks) need not ask a replica that may have failed.
+	size *uint64
+}
+
+var _ Disk = MirrorDisk{}
+
+// NewMirrorDisk mirrors d1 and d2, which should start with the same contents
+// (for example, both zeroed). The MirrorDisk has the size of the smaller one.
+func NewMirrorDisk(d1 Disk, d2 Disk) MirrorDisk {
+	requireBlockSize("MirrorDisk", d1)
+	requireBlockSize("MirrorDisk", d2)
+	size := min(d1.Size(), d2.Size())
+	return MirrorDisk{
+		replicas: [2]Disk{d1, d2},
+		m:        new(sync.Mutex),
+		failed:   new([2]bool),
+		next:     new(uint64),
+		size:     &size,
+	}
+}
+
+// Failed reports which replicas have failed.
+func (d MirrorDisk) Failed() (first bool, second bool) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return d.failed[0], d.failed[1]
+}
+
+// try runs f on replica i, marking it failed and returning false if f panics
+// with a failure. Any other panic propagates.
+func (d MirrorDisk) try(i int, f func(r Disk)) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, isErr := r.(error); !isErr || !isFailure(e) {
+				panic(r)
+			}
+			d.m.Lock()
+			d.failed[i] = true
+			d.m.Unlock()
+			ok = false
+		}
+	}()
+	f(d.replicas[i])
+	return true
+}
+
+// live returns the indices of the replicas that have not failed, starting
+// with the next one to read from.
+func (d MirrorDisk) live() []int {
+	d.m.Lock()
+	defer d.m.Unlock()
+	first := int(*d.next % 2)
+	*d.next++
+	var is []int
+	for _, i := range []int{first, 1 - first} {
+		if !d.failed[i] {
+			is = append(is, i)
+		}
+	}
+	return is
+}
+
+// all runs f on every live replica, panicking if none succeeds.
+func (d MirrorDisk) all(op string, f func(r Disk)) {
+	ok := false
+	for _, i := range d.live() {
+		if d.try(i, f) {
+			ok = true
+		}
+	}
+	if !ok {
+		panic(fmt.Errorf("%s failed on both replicas", op))
+	}
+}
+
+func (d MirrorDisk) ReadTo(a uint64, buf Block) {
+	if uint64(len(buf)) != BlockSize {
+		panic("buffer is not block-sized")
+	}
+	if a >= d.Size() {
+		panic(fmt.Errorf("out-of-bounds read at %v", a))
+	}
+	for _, i := range d.live() {
+		if d.try(i, func(r Disk) { r.ReadTo(a, buf) }) {
+			return
+		}
+	}
+	panic(fmt.Errorf("read of %v failed on both replicas", a))
+}
+
+func (d MirrorDisk) Read(a uint64) Block {
+	buf := make(Block, BlockSize)
+	d.ReadTo(a, buf)
+	return buf
+}
+
+func (d MirrorDisk) Write(a uint64, v Block) {
+	if uint64(len(v)) != BlockSize {
+		panic(fmt.Errorf("v is not block-sized (%d bytes)", len(v)))
+	}
+	if a >= d.Size() {
+		panic(fmt.Errorf("out-of-bounds write at %v", a))
+	}
+	d.all("write", func(r Disk) { r.Write(a, v) })
+}
+
+func (d MirrorDisk) Trim(a uint64) {
+	if a >= d.Size() {
+		panic(fmt.Errorf("out-of-bounds trim at %v", a))
+	}
+	d.all("trim", func(r Disk) { r.Trim(a) })
+}
+
+// Prefetch prefetches on every live replica, since reads alternate between
+// them.
+func (d MirrorDisk) Prefetch(a uint64, count uint64) {
+	d.m.Lock()
+	failed := *d.failed
+	d.m.Unlock()
+	for i := range d.replicas {
+		if !failed[i] {
+			d.try(i, func(r Disk) { r.Prefetch(a, count) })
+		}
+	}
+}
+
+// Size returns the size of the smaller replica when the MirrorDisk was
+// created, or the size given to the last Resize.
+func (d MirrorDisk) Size() uint64 {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return *d.size
+}
+
+func (d MirrorDisk) Resize(newSize uint64) {
+	d.all("resize", func(r Disk) { r.Resize(newSize) })
+	d.m.Lock()
+	*d.size = newSize
+	d.m.Unlock()
+}
+
+// Barrier fences both replicas: when it returns, earlier writes are durable
+// on every live replica.
+func (d MirrorDisk) Barrier() {
+	d.all("barrier", func(r Disk) { r.Barrier() })
+}
+
+func (d MirrorDisk) Close() {
+	d.replicas[0].Close()
+	d.replicas[1].Close()
+}
@@ -0,0 +1,85 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMirrorDiskReplicates(t *testing.T) {
+	assert := assert.New(t)
+	d1, d2 := NewMemDisk(10), NewMemDisk(12)
+	d := NewMirrorDisk(d1, d2)
+	assert.Equal(uint64(10), d.Size())
+	d.Write(3, mkBlock(1))
+	assert.Equal(mkBlock(1), d1.Read(3))
+	assert.Equal(mkBlock(1), d2.Read(3))
+	for i := 0; i < 4; i++ {
+		assert.Equal(mkBlock(1), d.Read(3))
+	}
+	assert.Panics(func() { d.Write(10, mkBlock(1)) })
+}
+
+func TestMirrorDiskSurvivesFailure(t *testing.T) {
+	assert := assert.New(t)
+	d1 := NewFaultDisk(NewMemDisk(10), 1)
+	d2 := NewMemDisk(10)
+	d := NewMirrorDisk(d1, d2)
+	d.Write(1, mkBlock(1))
+
+	d1.Inject(Fault{Op: OpRead, Addr: AnyAddr, Kind: FaultFail})
+	for i := 0; i < 4; i++ {
+		assert.Equal(mkBlock(1), d.Read(1))
+	}
+	first, second := d.Failed()
+	assert.True(first)
+	assert.False(second)
+
+	// writes continue on the surviving replica only
+	d.Write(2, mkBlock(2))
+	d.Barrier()
+	assert.Equal(mkBlock(2), d2.Read(2))
+}
+
+func TestMirrorDiskBothFail(t *testing.T) {
+	d1 := NewFaultDisk(NewMemDisk(10), 1)
+	d2 := NewFaultDisk(NewMemDisk(10), 2)
+	d := NewMirrorDisk(d1, d2)
+	d1.Inject(Fault{Op: OpWrite, Addr: AnyAddr, Kind: FaultFail})
+	d2.Inject(Fault{Op: OpWrite, Addr: AnyAddr, Kind: FaultFail})
+	assert.Panics(t, func() { d.Write(1, mkBlock(1)) })
+}
+
+func TestMirrorDiskCallerBug(t *testing.T) {
+	assert := assert.New(t)
+	d := NewMirrorDisk(NewMemDisk(10), NewMemDisk(10))
+	assert.Panics(func() { d.Write(0, make(Block, 10)) })
+	assert.Panics(func() { d.ReadTo(0, make(Block, 10)) })
+	first, second := d.Failed()
+	assert.False(first, "a wrongly-sized block is not a replica failure")
+	assert.False(second)
+	d.Write(0, mkBlock(1))
+	assert.Equal(mkBlock(1), d.Read(0))
+}
+
+func TestMirrorDiskOtherPanicPropagates(t *testing.T) {
+	d := NewMirrorDisk(NewMemDisk(10), NewReadOnlyDisk(NewMemDisk(10)))
+	assert.PanicsWithError(t, "write to read-only disk at 1", func() { d.Write(1, mkBlock(1)) })
+	first, second := d.Failed()
+	assert.False(t, first)
+	assert.False(t, second)
+}
+
+func TestMirrorDiskSizeAfterFailure(t *testing.T) {
+	assert := assert.New(t)
+	d1 := NewFaultDisk(NewMemDisk(10), 1)
+	d := NewMirrorDisk(d1, NewMemDisk(10))
+	d1.Inject(Fault{Op: OpWrite, Addr: AnyAddr, Kind: FaultFail})
+	d.Write(1, mkBlock(1))
+	d1.Close()
+	assert.Equal(uint64(10), d.Size())
+	d.Resize(20)
+	assert.Equal(uint64(20), d.Size())
+	d.Write(15, mkBlock(2))
+	assert.Equal(mkBlock(2), d.Read(15))
+}
@@ -0,0 +1,226 @@
+package disk
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// MmapDisk is a disk backed by a memory-mapped file.
+//
+// Reads and writes copy directly to and from the mapping, so they take no
+// system call; Barrier flushes the mapping with msync(2). As with a FileDisk,
+// writes that have not been flushed may or may not survive a crash.
+//
+// An MmapDisk always uses blocks of BlockSize bytes (it does not implement
+// BlockSizer), so the file holds Size() blocks of that size; opening the
+// image of a FileDisk created with another block size reinterprets it in
+// 4096-byte blocks.
+type MmapDisk struct {
+	m  *sync.RWMutex
+	fd int
+	// data is the mapping, shared by all copies of the MmapDisk so that Resize
+	// is visible through each of them. It is nil for an empty disk, which
+	// cannot be mapped.
+	data *[]byte
+}
+
+var _ Disk = MmapDisk{}
+
+// NewMmapDisk opens (creating if needed) the file at path as a disk of
+// numBlocks blocks and maps it into memory, resizing the file to fit.
+func NewMmapDisk(path string, numBlocks uint64) (MmapDisk, error) {
+	_, length := BlockRangeToBytes(0, numBlocks)
+	fd, err := unix.Open(path, unix.O_RDWR|unix.O_CREAT, 0666)
+	if err != nil {
+		return MmapDisk{}, err
+	}
+	err = unix.Ftruncate(fd, int64(length))
+	if err != nil {
+		unix.Close(fd)
+		return MmapDisk{}, err
+	}
+	data, err := mapFile(fd, length)
+	if err != nil {
+		unix.Close(fd)
+		return MmapDisk{}, err
+	}
+	return MmapDisk{m: new(sync.RWMutex), fd: fd, data: &data}, nil
+}
+
+func mapFile(fd int, length uint64) ([]byte, error) {
+	if length == 0 {
+		return nil, nil
+	}
+	return unix.Mmap(fd, 0, int(length), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+}
+
+// block returns the mapping of block a; the caller must hold d.m.
+func (d MmapDisk) block(a uint64) []byte {
+	return (*d.data)[a*BlockSize : (a+1)*BlockSize]
+}
+
+// numBlocks returns the size of the disk; the caller must hold d.m.
+func (d MmapDisk) numBlocks() uint64 {
+	return uint64(len(*d.data)) / BlockSize
+}
+
+func (d MmapDisk) ReadTo(a uint64, buf Block) {
+	if uint64(len(buf)) != BlockSize {
+		panic("buffer is not block-sized")
+	}
+	d.m.RLock()
+	defer d.m.RUnlock()
+	if a >= d.numBlocks() {
+		panic(fmt.Errorf("out-of-bounds read at %v", a))
+	}
+	copy(buf, d.block(a))
+}
+
+func (d MmapDisk) Read(a uint64) Block {
+	buf := make(Block, BlockSize)
+	d.ReadTo(a, buf)
+	return buf
+}
+
+// Write copies v into the mapping. Writes to different blocks may run
+// concurrently; concurrent writes to the same block must be synchronized by
+// the caller, as for a FileDisk.
+func (d MmapDisk) Write(a uint64, v Block) {
+	if uint64(len(v)) != BlockSize {
+		panic(fmt.Errorf("v is not block-sized (%d bytes)", len(v)))
+	}
+	d.m.RLock()
+	defer d.m.RUnlock()
+	if a >= d.numBlocks() {
+		panic(fmt.Errorf("out-of-bounds write at %v", a))
+	}
+	copy(d.block(a), v)
+}
+
+var _ MultiWriter = MmapDisk{}
+
+// WriteMulti copies the blocks into the mapping under a single acquisition of
+// the lock.
+func (d MmapDisk) WriteMulti(a uint64, blocks []Block) {
+	d.m.RLock()
+	defer d.m.RUnlock()
+	checkMulti(BlockSize, d.numBlocks(), a, blocks)
+	for i, v := range blocks {
+		copy(d.block(a+uint64(i)), v)
+	}
+}
+
+var _ ConditionalWriter = MmapDisk{}
+
+// ConditionalWrite compares and writes block a. It takes the lock exclusively,
+// since plain writes only share it.
+func (d MmapDisk) ConditionalWrite(a uint64, expected Block, v Block) bool {
+	checkConditional(BlockSize, expected, v)
+	d.m.Lock()
+	defer d.m.Unlock()
+	if a >= d.numBlocks() {
+		panic(fmt.Errorf("out-of-bounds write at %v", a))
+	}
+	if !bytes.Equal(d.block(a), expected) {
+		return false
+	}
+	copy(d.block(a), v)
+	return true
+}
+
+// Trim zeroes block a and releases its storage in the file where supported.
+func (d MmapDisk) Trim(a uint64) {
+	d.m.RLock()
+	defer d.m.RUnlock()
+	if a >= d.numBlocks() {
+		panic(fmt.Errorf("out-of-bounds trim at %v", a))
+	}
+	err := punchHole(d.fd, int64(a*BlockSize), int64(BlockSize))
+	if err != nil {
+		panic("trim failed: " + err.Error())
+	}
+	// punching a hole is reflected in a shared mapping, but the fallback of
+	// writing zeros to the file need not be, so zero the mapping as well
+	clear(d.block(a))
+}
+
+// Prefetch asks the kernel to fault in the mapping of the range with
+// madvise(2). As a hint, it ignores any error.
+func (d MmapDisk) Prefetch(a uint64, count uint64) {
+	d.m.RLock()
+	defer d.m.RUnlock()
+	a, n := clampRange(a, count, d.numBlocks())
+	if n == 0 {
+		return
+	}
+	// madvise needs a page-aligned start, and pages may be larger than blocks
+	start := a * BlockSize &^ uint64(os.Getpagesize()-1)
+	_ = unix.Madvise((*d.data)[start:(a+n)*BlockSize], unix.MADV_WILLNEED)
+}
+
+func (d MmapDisk) Size() uint64 {
+	d.m.RLock()
+	defer d.m.RUnlock()
+	return d.numBlocks()
+}
+
+// Resize unmaps the file, truncates it to newSize blocks and maps it again.
+func (d MmapDisk) Resize(newSize uint64) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	_, length := BlockRangeToBytes(0, newSize)
+	if *d.data != nil {
+		err := unix.Munmap(*d.data)
+		if err != nil {
+			panic("resize failed: " + err.Error())
+		}
+		*d.data = nil
+	}
+	err := unix.Ftruncate(d.fd, int64(length))
+	if err != nil {
+		panic("resize failed: " + err.Error())
+	}
+	data, err := mapFile(d.fd, length)
+	if err != nil {
+		panic("resize failed: " + err.Error())
+	}
+	*d.data = data
+}
+
+// Barrier flushes the mapping (and the file's metadata) to the device.
+func (d MmapDisk) Barrier() {
+	d.m.RLock()
+	defer d.m.RUnlock()
+	if *d.data != nil {
+		err := unix.Msync(*d.data, unix.MS_SYNC)
+		if err != nil {
+			panic("msync failed: " + err.Error())
+		}
+	}
+	err := unix.Fsync(d.fd)
+	if err != nil {
+		panic("file sync failed: " + err.Error())
+	}
+}
+
+// Close unmaps and closes the file, without flushing; call Barrier first to
+// make writes durable.
+func (d MmapDisk) Close() {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if *d.data != nil {
+		err := unix.Munmap(*d.data)
+		if err != nil {
+			panic(err)
+		}
+		*d.data = nil
+	}
+	err := unix.Close(d.fd)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,40 @@
+package disk
+
+import "fmt"
+
+// MultiWriter is implemented by disks that can write a run of contiguous
+// blocks more cheaply than one Write per block.
+type MultiWriter interface {
+	// WriteMulti writes blocks[i] to address a+i for each i, as if by a
+	// sequence of Writes.
+	WriteMulti(a uint64, blocks []Block)
+}
+
+// WriteMulti writes blocks[i] to address a+i of d for each i.
+//
+// The effect is the same as calling d.Write for each block in order, in
+// particular the blocks are not written atomically; if d implements
+// MultiWriter the writes are issued as a single batch. Expects a+len(blocks)
+// <= d.Size() and every block to be BlockSize bytes.
+func WriteMulti(d Disk, a uint64, blocks []Block) {
+	if mw, ok := d.(MultiWriter); ok {
+		mw.WriteMulti(a, blocks)
+		return
+	}
+	for i, v := range blocks {
+		d.Write(a+uint64(i), v)
+	}
+}
+
+// checkMulti validates the arguments of a WriteMulti against a disk of size
+// blocks of blockSize bytes.
+func checkMulti(blockSize uint64, size uint64, a uint64, blocks []Block) {
+	for _, v := range blocks {
+		if uint64(len(v)) != blockSize {
+			panic(fmt.Errorf("v is not block-sized (%d bytes)", len(v)))
+		}
+	}
+	if a > size || uint64(len(blocks)) > size-a {
+		panic(fmt.Errorf("out-of-bounds write at %v", a+uint64(len(blocks))-1))
+	}
+}
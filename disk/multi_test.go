@@ -0,0 +1,31 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *DiskSuite) TestWriteMulti() {
+	d := suite.D
+	WriteMulti(d, 97, []Block{block1, block2, block1})
+	suite.Equal(block0, d.Read(96))
+	suite.Equal(block1, d.Read(97))
+	suite.Equal(block2, d.Read(98))
+	suite.Equal(block1, d.Read(99))
+	WriteMulti(d, 0, nil)
+	suite.Panics(func() { WriteMulti(d, 98, []Block{block1, block1, block1}) },
+		"out-of-bounds write")
+	suite.Equal(block2, d.Read(98), "out-of-bounds batch should not be written")
+}
+
+func TestWriteMultiFallback(t *testing.T) {
+	// EventDisk does not implement MultiWriter, so this goes block by block
+	events := make(chan DiskEvent, 10)
+	d := NewEventDisk(NewMemDisk(10), events)
+	WriteMulti(d, 2, []Block{mkBlock(1), mkBlock(2)})
+	assert.Equal(t, mkBlock(1), d.Read(2))
+	assert.Equal(t, mkBlock(2), d.Read(3))
+	assert.Equal(t, uint64(2), (<-events).Addr)
+	assert.Equal(t, uint64(3), (<-events).Addr)
+}
@@ -0,0 +1,80 @@
+package disk
+
+import "fmt"
+
+// OffsetDisk exposes the blocks [start, start+length) of a disk as a disk of
+// its own, like a partition: address a of the OffsetDisk is block start+a of
+// the underlying disk, and addresses outside the range are rejected, so a
+// component given an OffsetDisk cannot touch the rest of the device.
+//
+// Barrier is forwarded to the whole underlying disk, which may be shared by
+// several OffsetDisks. Close does nothing; close the underlying disk once,
+// separately.
+type OffsetDisk struct {
+	d      Disk
+	start  uint64
+	length uint64
+}
+
+var _ Disk = OffsetDisk{}
+
+// NewOffsetDisk creates a view of the blocks [start, start+length) of d.
+//
+// Requires start+length <= d.Size().
+func NewOffsetDisk(d Disk, start uint64, length uint64) OffsetDisk {
+	if start > d.Size() || length > d.Size()-start {
+		panic(fmt.Errorf("range [%d, +%d) exceeds disk of %d blocks", start, length, d.Size()))
+	}
+	return OffsetDisk{d: d, start: start, length: length}
+}
+
+// BlockSize returns the block size of the wrapped disk.
+func (d OffsetDisk) BlockSize() uint64 {
+	return BlockSizeOf(d.d)
+}
+
+func (d OffsetDisk) translate(a uint64) uint64 {
+	if a >= d.length {
+		panic(fmt.Errorf("out-of-bounds access at %v", a))
+	}
+	return d.start + a
+}
+
+func (d OffsetDisk) ReadTo(a uint64, buf Block) {
+	d.d.ReadTo(d.translate(a), buf)
+}
+
+func (d OffsetDisk) Read(a uint64) Block {
+	return d.d.Read(d.translate(a))
+}
+
+func (d OffsetDisk) Write(a uint64, v Block) {
+	d.d.Write(d.translate(a), v)
+}
+
+func (d OffsetDisk) Trim(a uint64) {
+	d.d.Trim(d.translate(a))
+}
+
+func (d OffsetDisk) Prefetch(a uint64, count uint64) {
+	a, n := clampRange(a, count, d.length)
+	if n > 0 {
+		d.d.Prefetch(d.start+a, n)
+	}
+}
+
+func (d OffsetDisk) Size() uint64 {
+	return d.length
+}
+
+// Resize panics: the range of an OffsetDisk is fixed.
+func (d OffsetDisk) Resize(newSize uint64) {
+	panic("OffsetDisk does not support Resize")
+}
+
+func (d OffsetDisk) Barrier() {
+	d.d.Barrier()
+}
+
+// Close does nothing, since the underlying disk may be shared.
+func (d OffsetDisk) Close() {}
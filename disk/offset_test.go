@@ -0,0 +1,29 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOffsetDisk(t *testing.T) {
+	assert := assert.New(t)
+	base := NewMemDisk(10)
+	log := NewOffsetDisk(base, 0, 4)
+	data := NewOffsetDisk(base, 4, 6)
+	assert.Equal(uint64(4), log.Size())
+	assert.Equal(uint64(6), data.Size())
+
+	log.Write(3, mkBlock(1))
+	data.Write(0, mkBlock(2))
+	assert.Equal(mkBlock(1), base.Read(3))
+	assert.Equal(mkBlock(2), base.Read(4))
+	assert.Equal(mkBlock(2), data.Read(0))
+	assert.Panics(func() { log.Write(4, mkBlock(3)) }, "writes cannot leave the range")
+	assert.Equal(mkBlock(2), base.Read(4))
+}
+
+func TestOffsetDiskTooLarge(t *testing.T) {
+	assert.Panics(t, func() { NewOffsetDisk(NewMemDisk(10), 5, 6) })
+	assert.NotPanics(t, func() { NewOffsetDisk(NewMemDisk(10), 10, 0) })
+}
@@ -0,0 +1,112 @@
+package disk
+
+import (
+	"fmt"
+	"sync"
+)
+
+// QuotaDisk wraps a Disk and limits how many distinct blocks may be written
+// through it.
+//
+// The set of addresses written so far is tracked in memory, starting empty
+// when the QuotaDisk is created; rewriting an address already in the set is
+// always allowed. Once the set holds maxBlocks addresses, a write to any new
+// address is rejected: Write panics (modeled as the machine getting stuck),
+// while WriteWithinQuota returns false without writing.
+type QuotaDisk struct {
+	d         Disk
+	maxBlocks uint64
+	m         *sync.Mutex
+	written   map[uint64]bool
+}
+
+var _ Disk = QuotaDisk{}
+
+// NewQuotaDisk wraps d with a quota of maxBlocks distinct written blocks.
+func NewQuotaDisk(d Disk, maxBlocks uint64) QuotaDisk {
+	return QuotaDisk{
+		d:         d,
+		maxBlocks: maxBlocks,
+		m:         new(sync.Mutex),
+		written:   make(map[uint64]bool),
+	}
+}
+
+// BlockSize returns the block size of the wrapped disk.
+func (d QuotaDisk) BlockSize() uint64 {
+	return BlockSizeOf(d.d)
+}
+
+// WriteWithinQuota writes v to a if doing so stays within the quota, and
+// reports whether the write happened.
+func (d QuotaDisk) WriteWithinQuota(a uint64, v Block) bool {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if !d.written[a] {
+		if uint64(len(d.written)) >= d.maxBlocks {
+			return false
+		}
+		if a >= d.d.Size() {
+			panic(fmt.Errorf("out-of-bounds write at %v", a))
+		}
+		d.written[a] = true
+	}
+	d.d.Write(a, v)
+	return true
+}
+
+// Used returns the number of distinct blocks written so far.
+func (d QuotaDisk) Used() uint64 {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return uint64(len(d.written))
+}
+
+func (d QuotaDisk) ReadTo(a uint64, buf Block) {
+	d.d.ReadTo(a, buf)
+}
+
+func (d QuotaDisk) Read(a uint64) Block {
+	return d.d.Read(a)
+}
+
+func (d QuotaDisk) Write(a uint64, v Block) {
+	if !d.WriteWithinQuota(a, v) {
+		panic(fmt.Errorf("write to %v exceeds quota of %d blocks", a, d.maxBlocks))
+	}
+}
+
+func (d QuotaDisk) Size() uint64 {
+	return d.d.Size()
+}
+
+// Trim trims block a. Trimming does not count against the quota, nor does it
+// return a block's slot in the quota.
+func (d QuotaDisk) Trim(a uint64) {
+	d.d.Trim(a)
+}
+
+func (d QuotaDisk) Prefetch(a uint64, count uint64) {
+	d.d.Prefetch(a, count)
+}
+
+// Resize resizes the underlying disk. Shrinking frees the quota used by the
+// discarded blocks.
+func (d QuotaDisk) Resize(newSize uint64) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.d.Resize(newSize)
+	for a := range d.written {
+		if a >= newSize {
+			delete(d.written, a)
+		}
+	}
+}
+
+func (d QuotaDisk) Barrier() {
+	d.d.Barrier()
+}
+
+func (d QuotaDisk) Close() {
+	d.d.Close()
+}
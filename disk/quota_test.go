@@ -0,0 +1,47 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotaDisk(t *testing.T) {
+	assert := assert.New(t)
+	d := NewQuotaDisk(NewMemDisk(10), 3)
+	d.Write(1, mkBlock(1))
+	d.Write(2, mkBlock(2))
+	assert.True(d.WriteWithinQuota(3, mkBlock(3)))
+	assert.Equal(uint64(3), d.Used())
+
+	// rewrites stay within the quota
+	d.Write(1, mkBlock(4))
+	assert.True(d.WriteWithinQuota(2, mkBlock(5)))
+	assert.Equal(mkBlock(4), d.Read(1))
+	assert.Equal(mkBlock(5), d.Read(2))
+
+	assert.False(d.WriteWithinQuota(4, mkBlock(6)))
+	assert.Panics(func() { d.Write(4, mkBlock(6)) })
+	assert.Equal(mkBlock(0), d.Read(4), "rejected writes have no effect")
+	assert.Equal(uint64(3), d.Used())
+}
+
+func TestQuotaDiskOob(t *testing.T) {
+	d := NewQuotaDisk(NewMemDisk(10), 3)
+	assert.Panics(t, func() { d.Write(10, mkBlock(1)) })
+	assert.Equal(t, uint64(0), d.Used(), "out-of-bounds writes use no quota")
+}
+
+func TestQuotaDiskResize(t *testing.T) {
+	assert := assert.New(t)
+	d := NewQuotaDisk(NewMemDisk(10), 3)
+	d.Write(1, mkBlock(1))
+	d.Write(5, mkBlock(2))
+	d.Write(8, mkBlock(3))
+	d.Resize(5)
+	assert.Equal(uint64(1), d.Used(), "shrinking frees the discarded blocks")
+	d.Resize(10)
+	assert.True(d.WriteWithinQuota(8, mkBlock(4)))
+	assert.True(d.WriteWithinQuota(9, mkBlock(5)))
+	assert.False(d.WriteWithinQuota(7, mkBlock(6)))
+}
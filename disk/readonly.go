@@ -0,0 +1,65 @@
+package disk
+
+import "fmt"
+
+// ReadOnlyDisk wraps a Disk to forbid modifying it: reads and Size pass
+// through, while Write, Trim, Resize and Barrier panic. Code given only a
+// ReadOnlyDisk (a backup or scrub tool, say) therefore cannot change the
+// device, and in the model such calls make the machine stuck.
+type ReadOnlyDisk struct {
+	d Disk
+}
+
+var _ Disk = ReadOnlyDisk{}
+
+// NewReadOnlyDisk wraps d. d itself remains writable by whoever else holds
+// it.
+func NewReadOnlyDisk(d Disk) ReadOnlyDisk {
+	return ReadOnlyDisk{d: d}
+}
+
+// NewSnapshotDisk returns a read-only, point-in-time copy of d: later writes
+// to d are not visible through it.
+func NewSnapshotDisk(d MemDisk) ReadOnlyDisk {
+	return NewReadOnlyDisk(d.Clone())
+}
+
+// BlockSize returns the block size of the wrapped disk.
+func (d ReadOnlyDisk) BlockSize() uint64 {
+	return BlockSizeOf(d.d)
+}
+
+func (d ReadOnlyDisk) ReadTo(a uint64, buf Block) {
+	d.d.ReadTo(a, buf)
+}
+
+func (d ReadOnlyDisk) Read(a uint64) Block {
+	return d.d.Read(a)
+}
+
+func (d ReadOnlyDisk) Write(a uint64, v Block) {
+	panic(fmt.Errorf("write to read-only disk at %v", a))
+}
+
+func (d ReadOnlyDisk) Trim(a uint64) {
+	panic(fmt.Errorf("trim of read-only disk at %v", a))
+}
+
+func (d ReadOnlyDisk) Prefetch(a uint64, count uint64) {
+	d.d.Prefetch(a, count)
+}
+
+func (d ReadOnlyDisk) Size() uint64 {
+	return d.d.Size()
+}
+
+func (d ReadOnlyDisk) Resize(newSize uint64) {
+	panic("resize of read-only disk")
+}
+
+func (d ReadOnlyDisk) Barrier() {
+	panic("barrier on read-only disk")
+}
+
+// Close does nothing, since the underlying disk may be shared.
+func (d ReadOnlyDisk) Close() {}
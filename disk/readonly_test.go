@@ -0,0 +1,37 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOnlyDisk(t *testing.T) {
+	assert := assert.New(t)
+	base := NewMemDisk(10)
+	base.Write(1, mkBlock(1))
+	d := NewReadOnlyDisk(base)
+	assert.Equal(mkBlock(1), d.Read(1))
+	assert.Equal(uint64(10), d.Size())
+	assert.Panics(func() { d.Write(1, mkBlock(2)) })
+	assert.Panics(func() { d.Trim(1) })
+	assert.Panics(func() { d.Resize(5) })
+	assert.Panics(d.Barrier)
+	assert.Equal(mkBlock(1), base.Read(1))
+
+	base.Write(1, mkBlock(3))
+	assert.Equal(mkBlock(3), d.Read(1), "a read-only view sees the base's writes")
+
+	d.Close()
+	base.Write(2, mkBlock(4))
+	assert.Equal(mkBlock(4), base.Read(2), "closing the view leaves the base usable")
+}
+
+func TestSnapshotDisk(t *testing.T) {
+	base := NewMemDisk(10)
+	base.Write(1, mkBlock(1))
+	snap := NewSnapshotDisk(base)
+	base.Write(1, mkBlock(2))
+	assert.Equal(t, mkBlock(1), snap.Read(1), "snapshot is frozen")
+	assert.Panics(t, func() { snap.Write(1, mkBlock(3)) })
+}
@@ -0,0 +1,38 @@
+package disk
+
+// EachRecord treats the disk as a sequence of back-to-back records of
+// recordSize bytes each, and calls f on each one in order, stopping when f
+// returns false.
+//
+// Record i occupies bytes [i*recordSize, (i+1)*recordSize) of the disk and
+// may span block boundaries. If the disk's size is not a multiple of
+// recordSize, the trailing partial record is skipped: f is only ever called
+// with full records. The record slice is reused across calls, so f must copy
+// it to retain it.
+//
+// Requires recordSize > 0.
+func EachRecord(d Disk, recordSize uint64, f func(index uint64, record []byte) bool) {
+	if recordSize == 0 {
+		panic("record size must be positive")
+	}
+	bs := BlockSizeOf(d)
+	numRecords := d.Size() * bs / recordSize
+	record := make([]byte, recordSize)
+	block := make(Block, bs)
+	// address of the block currently in block, or d.Size() if none
+	cur := d.Size()
+	for i := uint64(0); i < numRecords; i++ {
+		off := i * recordSize
+		for n := uint64(0); n < recordSize; {
+			a := (off + n) / bs
+			if a != cur {
+				d.ReadTo(a, block)
+				cur = a
+			}
+			n += uint64(copy(record[n:], block[(off+n)%bs:]))
+		}
+		if !f(i, record) {
+			return
+		}
+	}
+}
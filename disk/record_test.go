@@ -0,0 +1,64 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fillPattern writes a disk where byte i has value i%251
+func fillPattern(d Disk) {
+	for a := uint64(0); a < d.Size(); a++ {
+		b := make(Block, BlockSize)
+		for i := range b {
+			b[i] = byte((a*BlockSize + uint64(i)) % 251)
+		}
+		d.Write(a, b)
+	}
+}
+
+func checkRecords(t *testing.T, d Disk, recordSize uint64) uint64 {
+	var count uint64
+	EachRecord(d, recordSize, func(index uint64, record []byte) bool {
+		assert.Equal(t, index, count)
+		assert.Len(t, record, int(recordSize))
+		for i, x := range record {
+			expected := byte((index*recordSize + uint64(i)) % 251)
+			if !assert.Equal(t, expected, x, "record %d byte %d", index, i) {
+				return false
+			}
+		}
+		count++
+		return true
+	})
+	return count
+}
+
+func TestEachRecordDivides(t *testing.T) {
+	d := NewMemDisk(3)
+	fillPattern(d)
+	assert.Equal(t, uint64(3*4096/512), checkRecords(t, d, 512))
+}
+
+func TestEachRecordSpansBlocks(t *testing.T) {
+	d := NewMemDisk(3)
+	fillPattern(d)
+	// 3*4096 = 12288 = 12*1000 + 288; the partial record is skipped
+	assert.Equal(t, uint64(12), checkRecords(t, d, 1000))
+}
+
+func TestEachRecordLargerThanBlock(t *testing.T) {
+	d := NewMemDisk(3)
+	fillPattern(d)
+	assert.Equal(t, uint64(2), checkRecords(t, d, 5000))
+}
+
+func TestEachRecordStops(t *testing.T) {
+	d := NewMemDisk(2)
+	var indices []uint64
+	EachRecord(d, 100, func(index uint64, record []byte) bool {
+		indices = append(indices, index)
+		return index < 2
+	})
+	assert.Equal(t, []uint64{0, 1, 2}, indices)
+}
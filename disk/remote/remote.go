@@ -0,0 +1,243 @@
+// Package remote exports a disk.Disk over a network connection, as a simple
+// network block device.
+//
+// Serve runs a server for any Disk, and Dial connects a Client, which itself
+// implements disk.Disk. Each request is a one-byte operation code followed by
+// its little-endian uint64 argument (an address, or a size for Resize) and,
+// for a write, the block. Each response starts with a status byte; an OK
+// status is followed by the operation's result (a block for a read, a uint64
+// for Size, nothing otherwise), and an error status by a length-prefixed
+// message. Requests on one connection are handled in order.
+package remote
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/goose-lang/primitive/disk"
+)
+
+const (
+	opRead byte = iota + 1
+	opWrite
+	opBarrier
+	opSize
+	opTrim
+	opResize
+)
+
+const (
+	statusOK byte = iota
+	statusErr
+)
+
+// maxErrorMessage bounds the length of an error message in a response. The
+// server truncates longer messages, and a client treats a longer length as a
+// protocol error rather than allocating it.
+const maxErrorMessage = 64 << 10
+
+// Serve accepts connections on l and serves requests on each against d, until
+// l is closed. It returns the error that stopped it accepting.
+//
+// An operation that panics on d (for example an out-of-bounds read) is
+// reported to the client as an error rather than crashing the server.
+func Serve(l net.Listener, d disk.Disk) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go serveConn(conn, d)
+	}
+}
+
+func serveConn(conn net.Conn, d disk.Disk) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	block := make([]byte, disk.BlockSize)
+	var hdr [9]byte
+	for {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return
+		}
+		op, arg := hdr[0], binary.LittleEndian.Uint64(hdr[1:])
+		if op == opWrite {
+			if _, err := io.ReadFull(r, block); err != nil {
+				return
+			}
+		}
+		result, err := handle(d, op, arg, block)
+		if err != nil {
+			msg := err.Error()
+			if len(msg) > maxErrorMessage {
+				msg = msg[:maxErrorMessage]
+			}
+			w.WriteByte(statusErr)
+			binary.Write(w, binary.LittleEndian, uint64(len(msg)))
+			w.WriteString(msg)
+		} else {
+			w.WriteByte(statusOK)
+			w.Write(result)
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// handle runs one request, converting a panic into an error.
+func handle(d disk.Disk, op byte, arg uint64, block []byte) (result []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	switch op {
+	case opRead:
+		d.ReadTo(arg, block)
+		return block, nil
+	case opWrite:
+		d.Write(arg, block)
+	case opBarrier:
+		d.Barrier()
+	case opSize:
+		return binary.LittleEndian.AppendUint64(nil, d.Size()), nil
+	case opTrim:
+		d.Trim(arg)
+	case opResize:
+		d.Resize(arg)
+	default:
+		return nil, fmt.Errorf("unknown operation %d", op)
+	}
+	return nil, nil
+}
+
+// Client is a disk.Disk whose operations are carried out by a remote server.
+//
+// Operations are synchronous and serialized over one connection. The Disk
+// interface has no errors, so a network failure panics, as does an operation
+// that failed on the server (with the server's message).
+type Client struct {
+	m    *sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+var _ disk.Disk = Client{}
+
+// Dial connects to a server at addr (a TCP host:port).
+func Dial(addr string) (Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return Client{}, err
+	}
+	return NewClient(conn), nil
+}
+
+// NewClient uses an existing connection to a server.
+func NewClient(conn net.Conn) Client {
+	return Client{
+		m:    new(sync.Mutex),
+		conn: conn,
+		r:    bufio.NewReader(conn),
+		w:    bufio.NewWriter(conn),
+	}
+}
+
+// call sends one request and reads the response's result into result.
+func (c Client) call(op byte, arg uint64, data []byte, result []byte) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	err := c.roundTrip(op, arg, data, result)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (c Client) roundTrip(op byte, arg uint64, data []byte, result []byte) error {
+	var hdr [9]byte
+	hdr[0] = op
+	binary.LittleEndian.PutUint64(hdr[1:], arg)
+	c.w.Write(hdr[:])
+	c.w.Write(data)
+	if err := c.w.Flush(); err != nil {
+		return err
+	}
+	status, err := c.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if status != statusOK {
+		var n uint64
+		if err := binary.Read(c.r, binary.LittleEndian, &n); err != nil {
+			return err
+		}
+		if n > maxErrorMessage {
+			return fmt.Errorf("remote disk: error message of %d bytes exceeds %d", n, maxErrorMessage)
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(c.r, msg); err != nil {
+			return err
+		}
+		return errors.New("remote disk: " + string(msg))
+	}
+	_, err = io.ReadFull(c.r, result)
+	return err
+}
+
+func (c Client) ReadTo(a uint64, buf disk.Block) {
+	if uint64(len(buf)) != disk.BlockSize {
+		panic("buffer is not block-sized")
+	}
+	c.call(opRead, a, nil, buf)
+}
+
+func (c Client) Read(a uint64) disk.Block {
+	buf := make(disk.Block, disk.BlockSize)
+	c.ReadTo(a, buf)
+	return buf
+}
+
+func (c Client) Write(a uint64, v disk.Block) {
+	if uint64(len(v)) != disk.BlockSize {
+		panic(fmt.Errorf("v is not block-sized (%d bytes)", len(v)))
+	}
+	c.call(opWrite, a, v, nil)
+}
+
+func (c Client) Trim(a uint64) {
+	c.call(opTrim, a, nil, nil)
+}
+
+// Prefetch does nothing: the protocol has no prefetch request, and a round
+// trip to deliver a hint would cost about as much as the reads it saves.
+func (c Client) Prefetch(a uint64, count uint64) {}
+
+func (c Client) Size() uint64 {
+	var b [8]byte
+	c.call(opSize, 0, nil, b[:])
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+func (c Client) Resize(newSize uint64) {
+	c.call(opResize, newSize, nil, nil)
+}
+
+// Barrier returns once the server's Barrier has returned.
+func (c Client) Barrier() {
+	c.call(opBarrier, 0, nil, nil)
+}
+
+// Close closes the connection. The server's disk stays open.
+func (c Client) Close() {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.conn.Close()
+}
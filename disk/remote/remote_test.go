@@ -0,0 +1,90 @@
+package remote
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/goose-lang/primitive/disk"
+)
+
+func mkBlock(x byte) disk.Block {
+	b := make(disk.Block, disk.BlockSize)
+	b[0] = x
+	return b
+}
+
+func startServer(t *testing.T, d disk.Disk) Client {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	go Serve(l, d)
+	c, err := Dial(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		c.Close()
+		l.Close()
+	})
+	return c
+}
+
+func TestRemoteDisk(t *testing.T) {
+	assert := assert.New(t)
+	d := disk.NewMemDisk(10)
+	c := startServer(t, d)
+	assert.Equal(uint64(10), c.Size())
+	c.Write(3, mkBlock(1))
+	assert.Equal(mkBlock(1), d.Read(3))
+	assert.Equal(mkBlock(1), c.Read(3))
+	c.Barrier()
+	c.Trim(3)
+	assert.Equal(mkBlock(0), c.Read(3))
+	c.Resize(20)
+	assert.Equal(uint64(20), d.Size())
+}
+
+func TestRemoteDiskServerError(t *testing.T) {
+	c := startServer(t, disk.NewMemDisk(10))
+	assert.PanicsWithError(t, "remote disk: out-of-bounds read at 10", func() { c.Read(10) })
+	// the connection is still usable
+	c.Write(1, mkBlock(1))
+	assert.Equal(t, mkBlock(1), c.Read(1))
+}
+
+func TestRemoteDiskConcurrent(t *testing.T) {
+	c := startServer(t, disk.NewMemDisk(10))
+	var wg sync.WaitGroup
+	for i := uint64(0); i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Write(i, mkBlock(byte(i)))
+			assert.Equal(t, mkBlock(byte(i)), c.Read(i))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRemoteDiskOversizedError(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	go func() {
+		var hdr [9]byte
+		if _, err := io.ReadFull(server, hdr[:]); err != nil {
+			return
+		}
+		resp := []byte{statusErr}
+		resp = binary.LittleEndian.AppendUint64(resp, 1<<62)
+		server.Write(resp)
+	}()
+	c := NewClient(client)
+	defer c.Close()
+	assert.PanicsWithError(t, "remote disk: error message of 4611686018427387904 bytes exceeds 65536", func() { c.Barrier() })
+}
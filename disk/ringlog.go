@@ -0,0 +1,120 @@
+package disk
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// MaxRingRecord is the largest record a RingLog can hold.
+const MaxRingRecord uint64 = BlockSize - 8
+
+// RingLog is a bounded log of records stored in a fixed region of a disk,
+// wrapping around when it reaches the end of the region.
+//
+// The first block of the region is a header holding two persistent counters,
+// head (the sequence number of the oldest record) and tail (one past the
+// newest), each a little-endian uint64. The remaining count-1 blocks are
+// slots: record number s lives in slot s % (count-1), prefixed by its length.
+// The counters only increase, so the slot positions wrap around the region
+// while head and tail themselves never do.
+//
+// Appending writes the record's slot and then the header, with a barrier
+// after each, so the header write is the commit point and a crash leaves
+// either the old or the new log. A zeroed region is a valid empty log.
+//
+// A RingLog is not safe for concurrent use.
+type RingLog struct {
+	d     Disk
+	start uint64
+	slots uint64
+	head  uint64
+	tail  uint64
+}
+
+// NewRingLog opens the ring log in blocks [start, start+count) of d,
+// recovering its contents from the header.
+//
+// Requires count >= 2 and start+count <= d.Size(), and d to use BlockSize
+// blocks.
+func NewRingLog(d Disk, start uint64, count uint64) *RingLog {
+	requireBlockSize("RingLog", d)
+	if count < 2 || start+count > d.Size() || start+count < start {
+		panic(fmt.Errorf("invalid ring log region [%d, +%d)", start, count))
+	}
+	hdr := d.Read(start)
+	l := &RingLog{
+		d:     d,
+		start: start,
+		slots: count - 1,
+		head:  binary.LittleEndian.Uint64(hdr[0:8]),
+		tail:  binary.LittleEndian.Uint64(hdr[8:16]),
+	}
+	if l.tail < l.head || l.tail-l.head > l.slots {
+		panic(fmt.Errorf("corrupt ring log header (head=%d, tail=%d)", l.head, l.tail))
+	}
+	return l
+}
+
+func (l *RingLog) writeHeader() {
+	hdr := make(Block, BlockSize)
+	binary.LittleEndian.PutUint64(hdr[0:8], l.head)
+	binary.LittleEndian.PutUint64(hdr[8:16], l.tail)
+	l.d.Write(l.start, hdr)
+	l.d.Barrier()
+}
+
+func (l *RingLog) slotAddr(seq uint64) uint64 {
+	return l.start + 1 + seq%l.slots
+}
+
+// Len returns the number of records in the log.
+func (l *RingLog) Len() uint64 {
+	return l.tail - l.head
+}
+
+// Append durably adds record to the end of the log, returning false (without
+// writing anything) if the log is full.
+//
+// Requires len(record) <= MaxRingRecord.
+func (l *RingLog) Append(record []byte) bool {
+	if uint64(len(record)) > MaxRingRecord {
+		panic(fmt.Errorf("ring log record too large (%d bytes)", len(record)))
+	}
+	if l.Len() == l.slots {
+		return false
+	}
+	b := make(Block, BlockSize)
+	binary.LittleEndian.PutUint64(b[0:8], uint64(len(record)))
+	copy(b[8:], record)
+	l.d.Write(l.slotAddr(l.tail), b)
+	l.d.Barrier()
+	l.tail++
+	l.writeHeader()
+	return true
+}
+
+// Pop durably discards the oldest record, freeing its slot, and returns false
+// if the log is empty.
+func (l *RingLog) Pop() bool {
+	if l.Len() == 0 {
+		return false
+	}
+	l.head++
+	l.writeHeader()
+	return true
+}
+
+// Iterate calls f on each record, oldest first.
+func (l *RingLog) Iterate(f func(record []byte)) {
+	buf := make(Block, BlockSize)
+	for seq := l.head; seq < l.tail; seq++ {
+		l.d.ReadTo(l.slotAddr(seq), buf)
+		n := binary.LittleEndian.Uint64(buf[0:8])
+		if n > MaxRingRecord {
+			panic(fmt.Errorf("corrupt ring log record %d", seq))
+		}
+		record := make([]byte, n)
+		copy(record, buf[8:8+n])
+		f(record)
+	}
+}
@@ -0,0 +1,66 @@
+package disk
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ringRecords(l *RingLog) []string {
+	var records []string
+	l.Iterate(func(record []byte) {
+		records = append(records, string(record))
+	})
+	return records
+}
+
+func TestRingLogFill(t *testing.T) {
+	assert := assert.New(t)
+	d := NewMemDisk(10)
+	l := NewRingLog(d, 2, 4)
+	assert.Empty(ringRecords(l))
+	assert.True(l.Append([]byte("a")))
+	assert.True(l.Append([]byte("b")))
+	assert.True(l.Append([]byte("c")))
+	assert.False(l.Append([]byte("d")), "log should be full")
+	assert.Equal([]string{"a", "b", "c"}, ringRecords(l))
+	assert.Equal(mkBlock(0), d.Read(6), "log stays in its region")
+}
+
+func TestRingLogWrap(t *testing.T) {
+	assert := assert.New(t)
+	d := NewMemDisk(10)
+	l := NewRingLog(d, 2, 4)
+	for i := 0; i < 10; i++ {
+		if l.Len() == 3 {
+			assert.True(l.Pop())
+		}
+		assert.True(l.Append([]byte(fmt.Sprintf("r%d", i))))
+	}
+	assert.Equal([]string{"r7", "r8", "r9"}, ringRecords(l))
+
+	for l.Pop() {
+	}
+	assert.Equal(uint64(0), l.Len())
+	assert.Empty(ringRecords(l))
+}
+
+func TestRingLogRecovery(t *testing.T) {
+	d := NewMemDisk(10)
+	l := NewRingLog(d, 0, 5)
+	for i := 0; i < 6; i++ {
+		if l.Len() == 4 {
+			l.Pop()
+		}
+		l.Append([]byte(fmt.Sprintf("r%d", i)))
+	}
+	l = NewRingLog(d, 0, 5)
+	assert.Equal(t, []string{"r2", "r3", "r4", "r5"}, ringRecords(l))
+}
+
+func TestRingLogInvalidRegion(t *testing.T) {
+	d := NewMemDisk(10)
+	assert.Panics(t, func() { NewRingLog(d, 0, 1) })
+	assert.Panics(t, func() { NewRingLog(d, 8, 3) })
+}
@@ -0,0 +1,31 @@
+package disk
+
+import "sort"
+
+// ReadScatter reads the blocks at addrs, which may be unsorted, non-contiguous
+// and contain duplicates.
+//
+// The result is in the same order as addrs: the ith block is the contents of
+// addrs[i]. Internally the reads are issued in address order for locality.
+// Each returned block is independent, even for duplicate addresses.
+//
+// Expects every address to be < d.Size().
+func ReadScatter(d Disk, addrs []uint64) [][]byte {
+	order := make([]int, len(addrs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return addrs[order[i]] < addrs[order[j]]
+	})
+	blocks := make([][]byte, len(addrs))
+	for k, i := range order {
+		if k > 0 && addrs[order[k-1]] == addrs[i] {
+			b := append(Block(nil), blocks[order[k-1]]...)
+			blocks[i] = b
+			continue
+		}
+		blocks[i] = d.Read(addrs[i])
+	}
+	return blocks
+}
@@ -0,0 +1,46 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadScatterOrder(t *testing.T) {
+	assert := assert.New(t)
+	d := NewMemDisk(10)
+	for a := uint64(0); a < 10; a++ {
+		d.Write(a, mkBlock(byte(a)))
+	}
+	addrs := []uint64{7, 2, 9, 0, 4}
+	blocks := ReadScatter(d, addrs)
+	assert.Len(blocks, len(addrs))
+	for i, a := range addrs {
+		assert.Equal(mkBlock(byte(a)), blocks[i], "block %d", i)
+	}
+}
+
+func TestReadScatterDuplicates(t *testing.T) {
+	assert := assert.New(t)
+	d := NewMemDisk(10)
+	d.Write(3, mkBlock(3))
+	d.Write(5, mkBlock(5))
+	blocks := ReadScatter(d, []uint64{5, 3, 5, 3})
+	assert.Equal(mkBlock(5), blocks[0])
+	assert.Equal(mkBlock(3), blocks[1])
+	assert.Equal(mkBlock(5), blocks[2])
+	assert.Equal(mkBlock(3), blocks[3])
+
+	blocks[0][0] = 42
+	assert.Equal(byte(5), blocks[2][0], "duplicate blocks should be independent")
+}
+
+func TestReadScatterEmpty(t *testing.T) {
+	d := NewMemDisk(10)
+	assert.Empty(t, ReadScatter(d, nil))
+}
+
+func TestReadScatterOob(t *testing.T) {
+	d := NewMemDisk(10)
+	assert.Panics(t, func() { ReadScatter(d, []uint64{1, 10}) })
+}
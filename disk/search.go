@@ -0,0 +1,31 @@
+package disk
+
+import "bytes"
+
+// BinarySearchBlocks searches blocks [lo, hi) for one whose key, as extracted
+// by keyOf, equals target.
+//
+// Assumes the keys of blocks lo through hi-1 are sorted in increasing
+// (bytes.Compare) order; the result is unspecified otherwise. If found, returns
+// the address of a matching block and true. If not, returns the address where
+// target would be inserted to keep the blocks sorted (the first block whose key
+// is greater than target, or hi) and false. Reads O(log(hi-lo)) blocks.
+//
+// Expects lo <= hi <= d.Size().
+func BinarySearchBlocks(d Disk, lo uint64, hi uint64, target []byte, keyOf func(block []byte) []byte) (uint64, bool) {
+	buf := make(Block, BlockSizeOf(d))
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		d.ReadTo(mid, buf)
+		c := bytes.Compare(keyOf(buf), target)
+		if c == 0 {
+			return mid, true
+		}
+		if c < 0 {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo, false
+}
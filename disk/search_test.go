@@ -0,0 +1,61 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func keyBlock(key string) Block {
+	b := make(Block, BlockSize)
+	b[0] = byte(len(key))
+	copy(b[1:], key)
+	return b
+}
+
+func blockKey(b []byte) []byte {
+	return b[1 : 1+b[0]]
+}
+
+func sortedKeyDisk() MemDisk {
+	d := NewMemDisk(10)
+	// blocks 2-6 hold sorted keys; the rest are ignored by the searches
+	for i, k := range []string{"apple", "banana", "cherry", "grape", "melon"} {
+		d.Write(uint64(2+i), keyBlock(k))
+	}
+	return d
+}
+
+func TestBinarySearchBlocksPresent(t *testing.T) {
+	d := sortedKeyDisk()
+	for i, k := range []string{"apple", "banana", "cherry", "grape", "melon"} {
+		a, found := BinarySearchBlocks(d, 2, 7, []byte(k), blockKey)
+		assert.True(t, found, k)
+		assert.Equal(t, uint64(2+i), a, k)
+	}
+}
+
+func TestBinarySearchBlocksAbsent(t *testing.T) {
+	d := sortedKeyDisk()
+	tests := []struct {
+		key string
+		pos uint64
+	}{
+		{"aardvark", 2},
+		{"blueberry", 4},
+		{"kiwi", 6},
+		{"zucchini", 7},
+	}
+	for _, tt := range tests {
+		a, found := BinarySearchBlocks(d, 2, 7, []byte(tt.key), blockKey)
+		assert.False(t, found, tt.key)
+		assert.Equal(t, tt.pos, a, tt.key)
+	}
+}
+
+func TestBinarySearchBlocksEmptyRange(t *testing.T) {
+	d := sortedKeyDisk()
+	a, found := BinarySearchBlocks(d, 4, 4, []byte("cherry"), blockKey)
+	assert.False(t, found)
+	assert.Equal(t, uint64(4), a)
+}
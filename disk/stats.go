@@ -0,0 +1,128 @@
+package disk
+
+import (
+	"expvar"
+	"sync/atomic"
+
+	"github.com/goose-lang/primitive"
+)
+
+// DiskStats is a snapshot of the counters of a StatsDisk.
+type DiskStats struct {
+	Reads        uint64
+	Writes       uint64
+	Trims        uint64
+	Barriers     uint64
+	BytesRead    uint64
+	BytesWritten uint64
+	// Latency histograms, in the bucket layout of primitive.Histogram.
+	ReadLatency    []uint64
+	WriteLatency   []uint64
+	BarrierLatency []uint64
+}
+
+type diskCounters struct {
+	reads, writes, trims, barriers atomic.Uint64
+	bytesRead, bytesWritten        atomic.Uint64
+	readLat, writeLat, barrierLat  primitive.Histogram
+}
+
+// StatsDisk wraps a Disk and counts the operations issued through it, the
+// bytes transferred, and the latency of reads, writes and barriers.
+//
+// Counting is lock-free and safe for concurrent use. Like the Histogram it
+// uses, the statistics are a Go-side affordance with no semantic effect.
+type StatsDisk struct {
+	d Disk
+	c *diskCounters
+}
+
+var _ Disk = StatsDisk{}
+
+// NewStatsDisk wraps d with all counters at zero.
+func NewStatsDisk(d Disk) StatsDisk {
+	return StatsDisk{d: d, c: new(diskCounters)}
+}
+
+// BlockSize returns the block size of the wrapped disk.
+func (d StatsDisk) BlockSize() uint64 {
+	return BlockSizeOf(d.d)
+}
+
+// Stats returns the current statistics. Operations running concurrently may
+// be only partially reflected.
+func (d StatsDisk) Stats() DiskStats {
+	c := d.c
+	return DiskStats{
+		Reads:          c.reads.Load(),
+		Writes:         c.writes.Load(),
+		Trims:          c.trims.Load(),
+		Barriers:       c.barriers.Load(),
+		BytesRead:      c.bytesRead.Load(),
+		BytesWritten:   c.bytesWritten.Load(),
+		ReadLatency:    c.readLat.Buckets(),
+		WriteLatency:   c.writeLat.Buckets(),
+		BarrierLatency: c.barrierLat.Buckets(),
+	}
+}
+
+// Publish exports the statistics as the expvar variable name, so they appear
+// as JSON at /debug/vars on a server using expvar's handler.
+//
+// Like expvar.Publish, it panics if name is already in use.
+func (d StatsDisk) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() any { return d.Stats() }))
+}
+
+func (d StatsDisk) ReadTo(a uint64, buf Block) {
+	start := primitive.TimeMonotonicNow()
+	d.d.ReadTo(a, buf)
+	d.c.readLat.Record(primitive.TimeMonotonicNow() - start)
+	d.c.reads.Add(1)
+	d.c.bytesRead.Add(uint64(len(buf)))
+}
+
+func (d StatsDisk) Read(a uint64) Block {
+	start := primitive.TimeMonotonicNow()
+	b := d.d.Read(a)
+	d.c.readLat.Record(primitive.TimeMonotonicNow() - start)
+	d.c.reads.Add(1)
+	d.c.bytesRead.Add(uint64(len(b)))
+	return b
+}
+
+func (d StatsDisk) Write(a uint64, v Block) {
+	start := primitive.TimeMonotonicNow()
+	d.d.Write(a, v)
+	d.c.writeLat.Record(primitive.TimeMonotonicNow() - start)
+	d.c.writes.Add(1)
+	d.c.bytesWritten.Add(uint64(len(v)))
+}
+
+func (d StatsDisk) Trim(a uint64) {
+	d.d.Trim(a)
+	d.c.trims.Add(1)
+}
+
+func (d StatsDisk) Prefetch(a uint64, count uint64) {
+	d.d.Prefetch(a, count)
+}
+
+func (d StatsDisk) Size() uint64 {
+	return d.d.Size()
+}
+
+func (d StatsDisk) Resize(newSize uint64) {
+	d.d.Resize(newSize)
+}
+
+func (d StatsDisk) Barrier() {
+	start := primitive.TimeMonotonicNow()
+	d.d.Barrier()
+	d.c.barrierLat.Record(primitive.TimeMonotonicNow() - start)
+	d.c.barriers.Add(1)
+}
+
+func (d StatsDisk) Close() {
+	d.d.Close()
+}
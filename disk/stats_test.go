@@ -0,0 +1,58 @@
+package disk
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sum(xs []uint64) uint64 {
+	var n uint64
+	for _, x := range xs {
+		n += x
+	}
+	return n
+}
+
+func TestStatsDiskCounts(t *testing.T) {
+	assert := assert.New(t)
+	d := NewStatsDisk(NewMemDisk(10))
+	d.Write(1, mkBlock(1))
+	d.Write(2, mkBlock(1))
+	d.Read(1)
+	d.ReadTo(2, make(Block, BlockSize))
+	d.Trim(3)
+	d.Barrier()
+	s := d.Stats()
+	assert.Equal(uint64(2), s.Reads)
+	assert.Equal(uint64(2), s.Writes)
+	assert.Equal(uint64(1), s.Trims)
+	assert.Equal(uint64(1), s.Barriers)
+	assert.Equal(2*BlockSize, s.BytesRead)
+	assert.Equal(2*BlockSize, s.BytesWritten)
+	assert.Equal(uint64(2), sum(s.ReadLatency))
+	assert.Equal(uint64(2), sum(s.WriteLatency))
+	assert.Equal(uint64(1), sum(s.BarrierLatency))
+}
+
+func TestStatsDiskBarrierLatency(t *testing.T) {
+	d := NewStatsDisk(slowBarrierDisk{Disk: NewMemDisk(1), delay: time.Millisecond})
+	d.Barrier()
+	// 1ms is in bucket 20, [2^19, 2^20) ns, or later
+	lat := d.Stats().BarrierLatency
+	assert.Equal(t, uint64(0), sum(lat[:20]))
+	assert.Equal(t, uint64(1), sum(lat[20:]))
+}
+
+func TestStatsDiskPublish(t *testing.T) {
+	d := NewStatsDisk(NewMemDisk(10))
+	d.Publish("test-stats-disk")
+	d.Write(0, mkBlock(1))
+	var s DiskStats
+	err := json.Unmarshal([]byte(expvar.Get("test-stats-disk").String()), &s)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), s.Writes)
+}
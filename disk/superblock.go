@@ -0,0 +1,78 @@
+package disk
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc64"
+)
+
+// A checksummed block stores a payload together with its length and a CRC-64
+// (ECMA) checksum:
+//
+//	[0, 8)   payload length, little-endian
+//	[8, 16)  CRC-64 of bytes [0, 8) followed by the payload
+//	[16, ..) payload
+//
+// An all-zero block does not pass the checksum, so a fresh disk never appears
+// to contain a valid superblock.
+
+// MaxSuperblockPayload is the largest payload that fits in a checksummed block.
+const MaxSuperblockPayload uint64 = BlockSize - 16
+
+var crcTable = crc64.MakeTable(crc64.ECMA)
+
+func superblockChecksum(b Block, n uint64) uint64 {
+	crc := crc64.Update(0, crcTable, b[:8])
+	return crc64.Update(crc, crcTable, b[16:16+n])
+}
+
+func encodeSuperblock(payload []byte) Block {
+	if uint64(len(payload)) > MaxSuperblockPayload {
+		panic(fmt.Errorf("superblock payload too large (%d bytes)", len(payload)))
+	}
+	b := make(Block, BlockSize)
+	n := uint64(len(payload))
+	binary.LittleEndian.PutUint64(b[:8], n)
+	copy(b[16:], payload)
+	binary.LittleEndian.PutUint64(b[8:16], superblockChecksum(b, n))
+	return b
+}
+
+func decodeSuperblock(b Block) ([]byte, bool) {
+	n := binary.LittleEndian.Uint64(b[:8])
+	if n > MaxSuperblockPayload {
+		return nil, false
+	}
+	if binary.LittleEndian.Uint64(b[8:16]) != superblockChecksum(b, n) {
+		return nil, false
+	}
+	payload := make([]byte, n)
+	copy(payload, b[16:16+n])
+	return payload, true
+}
+
+// WriteRedundantSuperblock writes payload, protected by a checksum, to both
+// the primary and backup addresses.
+//
+// The primary is written and made durable before the backup is written, so a
+// crash at any point leaves at least one valid copy (assuming one existed
+// before). Requires len(payload) <= MaxSuperblockPayload.
+func WriteRedundantSuperblock(d Disk, primary uint64, backup uint64, payload []byte) {
+	b := encodeSuperblock(payload)
+	d.Write(primary, b)
+	d.Barrier()
+	d.Write(backup, b)
+	d.Barrier()
+}
+
+// ReadRedundantSuperblock returns the payload stored by
+// WriteRedundantSuperblock.
+//
+// The primary copy takes precedence: the backup is used only if the primary
+// fails its checksum. Returns false if neither copy is valid.
+func ReadRedundantSuperblock(d Disk, primary uint64, backup uint64) ([]byte, bool) {
+	if payload, ok := decodeSuperblock(d.Read(primary)); ok {
+		return payload, true
+	}
+	return decodeSuperblock(d.Read(backup))
+}
@@ -0,0 +1,57 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedundantSuperblock(t *testing.T) {
+	assert := assert.New(t)
+	d := NewMemDisk(10)
+	payload := []byte("superblock v1")
+	WriteRedundantSuperblock(d, 0, 5, payload)
+	data, ok := ReadRedundantSuperblock(d, 0, 5)
+	assert.True(ok)
+	assert.Equal(payload, data)
+}
+
+func TestRedundantSuperblockEmptyDisk(t *testing.T) {
+	d := NewMemDisk(10)
+	_, ok := ReadRedundantSuperblock(d, 0, 5)
+	assert.False(t, ok)
+}
+
+func TestRedundantSuperblockPrimaryCorrupt(t *testing.T) {
+	assert := assert.New(t)
+	d := NewMemDisk(10)
+	payload := []byte("superblock v1")
+	WriteRedundantSuperblock(d, 0, 5, payload)
+
+	b := d.Read(0)
+	b[20] ^= 0xff
+	d.Write(0, b)
+
+	data, ok := ReadRedundantSuperblock(d, 0, 5)
+	assert.True(ok, "should fall back to backup")
+	assert.Equal(payload, data)
+}
+
+func TestRedundantSuperblockBothCorrupt(t *testing.T) {
+	d := NewMemDisk(10)
+	WriteRedundantSuperblock(d, 0, 5, []byte("superblock v1"))
+	for _, a := range []uint64{0, 5} {
+		b := d.Read(a)
+		b[16] ^= 0x01
+		d.Write(a, b)
+	}
+	_, ok := ReadRedundantSuperblock(d, 0, 5)
+	assert.False(t, ok)
+}
+
+func TestRedundantSuperblockTooLarge(t *testing.T) {
+	d := NewMemDisk(10)
+	assert.Panics(t, func() {
+		WriteRedundantSuperblock(d, 0, 5, make([]byte, BlockSize))
+	})
+}
@@ -0,0 +1,52 @@
+package disk
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// A torn-protected block brackets its payload with two copies of a sequence
+// number:
+//
+//	[0, 8)                      sequence number, little-endian
+//	[8, BlockSize-8)            payload, zero-padded
+//	[BlockSize-8, BlockSize)    sequence number, little-endian
+//
+// Each write uses a sequence number one greater than the block's previous
+// one, so a write torn between the two ends leaves mismatched markers. This
+// costs 16 bytes of the block and an extra read per write. It assumes the
+// device tears writes only at sector granularity, so that each marker is
+// either entirely old or entirely new.
+
+// MaxTornProtectedPayload is the payload capacity of a torn-protected block.
+const MaxTornProtectedPayload uint64 = BlockSize - 16
+
+// WriteTornProtected writes payload to block a with matching sequence numbers
+// at both ends.
+//
+// Requires len(payload) <= MaxTornProtectedPayload.
+func WriteTornProtected(d Disk, a uint64, payload []byte) {
+	if uint64(len(payload)) > MaxTornProtectedPayload {
+		panic(fmt.Errorf("payload too large (%d bytes)", len(payload)))
+	}
+	b := d.Read(a)
+	seq := binary.LittleEndian.Uint64(b[:8]) + 1
+	clear(b)
+	binary.LittleEndian.PutUint64(b[:8], seq)
+	copy(b[8:], payload)
+	binary.LittleEndian.PutUint64(b[BlockSize-8:], seq)
+	d.Write(a, b)
+}
+
+// ReadTornProtected reads a block written by WriteTornProtected, returning its
+// MaxTornProtectedPayload-byte (zero-padded) payload.
+//
+// Returns false if the sequence numbers at the two ends disagree, indicating a
+// torn write.
+func ReadTornProtected(d Disk, a uint64) ([]byte, bool) {
+	b := d.Read(a)
+	if binary.LittleEndian.Uint64(b[:8]) != binary.LittleEndian.Uint64(b[BlockSize-8:]) {
+		return nil, false
+	}
+	return b[8 : BlockSize-8], true
+}
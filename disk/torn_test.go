@@ -0,0 +1,47 @@
+package disk
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTornProtectedClean(t *testing.T) {
+	assert := assert.New(t)
+	d := NewMemDisk(10)
+	WriteTornProtected(d, 3, []byte("hello"))
+	payload, ok := ReadTornProtected(d, 3)
+	assert.True(ok)
+	assert.Len(payload, int(MaxTornProtectedPayload))
+	assert.Equal([]byte("hello"), payload[:5])
+	assert.Equal(byte(0), payload[5])
+
+	WriteTornProtected(d, 3, []byte("world"))
+	payload, ok = ReadTornProtected(d, 3)
+	assert.True(ok)
+	assert.Equal([]byte("world"), payload[:5])
+}
+
+func TestTornProtectedDetectsTear(t *testing.T) {
+	d := NewMemDisk(10)
+	WriteTornProtected(d, 3, []byte("old"))
+	old := d.Read(3)
+	WriteTornProtected(d, 3, []byte("new"))
+	torn := d.Read(3)
+	// simulate a write that only reached the first half of the block
+	copy(torn[BlockSize/2:], old[BlockSize/2:])
+	d.Write(3, torn)
+
+	_, ok := ReadTornProtected(d, 3)
+	assert.False(t, ok)
+}
+
+func TestTornProtectedSequence(t *testing.T) {
+	d := NewMemDisk(10)
+	for i := 0; i < 3; i++ {
+		WriteTornProtected(d, 0, nil)
+	}
+	b := d.Read(0)
+	assert.Equal(t, uint64(3), binary.LittleEndian.Uint64(b[:8]))
+}
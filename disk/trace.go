@@ -0,0 +1,177 @@
+package disk
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/goose-lang/primitive"
+)
+
+// A trace is a sequence of records, each laid out as
+//
+//	[0, 1)      operation (a DiskOp)
+//	[1, 9)      address; the new size for OpResize; 0 for OpBarrier
+//	[9, 17)     time the operation completed, from TimeMonotonicNow
+//	[17, 25)    XxHash64 of the block read or written (0 for other operations)
+//
+// with integers little-endian. Times are nondecreasing within a trace, but
+// only meaningful relative to each other. A write record is followed by the
+// BlockSize bytes written, so that it can be replayed; reads only record the
+// hash.
+
+const traceRecordSize = 25
+
+// TraceRecord is one operation in a trace.
+type TraceRecord struct {
+	Op   DiskOp
+	Addr uint64
+	Time uint64
+	Hash uint64
+	// Data is the block written, for OpWrite records only.
+	Data Block
+}
+
+// TraceDisk wraps a Disk and appends a record of every operation to a trace.
+//
+// Records are written in the order operations complete. A failure to write
+// the trace panics.
+type TraceDisk struct {
+	d Disk
+	m *sync.Mutex
+	w io.Writer
+}
+
+var _ Disk = TraceDisk{}
+
+// NewTraceDisk wraps d, writing its trace to w. Wrap w in a bufio.Writer (and
+// flush it when done) to avoid a write per operation.
+func NewTraceDisk(d Disk, w io.Writer) TraceDisk {
+	requireBlockSize("TraceDisk", d)
+	return TraceDisk{d: d, m: new(sync.Mutex), w: w}
+}
+
+func (d TraceDisk) record(op DiskOp, a uint64, data Block) {
+	var hdr [traceRecordSize]byte
+	hdr[0] = byte(op)
+	binary.LittleEndian.PutUint64(hdr[1:], a)
+	if data != nil {
+		binary.LittleEndian.PutUint64(hdr[17:], primitive.XxHash64(data))
+	}
+	d.m.Lock()
+	defer d.m.Unlock()
+	// read the clock under the lock, so times increase in trace order
+	binary.LittleEndian.PutUint64(hdr[9:], primitive.TimeMonotonicNow())
+	_, err := d.w.Write(hdr[:])
+	if err == nil && op == OpWrite {
+		_, err = d.w.Write(data)
+	}
+	if err != nil {
+		panic("trace write failed: " + err.Error())
+	}
+}
+
+func (d TraceDisk) ReadTo(a uint64, buf Block) {
+	d.d.ReadTo(a, buf)
+	d.record(OpRead, a, buf)
+}
+
+func (d TraceDisk) Read(a uint64) Block {
+	b := d.d.Read(a)
+	d.record(OpRead, a, b)
+	return b
+}
+
+func (d TraceDisk) Write(a uint64, v Block) {
+	d.d.Write(a, v)
+	d.record(OpWrite, a, v)
+}
+
+func (d TraceDisk) Trim(a uint64) {
+	d.d.Trim(a)
+	d.record(OpTrim, a, nil)
+}
+
+func (d TraceDisk) Prefetch(a uint64, count uint64) {
+	d.d.Prefetch(a, count)
+}
+
+func (d TraceDisk) Size() uint64 {
+	return d.d.Size()
+}
+
+func (d TraceDisk) Resize(newSize uint64) {
+	d.d.Resize(newSize)
+	d.record(OpResize, newSize, nil)
+}
+
+func (d TraceDisk) Barrier() {
+	d.d.Barrier()
+	d.record(OpBarrier, 0, nil)
+}
+
+func (d TraceDisk) Close() {
+	d.d.Close()
+}
+
+// ReadTraceRecord reads the next record from a trace. It returns io.EOF at
+// the end of the trace, and io.ErrUnexpectedEOF for a truncated record.
+func ReadTraceRecord(r io.Reader) (TraceRecord, error) {
+	var hdr [traceRecordSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return TraceRecord{}, err
+	}
+	rec := TraceRecord{
+		Op:   DiskOp(hdr[0]),
+		Addr: binary.LittleEndian.Uint64(hdr[1:]),
+		Time: binary.LittleEndian.Uint64(hdr[9:]),
+		Hash: binary.LittleEndian.Uint64(hdr[17:]),
+	}
+	if rec.Op == OpWrite {
+		rec.Data = make(Block, BlockSize)
+		if _, err := io.ReadFull(r, rec.Data); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return TraceRecord{}, err
+		}
+	}
+	return rec, nil
+}
+
+// ReplayTrace re-executes the trace read from r against d, in order and
+// without the original timing.
+//
+// Each read is checked against the hash in the trace; the first read that
+// returns different data stops the replay with an error, as does a malformed
+// trace.
+func ReplayTrace(r io.Reader, d Disk) error {
+	buf := make(Block, BlockSize)
+	for i := 0; ; i++ {
+		rec, err := ReadTraceRecord(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("trace record %d: %w", i, err)
+		}
+		switch rec.Op {
+		case OpRead:
+			d.ReadTo(rec.Addr, buf)
+			if primitive.XxHash64(buf) != rec.Hash {
+				return fmt.Errorf("trace record %d: read of %d returned different data", i, rec.Addr)
+			}
+		case OpWrite:
+			d.Write(rec.Addr, rec.Data)
+		case OpTrim:
+			d.Trim(rec.Addr)
+		case OpResize:
+			d.Resize(rec.Addr)
+		case OpBarrier:
+			d.Barrier()
+		default:
+			return fmt.Errorf("trace record %d: unknown operation %d", i, rec.Op)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package disk
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceDiskRecords(t *testing.T) {
+	assert := assert.New(t)
+	var trace bytes.Buffer
+	d := NewTraceDisk(NewMemDisk(10), &trace)
+	d.Write(1, mkBlock(1))
+	d.Read(1)
+	d.Trim(2)
+	d.Barrier()
+	d.Resize(12)
+
+	r := bytes.NewReader(trace.Bytes())
+	var ops []DiskOp
+	var last uint64
+	for {
+		rec, err := ReadTraceRecord(r)
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(err)
+		ops = append(ops, rec.Op)
+		assert.GreaterOrEqual(rec.Time, last)
+		last = rec.Time
+		if rec.Op == OpWrite {
+			assert.Equal(mkBlock(1), rec.Data)
+		}
+	}
+	assert.Equal([]DiskOp{OpWrite, OpRead, OpTrim, OpBarrier, OpResize}, ops)
+	assert.Equal(5*traceRecordSize+int(BlockSize), trace.Len())
+}
+
+func TestReplayTrace(t *testing.T) {
+	assert := assert.New(t)
+	var trace bytes.Buffer
+	d := NewTraceDisk(NewMemDisk(10), &trace)
+	d.Write(1, mkBlock(1))
+	d.Write(2, mkBlock(2))
+	d.Read(2)
+	d.Resize(5)
+
+	replayed := NewMemDisk(10)
+	assert.NoError(ReplayTrace(bytes.NewReader(trace.Bytes()), replayed))
+	assert.Equal(mkBlock(1), replayed.Read(1))
+	assert.Equal(uint64(5), replayed.Size())
+
+	// a disk that returns different data for the read is caught
+	stale := NewFaultDisk(NewMemDisk(10), 1)
+	stale.Inject(Fault{Op: OpWrite, Addr: 2, Kind: FaultDropWrite})
+	err := ReplayTrace(bytes.NewReader(trace.Bytes()), stale)
+	assert.EqualError(err, "trace record 2: read of 2 returned different data")
+
+	err = ReplayTrace(bytes.NewReader(trace.Bytes()[:traceRecordSize+10]), NewMemDisk(10))
+	assert.ErrorIs(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,17 @@
+package disk
+
+// ValidateWrites checks that every write in a batch is in bounds for d and is
+// exactly one block, without writing anything.
+//
+// This is only a pre-check: callers that get true can then issue the writes
+// knowing none will fail the bounds or size checks partway through, and
+// callers that get false can reject the whole batch with the disk untouched.
+func ValidateWrites(d Disk, writes map[uint64][]byte) bool {
+	size, bs := d.Size(), BlockSizeOf(d)
+	for a, b := range writes {
+		if a >= size || uint64(len(b)) != bs {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateWrites(t *testing.T) {
+	d := NewMemDisk(10)
+	assert.True(t, ValidateWrites(d, nil))
+	assert.True(t, ValidateWrites(d, map[uint64][]byte{
+		0: mkBlock(1),
+		9: mkBlock(2),
+	}))
+}
+
+func TestValidateWritesOutOfRange(t *testing.T) {
+	d := NewMemDisk(10)
+	assert.False(t, ValidateWrites(d, map[uint64][]byte{
+		0:  mkBlock(1),
+		10: mkBlock(2),
+	}))
+	assert.Equal(t, mkBlock(0), d.Read(0), "no side effects")
+}
+
+func TestValidateWritesWrongSize(t *testing.T) {
+	d := NewMemDisk(10)
+	assert.False(t, ValidateWrites(d, map[uint64][]byte{
+		0: mkBlock(1),
+		1: make([]byte, 100),
+	}))
+	assert.Equal(t, mkBlock(0), d.Read(0), "no side effects")
+}
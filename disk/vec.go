@@ -0,0 +1,86 @@
+package disk
+
+import (
+	"fmt"
+	"sort"
+)
+
+// VecDisk is implemented by disks that can read or write a set of
+// non-contiguous blocks more cheaply than one operation per block.
+type VecDisk interface {
+	// ReadVec reads the blocks at addrs, with the contract of the
+	// package-level ReadVec function.
+	ReadVec(addrs []uint64) []Block
+	// WriteVec writes blocks[i] to addrs[i] for each i, with the contract of
+	// the package-level WriteVec function.
+	WriteVec(addrs []uint64, blocks []Block)
+}
+
+// ReadVec reads the blocks at addrs, which may be unsorted, non-contiguous
+// and contain duplicates, returning them in the same order as addrs. Each
+// returned block is independent, even for duplicate addresses.
+//
+// The effect is the same as reading each block in turn; if d implements
+// VecDisk the reads are batched. Expects every address to be < d.Size().
+func ReadVec(d Disk, addrs []uint64) []Block {
+	if vd, ok := d.(VecDisk); ok {
+		return vd.ReadVec(addrs)
+	}
+	return ReadScatter(d, addrs)
+}
+
+// WriteVec writes blocks[i] to addrs[i] for each i.
+//
+// The effect is the same as calling d.Write for each block in order (so
+// for a duplicate address the last block wins), and in particular the
+// writes are not atomic; if d implements VecDisk they are batched. Expects
+// len(addrs) == len(blocks), every address to be < d.Size() and every block
+// to be BlockSize bytes.
+func WriteVec(d Disk, addrs []uint64, blocks []Block) {
+	if vd, ok := d.(VecDisk); ok {
+		vd.WriteVec(addrs, blocks)
+		return
+	}
+	checkVec(BlockSizeOf(d), d.Size(), addrs, blocks)
+	for i, a := range addrs {
+		d.Write(a, blocks[i])
+	}
+}
+
+// checkVec validates the arguments of a WriteVec against a disk of size
+// blocks of blockSize bytes.
+func checkVec(blockSize uint64, size uint64, addrs []uint64, blocks []Block) {
+	if len(addrs) != len(blocks) {
+		panic(fmt.Errorf("%d addresses for %d blocks", len(addrs), len(blocks)))
+	}
+	for i, a := range addrs {
+		if a >= size {
+			panic(fmt.Errorf("out-of-bounds write at %v", a))
+		}
+		if uint64(len(blocks[i])) != blockSize {
+			panic(fmt.Errorf("v is not block-sized (%d bytes)", len(blocks[i])))
+		}
+	}
+}
+
+// vecRuns sorts addrs (removing duplicates) and splits them into runs of
+// consecutive addresses, each of at most maxRun addresses.
+func vecRuns(addrs []uint64, maxRun int) [][]uint64 {
+	sorted := append([]uint64(nil), addrs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var runs [][]uint64
+	for i, a := range sorted {
+		if i > 0 && a == sorted[i-1] {
+			continue
+		}
+		if n := len(runs); n > 0 {
+			last := runs[n-1]
+			if last[len(last)-1]+1 == a && len(last) < maxRun {
+				runs[n-1] = append(last, a)
+				continue
+			}
+		}
+		runs = append(runs, []uint64{a})
+	}
+	return runs
+}
@@ -0,0 +1,32 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *DiskSuite) TestVec() {
+	d := suite.D
+	WriteVec(d, []uint64{7, 3, 4, 7}, []Block{block1, block2, block1, block2})
+	suite.Equal(block2, d.Read(3))
+	suite.Equal(block1, d.Read(4))
+	suite.Equal(block2, d.Read(7), "the last write to a duplicate address wins")
+
+	blocks := ReadVec(d, []uint64{7, 3, 5, 4, 3})
+	suite.Equal([]Block{block2, block2, block0, block1, block2}, blocks)
+	blocks[1][0] = 9
+	suite.Equal(block2, blocks[4], "duplicate reads are independent")
+
+	suite.Panics(func() { WriteVec(d, []uint64{1, 100}, []Block{block1, block1}) })
+	suite.Equal(block0, d.Read(1), "out-of-bounds batch should not be written")
+	suite.Panics(func() { ReadVec(d, []uint64{100}) })
+	suite.Panics(func() { WriteVec(d, []uint64{1}, nil) })
+}
+
+func TestVecRuns(t *testing.T) {
+	assert.Equal(t, [][]uint64{{1, 2, 3}, {5}, {7, 8, 9}},
+		vecRuns([]uint64{9, 3, 1, 2, 8, 2, 5, 7}, 3))
+	assert.Equal(t, [][]uint64{{1, 2}, {3}}, vecRuns([]uint64{1, 2, 3}, 2))
+	assert.Empty(t, vecRuns(nil, 2))
+}
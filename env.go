@@ -0,0 +1,47 @@
+package primitive
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// The process environment and arguments, captured when first used so that
+// they stay fixed for the rest of the execution even if Go code changes them
+// with os.Setenv.
+var (
+	envOnce  sync.Once
+	envVars  map[string]string
+	procArgs []string
+)
+
+func loadEnv() {
+	envOnce.Do(func() {
+		envVars = make(map[string]string)
+		for _, kv := range os.Environ() {
+			k, v, _ := strings.Cut(kv, "=")
+			envVars[k] = v
+		}
+		procArgs = append([]string(nil), os.Args...)
+	})
+}
+
+// GetEnv returns the value of the environment variable name, and whether it
+// is set.
+//
+// Modeled as an arbitrary value that is fixed for the whole execution: every
+// call with the same name returns the same result.
+func GetEnv(name string) (string, bool) {
+	loadEnv()
+	v, ok := envVars[name]
+	return v, ok
+}
+
+// Args returns the command-line arguments, starting with the program name.
+//
+// Modeled as an arbitrary slice that is fixed for the whole execution. The
+// caller owns the returned slice.
+func Args() []string {
+	loadEnv()
+	return append([]string(nil), procArgs...)
+}
@@ -0,0 +1,30 @@
+package primitive
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetEnvFixed(t *testing.T) {
+	t.Setenv("PRIMITIVE_TEST_VAR", "1")
+	// the environment may already have been captured by another test, so only
+	// check that the result does not change
+	v, ok := GetEnv("PRIMITIVE_TEST_VAR")
+	os.Setenv("PRIMITIVE_TEST_VAR", "2")
+	v2, ok2 := GetEnv("PRIMITIVE_TEST_VAR")
+	assert.Equal(t, v, v2)
+	assert.Equal(t, ok, ok2)
+
+	path, ok := GetEnv("PATH")
+	assert.True(t, ok)
+	assert.Equal(t, os.Getenv("PATH"), path)
+}
+
+func TestArgs(t *testing.T) {
+	args := Args()
+	assert.Equal(t, os.Args, args)
+	args[0] = "changed"
+	assert.NotEqual(t, "changed", Args()[0], "Args should return a copy")
+}
@@ -0,0 +1,70 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Directory operations. Each fsyncs the directories involved before
+// returning, so the change is durable once the operation returns. A crash
+// during one that changes a single directory entry nondeterministically
+// leaves the change either fully made or not made at all; see Rename for the
+// exception.
+
+// ListDir returns the names of the entries of the directory name, in sorted
+// order and excluding "." and "..".
+//
+// Modeled as returning the directory's entries atomically.
+func ListDir(name string) []string {
+	f, err := os.Open(name)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	names, err := f.Readdirnames(-1)
+	if err != nil {
+		panic(err)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Mkdir creates the directory name. Panics if it already exists or its parent
+// does not.
+func Mkdir(name string) {
+	err := os.Mkdir(name, 0755)
+	if err != nil {
+		panic(err)
+	}
+	syncDir(filepath.Dir(name))
+}
+
+// Remove removes the file or empty directory name. Panics if it does not
+// exist.
+func Remove(name string) {
+	err := os.Remove(name)
+	if err != nil {
+		panic(err)
+	}
+	syncDir(filepath.Dir(name))
+}
+
+// Rename renames oldName to newName, atomically replacing newName if it
+// exists. Both must be on the same file system.
+//
+// A rename within one directory is atomic with respect to crashes. A rename
+// between directories changes two entries, and common file systems do not
+// make that atomic: a crash during it may leave the file under both names or,
+// on some file systems, under neither.
+func Rename(oldName, newName string) {
+	err := os.Rename(oldName, newName)
+	if err != nil {
+		panic(err)
+	}
+	oldDir, newDir := filepath.Dir(oldName), filepath.Dir(newName)
+	syncDir(newDir)
+	if oldDir != newDir {
+		syncDir(oldDir)
+	}
+}
@@ -0,0 +1,31 @@
+package file
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirOps(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	assert.Empty(ListDir(root))
+
+	Mkdir(filepath.Join(root, "segs"))
+	FileWrite(filepath.Join(root, "segs", "b"), []byte("b"))
+	FileWrite(filepath.Join(root, "segs", "a"), []byte("a"))
+	assert.Equal([]string{"a", "b"}, ListDir(filepath.Join(root, "segs")))
+
+	Rename(filepath.Join(root, "segs", "a"), filepath.Join(root, "a"))
+	assert.Equal([]string{"a", "segs"}, ListDir(root))
+	assert.Equal([]byte("a"), FileRead(filepath.Join(root, "a")))
+
+	Rename(filepath.Join(root, "segs", "b"), filepath.Join(root, "a"))
+	assert.Equal([]byte("b"), FileRead(filepath.Join(root, "a")), "rename should replace")
+
+	Remove(filepath.Join(root, "segs"))
+	assert.Equal([]string{"a"}, ListDir(root))
+	assert.Panics(func() { Remove(filepath.Join(root, "segs")) })
+	assert.Panics(func() { Mkdir(filepath.Join(root, "a")) })
+}
@@ -0,0 +1,111 @@
+// Package file provides whole-file operations on the host file system, for
+// small pieces of durable state (configuration, metadata, checkpoints) that
+// do not fit the block-disk abstraction.
+//
+// Unlike filesys, which exposes file descriptors within a single directory,
+// these operations take ordinary paths and read or write a file in one call.
+// Every operation panics if the underlying system call fails; the crash
+// behavior of each is given in its documentation, and an operation that
+// returns has taken full effect durably.
+package file
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// FileRead returns the contents of the file name.
+//
+// Modeled as atomically reading the file's contents. Panics if the file does
+// not exist.
+func FileRead(name string) []byte {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// FileWrite replaces the contents of the file name with data, creating it if
+// it does not exist, and makes the new contents durable before returning.
+// The parent directory is fsynced too, so a newly created file survives a
+// crash.
+//
+// A crash during FileWrite may leave the file with arbitrary contents (in
+// practice, some mix of a truncated file and a prefix of data); use
+// AtomicWriteFile when the old contents must survive a crash.
+func FileWrite(name string, data []byte) {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		panic(err)
+	}
+	writeSync(f, data)
+	syncDir(filepath.Dir(name))
+}
+
+// FileAppend appends data to the file name, creating it if it does not
+// exist, and makes the appended data durable before returning. Like
+// FileWrite, it fsyncs the parent directory in case the file was created.
+//
+// A crash during FileAppend leaves the file extended by an arbitrary prefix
+// of data.
+func FileAppend(name string, data []byte) {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		panic(err)
+	}
+	writeSync(f, data)
+	syncDir(filepath.Dir(name))
+}
+
+// writeSync writes data to f, fsyncs it and closes it.
+func writeSync(f *os.File, data []byte) {
+	_, err := f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		f.Close()
+		panic(err)
+	}
+	err = f.Close()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// AtomicWriteFile replaces the contents of the file name with data
+// atomically, even with respect to crashes: a crash leaves the file with
+// either its old contents (or absent, if it did not exist) or data.
+//
+// The data is written to a temporary file in the same directory, which is
+// fsynced and renamed over name; the directory is then fsynced so the rename
+// is durable. A crash may leave the temporary file behind.
+func AtomicWriteFile(name string, data []byte) {
+	dir := filepath.Dir(name)
+	f, err := os.CreateTemp(dir, filepath.Base(name)+".tmp*")
+	if err != nil {
+		panic(err)
+	}
+	tmp := f.Name()
+	writeSync(f, data)
+	err = os.Rename(tmp, name)
+	if err != nil {
+		os.Remove(tmp)
+		panic(err)
+	}
+	syncDir(dir)
+}
+
+// syncDir fsyncs the directory dir, making changes to its entries durable.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+	err = d.Sync()
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,39 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileWriteRead(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "f")
+	FileWrite(name, []byte("hello"))
+	assert.Equal(t, []byte("hello"), FileRead(name))
+	FileWrite(name, []byte("hi"))
+	assert.Equal(t, []byte("hi"), FileRead(name), "write should truncate")
+}
+
+func TestFileAppend(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "f")
+	FileAppend(name, []byte("a"))
+	FileAppend(name, []byte("bc"))
+	assert.Equal(t, []byte("abc"), FileRead(name))
+}
+
+func TestFileReadMissing(t *testing.T) {
+	assert.Panics(t, func() { FileRead(filepath.Join(t.TempDir(), "missing")) })
+}
+
+func TestAtomicWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "state")
+	AtomicWriteFile(name, []byte("v1"))
+	AtomicWriteFile(name, []byte("v2"))
+	assert.Equal(t, []byte("v2"), FileRead(name))
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files should remain")
+}
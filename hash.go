@@ -0,0 +1,158 @@
+package primitive
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"hash/crc64"
+	"hash/fnv"
+	"math/bits"
+)
+
+// FnvHash64 returns the 64-bit FNV-1a hash of p.
+//
+// Modeled as a pure function.
+func FnvHash64(p []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(p)
+	return h.Sum64()
+}
+
+// mix64 is the splitmix64 finalizer, a bijective scrambling of x.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// ShardFor deterministically assigns key to one of numShards shards, returning
+// a value in [0, numShards).
+//
+// The same key always maps to the same shard. The key is hashed with
+// FnvHash64, scrambled with the splitmix64 finalizer (FNV's high bits are
+// poorly distributed for short, similar keys), and reduced with Lemire's
+// multiply-shift method, rejecting and re-mixing the few hash values that
+// would bias the result so every shard receives an equal share of the hash
+// space.
+//
+// Requires numShards > 0. Modeled as a pure function.
+func ShardFor(key []byte, numShards uint64) uint64 {
+	Assume(numShards > 0)
+	h := FnvHash64(key)
+	return uniformBelow(numShards, func() uint64 {
+		h = mix64(h)
+		return h
+	})
+}
+
+const (
+	xxPrime1 uint64 = 0x9E3779B185EBCA87
+	xxPrime2 uint64 = 0xC2B2AE3D27D4EB4F
+	xxPrime3 uint64 = 0x165667B19E3779F9
+	xxPrime4 uint64 = 0x85EBCA77C2B2AE63
+	xxPrime5 uint64 = 0x27D4EB2F165667C5
+)
+
+func xxRound(acc, input uint64) uint64 {
+	acc += input * xxPrime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxPrime1
+}
+
+func xxMergeRound(acc, val uint64) uint64 {
+	acc ^= xxRound(0, val)
+	return acc*xxPrime1 + xxPrime4
+}
+
+// XxHash64 returns the xxHash64 digest of p, with the seed fixed to 0.
+//
+// This follows the reference XXH64 algorithm, so it matches other xxHash64
+// implementations. It is not cryptographic, but is much faster than FnvHash64
+// on large inputs. Modeled as a pure function.
+func XxHash64(p []byte) uint64 {
+	n := uint64(len(p))
+	var h uint64
+	if len(p) >= 32 {
+		// initial state for seed 0 (computed at runtime since the
+		// constants overflow)
+		var v1, v2, v3, v4 uint64
+		v1 = xxPrime1
+		v1 += xxPrime2
+		v2 = xxPrime2
+		v4 -= xxPrime1
+		for ; len(p) >= 32; p = p[32:] {
+			v1 = xxRound(v1, binary.LittleEndian.Uint64(p[0:8]))
+			v2 = xxRound(v2, binary.LittleEndian.Uint64(p[8:16]))
+			v3 = xxRound(v3, binary.LittleEndian.Uint64(p[16:24]))
+			v4 = xxRound(v4, binary.LittleEndian.Uint64(p[24:32]))
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) +
+			bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxMergeRound(h, v1)
+		h = xxMergeRound(h, v2)
+		h = xxMergeRound(h, v3)
+		h = xxMergeRound(h, v4)
+	} else {
+		h = xxPrime5
+	}
+	h += n
+	for ; len(p) >= 8; p = p[8:] {
+		h ^= xxRound(0, binary.LittleEndian.Uint64(p[:8]))
+		h = bits.RotateLeft64(h, 27)*xxPrime1 + xxPrime4
+	}
+	if len(p) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(p[:4])) * xxPrime1
+		h = bits.RotateLeft64(h, 23)*xxPrime2 + xxPrime3
+		p = p[4:]
+	}
+	for _, b := range p {
+		h ^= uint64(b) * xxPrime5
+		h = bits.RotateLeft64(h, 11) * xxPrime1
+	}
+	h ^= h >> 33
+	h *= xxPrime2
+	h ^= h >> 29
+	h *= xxPrime3
+	h ^= h >> 32
+	return h
+}
+
+// Hash64 returns a 64-bit hash of p, for hash tables, sharding and content
+// addressing. It is XxHash64, so the values are stable across releases and
+// match other xxHash64 implementations.
+//
+// Modeled as a pure function.
+func Hash64(p []byte) uint64 {
+	return XxHash64(p)
+}
+
+// HashString returns Hash64 of the bytes of s, without copying them.
+//
+// Modeled as a pure function.
+func HashString(s string) uint64 {
+	return XxHash64(StringToBytesNoCopy(s))
+}
+
+var (
+	castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+	crc64Table      = crc64.MakeTable(crc64.ECMA)
+)
+
+// CRC32C returns the CRC-32C (Castagnoli) checksum of p, as used by iSCSI,
+// ext4 and many storage formats. It uses the SSE4.2 or ARMv8 CRC
+// instructions where available.
+//
+// Modeled as a pure function.
+func CRC32C(p []byte) uint32 {
+	return crc32.Checksum(p, castagnoliTable)
+}
+
+// CRC64 returns the CRC-64 checksum of p with the ECMA-182 polynomial, in the
+// reflected form used by xz (CRC-64/XZ), as the disk superblock uses.
+//
+// Modeled as a pure function.
+func CRC64(p []byte) uint64 {
+	return crc64.Checksum(p, crc64Table)
+}
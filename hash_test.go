@@ -0,0 +1,98 @@
+package primitive
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFnvHash64(t *testing.T) {
+	assert.Equal(t, uint64(0xcbf29ce484222325), FnvHash64(nil))
+	assert.Equal(t, uint64(0xaf63dc4c8601ec8c), FnvHash64([]byte("a")))
+}
+
+func TestShardForStable(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("key%d", i))
+		assert.Equal(t, ShardFor(key, 7), ShardFor(key, 7))
+	}
+}
+
+func TestShardForSingleShard(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, uint64(0), ShardFor([]byte(fmt.Sprintf("key%d", i)), 1))
+	}
+}
+
+func TestShardForDistribution(t *testing.T) {
+	const numShards = 8
+	const numKeys = 8000
+	counts := make([]int, numShards)
+	for i := 0; i < numKeys; i++ {
+		s := ShardFor([]byte(fmt.Sprintf("key%d", i)), numShards)
+		if !assert.Less(t, s, uint64(numShards)) {
+			return
+		}
+		counts[s]++
+	}
+	for s, c := range counts {
+		assert.InDelta(t, numKeys/numShards, c, numKeys/numShards/4, "shard %d", s)
+	}
+}
+
+func TestShardForZeroShards(t *testing.T) {
+	assert.Panics(t, func() { ShardFor([]byte("key"), 0) })
+}
+
+func TestXxHash64(t *testing.T) {
+	tests := []struct {
+		in   string
+		hash uint64
+	}{
+		{"", 0xef46db3751d8e999},
+		{"a", 0xd24ec4f1a98c6e5b},
+		{"abc", 0x44bc2cf5ad770999},
+		{"asdf", 0x415872f599cea71e},
+		{"Nobody inspects the spammish repetition", 0xfbcea83c8a378bf1},
+		{"Call me Ishmael. Some years ago--never mind how long precisely-", 0x02a2e85470d6fd96},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.hash, XxHash64([]byte(tt.in)), "%q", tt.in)
+	}
+}
+
+func BenchmarkXxHash64(b *testing.B) {
+	p := make([]byte, 4096)
+	b.SetBytes(int64(len(p)))
+	for i := 0; i < b.N; i++ {
+		XxHash64(p)
+	}
+}
+
+func BenchmarkFnvHash64(b *testing.B) {
+	p := make([]byte, 4096)
+	b.SetBytes(int64(len(p)))
+	for i := 0; i < b.N; i++ {
+		FnvHash64(p)
+	}
+}
+
+func TestHash64(t *testing.T) {
+	assert.Equal(t, XxHash64([]byte("abc")), Hash64([]byte("abc")))
+	assert.Equal(t, Hash64([]byte("abc")), HashString("abc"))
+	assert.Equal(t, Hash64(nil), HashString(""))
+	assert.NotEqual(t, HashString("abc"), HashString("abd"))
+}
+
+func TestCRC(t *testing.T) {
+	assert := assert.New(t)
+	// the standard check values, for the input "123456789"
+	check := []byte("123456789")
+	assert.Equal(uint32(0xe3069283), CRC32C(check))
+	assert.Equal(uint64(0x995dc9bbdf1939fa), CRC64(check))
+	assert.Equal(uint32(0), CRC32C(nil))
+	assert.Equal(uint64(0), CRC64(nil))
+	// the iSCSI test vector of 32 zero bytes
+	assert.Equal(uint32(0x8a9136aa), CRC32C(make([]byte, 32)))
+}
@@ -0,0 +1,41 @@
+package primitive
+
+import (
+	"math/bits"
+	"sync/atomic"
+)
+
+// NumHistogramBuckets is the number of buckets in a Histogram.
+const NumHistogramBuckets = 65
+
+// Histogram accumulates latency samples in exponentially-sized buckets.
+//
+// Bucket 0 counts samples of exactly 0ns, and bucket i (for 1 <= i <= 64)
+// counts samples in the range [2^(i-1), 2^i) nanoseconds.
+//
+// A Histogram is safe for concurrent use. It is a Go-side measurement
+// affordance and is modeled as having no effect.
+type Histogram struct {
+	buckets [NumHistogramBuckets]atomic.Uint64
+}
+
+// NewHistogram returns an empty histogram.
+func NewHistogram() *Histogram {
+	return &Histogram{}
+}
+
+// Record adds one sample of ns nanoseconds.
+func (h *Histogram) Record(ns uint64) {
+	h.buckets[bits.Len64(ns)].Add(1)
+}
+
+// Buckets returns the current count in each bucket.
+//
+// Concurrent calls to Record may or may not be reflected in the result.
+func (h *Histogram) Buckets() []uint64 {
+	counts := make([]uint64, NumHistogramBuckets)
+	for i := range h.buckets {
+		counts[i] = h.buckets[i].Load()
+	}
+	return counts
+}
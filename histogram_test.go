@@ -0,0 +1,57 @@
+package primitive
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogramBuckets(t *testing.T) {
+	assert := assert.New(t)
+	h := NewHistogram()
+	h.Record(0)
+	h.Record(1)
+	h.Record(2)
+	h.Record(3)
+	h.Record(4)
+	h.Record(1023)
+	h.Record(1024)
+	h.Record(^uint64(0))
+	counts := h.Buckets()
+	assert.Len(counts, NumHistogramBuckets)
+	assert.Equal(uint64(1), counts[0])
+	assert.Equal(uint64(1), counts[1])
+	assert.Equal(uint64(2), counts[2], "[2, 4)")
+	assert.Equal(uint64(1), counts[3], "[4, 8)")
+	assert.Equal(uint64(1), counts[10], "[512, 1024)")
+	assert.Equal(uint64(1), counts[11], "[1024, 2048)")
+	assert.Equal(uint64(1), counts[64])
+}
+
+func TestHistogramConcurrent(t *testing.T) {
+	assert := assert.New(t)
+	h := NewHistogram()
+	const workers = 8
+	const samples = 1000
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < samples; i++ {
+				h.Record(5)
+				h.Record(100)
+			}
+		}()
+	}
+	wg.Wait()
+	counts := h.Buckets()
+	assert.Equal(uint64(workers*samples), counts[3], "5ns samples")
+	assert.Equal(uint64(workers*samples), counts[7], "100ns samples")
+	var total uint64
+	for _, c := range counts {
+		total += c
+	}
+	assert.Equal(uint64(2*workers*samples), total)
+}
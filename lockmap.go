@@ -0,0 +1,80 @@
+package primitive
+
+import "sync"
+
+// numLockShards is the number of shards in a LockMap. A prime spreads
+// sequential keys (e.g., block addresses) evenly.
+const numLockShards uint64 = 43
+
+type lockShard struct {
+	m     sync.Mutex
+	cond  *sync.Cond
+	held  map[uint64]bool
+	waits uint64
+}
+
+// LockMap provides a mutual-exclusion lock for every uint64 key, for
+// fine-grained locking over addresses or blocks.
+//
+// In the model each key has its own lock, and Acquire and Release behave like
+// Lock and Unlock on it. Internally keys are spread over a fixed number of
+// shards, each tracking which of its keys are held, so memory use is
+// proportional to the number of keys held at once rather than the key space.
+type LockMap struct {
+	shards [numLockShards]*lockShard
+}
+
+// NewLockMap creates a lock map in which no key is held.
+func NewLockMap() *LockMap {
+	lm := &LockMap{}
+	for i := range lm.shards {
+		s := &lockShard{held: make(map[uint64]bool)}
+		s.cond = sync.NewCond(&s.m)
+		lm.shards[i] = s
+	}
+	return lm
+}
+
+func (lm *LockMap) shard(key uint64) *lockShard {
+	return lm.shards[key%numLockShards]
+}
+
+// Acquire blocks until the lock for key is free and takes it.
+func (lm *LockMap) Acquire(key uint64) {
+	s := lm.shard(key)
+	s.m.Lock()
+	defer s.m.Unlock()
+	for s.held[key] {
+		s.waits++
+		s.cond.Wait()
+		s.waits--
+	}
+	s.held[key] = true
+}
+
+// TryAcquire takes the lock for key if it is free right now, reporting
+// whether it did.
+func (lm *LockMap) TryAcquire(key uint64) bool {
+	s := lm.shard(key)
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.held[key] {
+		return false
+	}
+	s.held[key] = true
+	return true
+}
+
+// Release frees the lock for key.
+//
+// Assumes the lock for key is held.
+func (lm *LockMap) Release(key uint64) {
+	s := lm.shard(key)
+	s.m.Lock()
+	defer s.m.Unlock()
+	Assume(s.held[key])
+	delete(s.held, key)
+	if s.waits > 0 {
+		s.cond.Broadcast()
+	}
+}
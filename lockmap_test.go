@@ -0,0 +1,42 @@
+package primitive
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockMapTryAcquire(t *testing.T) {
+	assert := assert.New(t)
+	lm := NewLockMap()
+	assert.True(lm.TryAcquire(1))
+	assert.False(lm.TryAcquire(1))
+	// 1 and 44 share a shard but are independent locks
+	assert.True(lm.TryAcquire(1 + numLockShards))
+	lm.Release(1)
+	assert.True(lm.TryAcquire(1))
+	assert.Panics(func() { lm.Release(2) }, "release of unheld key")
+}
+
+func TestLockMapMutualExclusion(t *testing.T) {
+	lm := NewLockMap()
+	counters := make([]uint64, 4)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				key := uint64(j) % uint64(len(counters))
+				lm.Acquire(key)
+				counters[key]++
+				lm.Release(key)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, c := range counters {
+		assert.Equal(t, uint64(1000), c)
+	}
+}
@@ -0,0 +1,67 @@
+package primitive
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Log levels, in increasing order of severity.
+const (
+	LogDebug uint64 = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+var logLevelNames = [...]string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+var logState = struct {
+	m        sync.Mutex
+	sink     io.Writer
+	minLevel uint64
+}{sink: os.Stderr, minLevel: LogInfo}
+
+// SetLogSink directs log output to w (os.Stderr by default).
+func SetLogSink(w io.Writer) {
+	logState.m.Lock()
+	defer logState.m.Unlock()
+	logState.sink = w
+}
+
+// SetLogLevel discards messages below level (LogInfo by default).
+func SetLogLevel(level uint64) {
+	logState.m.Lock()
+	defer logState.m.Unlock()
+	logState.minLevel = level
+}
+
+// Log writes msg at level to the log sink, as one line of the form
+//
+//	time=2006-01-02T15:04:05.000Z07:00 level=INFO msg="..."
+//
+// Modeled as a no-op: logging has no effect on the program's behavior.
+// Messages are written whole, even from concurrent goroutines.
+func Log(level uint64, msg string) {
+	logState.m.Lock()
+	defer logState.m.Unlock()
+	if level < logState.minLevel {
+		return
+	}
+	name := "LEVEL" + fmt.Sprint(level)
+	if level < uint64(len(logLevelNames)) {
+		name = logLevelNames[level]
+	}
+	// errors writing the log are deliberately ignored
+	fmt.Fprintf(logState.sink, "time=%s level=%s msg=%q\n",
+		time.Now().Format("2006-01-02T15:04:05.000Z07:00"), name, msg)
+}
+
+// Logf is Log with the message formatted as by fmt.Sprintf.
+//
+// Modeled as a no-op.
+func Logf(level uint64, format string, args ...any) {
+	Log(level, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,31 @@
+package primitive
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	SetLogSink(&buf)
+	defer SetLogSink(os.Stderr)
+	defer SetLogLevel(LogInfo)
+
+	Log(LogDebug, "hidden")
+	assert.Empty(buf.String(), "debug is below the default level")
+
+	Logf(LogWarn, "disk %d is %s", 3, "slow")
+	assert.Regexp(`^time=\S+ level=WARN msg="disk 3 is slow"\n$`, buf.String())
+
+	buf.Reset()
+	SetLogLevel(LogDebug)
+	Log(LogDebug, "quoted \"msg\"")
+	assert.Contains(buf.String(), `level=DEBUG msg="quoted \"msg\""`)
+	SetLogLevel(LogError)
+	Log(LogWarn, "dropped")
+	assert.NotContains(buf.String(), "dropped")
+}
@@ -0,0 +1,149 @@
+// Package marshal provides a stateful Encoder and Decoder for
+// serializing data to byte slices.
+//
+// All integers are encoded in little-endian order, matching primitive.UInt64Put
+// and friends. Booleans are a single byte, 0 or 1. PutBytes writes raw bytes
+// with no length; PutBytesLenPrefixed and PutString write an 8-byte length
+// prefix first.
+//
+// These are trusted primitives with models in GooseLang, so verified projects
+// can share one implementation and its specification.
+package marshal
+
+import "github.com/goose-lang/primitive"
+
+// Encoder builds up a byte slice by appending encoded values.
+type Encoder struct {
+	b []byte
+}
+
+// NewEncoder creates an empty Encoder, with space reserved for sizeHint bytes.
+//
+// The hint only affects performance: the buffer grows as needed.
+func NewEncoder(sizeHint uint64) *Encoder {
+	return &Encoder{b: make([]byte, 0, sizeHint)}
+}
+
+func (enc *Encoder) grow(n int) []byte {
+	start := len(enc.b)
+	enc.b = append(enc.b, make([]byte, n)...)
+	return enc.b[start:]
+}
+
+// PutInt appends x as 8 bytes.
+func (enc *Encoder) PutInt(x uint64) {
+	primitive.UInt64Put(enc.grow(8), x)
+}
+
+// PutInt32 appends x as 4 bytes.
+func (enc *Encoder) PutInt32(x uint32) {
+	primitive.UInt32Put(enc.grow(4), x)
+}
+
+// PutBytes appends the contents of b.
+func (enc *Encoder) PutBytes(b []byte) {
+	enc.b = append(enc.b, b...)
+}
+
+// PutBool appends b as a single byte.
+func (enc *Encoder) PutBool(b bool) {
+	if b {
+		enc.b = append(enc.b, 1)
+	} else {
+		enc.b = append(enc.b, 0)
+	}
+}
+
+// Finish returns the encoded data.
+//
+// The Encoder should not be used afterward.
+func (enc *Encoder) Finish() []byte {
+	b := enc.b
+	enc.b = nil
+	return b
+}
+
+// Decoder reads encoded values from a byte slice, advancing a cursor past
+// each one.
+//
+// Reading past the end of the data panics (modeled as the machine getting
+// stuck), so callers must know the data is long enough.
+type Decoder struct {
+	b   []byte
+	off uint64
+}
+
+// NewDecoder creates a Decoder that reads b from the beginning.
+func NewDecoder(b []byte) *Decoder {
+	return &Decoder{b: b}
+}
+
+func (dec *Decoder) take(n uint64) []byte {
+	primitive.Assume(n <= uint64(len(dec.b))-dec.off)
+	p := dec.b[dec.off : dec.off+n]
+	dec.off += n
+	return p
+}
+
+// GetInt decodes an 8-byte integer.
+func (dec *Decoder) GetInt() uint64 {
+	return primitive.UInt64Get(dec.take(8))
+}
+
+// GetInt32 decodes a 4-byte integer.
+func (dec *Decoder) GetInt32() uint32 {
+	return primitive.UInt32Get(dec.take(4))
+}
+
+// GetBytes returns the next n bytes.
+//
+// The result aliases the decoder's input.
+func (dec *Decoder) GetBytes(n uint64) []byte {
+	return dec.take(n)
+}
+
+// GetBool decodes a boolean. Any non-zero byte decodes to true.
+func (dec *Decoder) GetBool() bool {
+	return dec.take(1)[0] != 0
+}
+
+// Remaining returns the number of bytes not yet decoded.
+func (dec *Decoder) Remaining() uint64 {
+	return uint64(len(dec.b)) - dec.off
+}
+
+// PutBytesLenPrefixed appends the length of data (as 8 bytes) followed by
+// data itself to b, returning the extended slice.
+func PutBytesLenPrefixed(b []byte, data []byte) []byte {
+	var n [8]byte
+	primitive.UInt64Put(n[:], uint64(len(data)))
+	b = append(b, n[:]...)
+	return append(b, data...)
+}
+
+// GetBytesLenPrefixed parses a length-prefixed byte slice from the start of
+// b, returning it and the remainder of b.
+//
+// Requires b to start with a complete encoding. The result aliases b.
+func GetBytesLenPrefixed(b []byte) ([]byte, []byte) {
+	primitive.Assume(uint64(len(b)) >= 8)
+	n := primitive.UInt64Get(b)
+	b = b[8:]
+	primitive.Assume(n <= uint64(len(b)))
+	return b[:n], b[n:]
+}
+
+// PutString appends s with a length prefix to b, in the same format as
+// PutBytesLenPrefixed, returning the extended slice.
+func PutString(b []byte, s string) []byte {
+	return PutBytesLenPrefixed(b, []byte(s))
+}
+
+// GetString parses a length-prefixed string from the start of b, returning it
+// and the remainder of b.
+//
+// Requires b to start with a complete encoding.
+func GetString(b []byte) (string, []byte) {
+	data, rest := GetBytesLenPrefixed(b)
+	return string(data), rest
+}
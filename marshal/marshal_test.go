@@ -0,0 +1,69 @@
+package marshal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+	enc := NewEncoder(4)
+	enc.PutInt(1 << 40)
+	enc.PutInt32(7)
+	enc.PutBool(true)
+	enc.PutBytes([]byte("hello"))
+	enc.PutBool(false)
+	data := enc.Finish()
+	assert.Len(data, 8+4+1+5+1)
+
+	dec := NewDecoder(data)
+	assert.Equal(uint64(1<<40), dec.GetInt())
+	assert.Equal(uint32(7), dec.GetInt32())
+	assert.True(dec.GetBool())
+	assert.Equal([]byte("hello"), dec.GetBytes(5))
+	assert.False(dec.GetBool())
+	assert.Equal(uint64(0), dec.Remaining())
+}
+
+func TestEncoderFormat(t *testing.T) {
+	enc := NewEncoder(0)
+	enc.PutInt32(0x01020304)
+	enc.PutBool(true)
+	assert.Equal(t, []byte{4, 3, 2, 1, 1}, enc.Finish())
+}
+
+func TestDecoderShort(t *testing.T) {
+	dec := NewDecoder([]byte{1, 2, 3})
+	assert.Panics(t, func() { dec.GetInt() })
+	dec = NewDecoder([]byte{1, 2, 3})
+	assert.Panics(t, func() { dec.GetBytes(4) })
+}
+
+func TestEmpty(t *testing.T) {
+	assert.Empty(t, NewEncoder(0).Finish())
+	assert.Equal(t, uint64(0), NewDecoder(nil).Remaining())
+}
+
+func TestLenPrefixed(t *testing.T) {
+	assert := assert.New(t)
+	var b []byte
+	b = PutString(b, "key")
+	b = PutBytesLenPrefixed(b, []byte{1, 2, 3, 4})
+	b = PutString(b, "")
+	assert.Len(b, 8+3+8+4+8)
+
+	s, b := GetString(b)
+	assert.Equal("key", s)
+	data, b := GetBytesLenPrefixed(b)
+	assert.Equal([]byte{1, 2, 3, 4}, data)
+	s, b = GetString(b)
+	assert.Equal("", s)
+	assert.Empty(b)
+}
+
+func TestLenPrefixedTruncated(t *testing.T) {
+	b := PutString(nil, "hello")
+	assert.Panics(t, func() { GetString(b[:len(b)-1]) })
+	assert.Panics(t, func() { GetString(b[:4]) })
+}
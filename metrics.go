@@ -0,0 +1,90 @@
+package primitive
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// An in-process metrics registry: named counters, gauges and latency
+// histograms. Like Histogram, recording a metric is modeled as a no-op, so it
+// can be sprinkled through verified code for performance visibility without
+// affecting its proofs. Metrics are created on first use and are safe for
+// concurrent use.
+var metrics struct {
+	// each map holds name -> *atomic.Uint64 (counters and gauges) or
+	// *Histogram (latencies)
+	counters, gauges, latencies sync.Map
+}
+
+func metricUint64(m *sync.Map, name string) *atomic.Uint64 {
+	if v, ok := m.Load(name); ok {
+		return v.(*atomic.Uint64)
+	}
+	v, _ := m.LoadOrStore(name, new(atomic.Uint64))
+	return v.(*atomic.Uint64)
+}
+
+// CountOp adds one to the counter name.
+//
+// Modeled as a no-op.
+func CountOp(name string) {
+	metricUint64(&metrics.counters, name).Add(1)
+}
+
+// AddCount adds n to the counter name.
+//
+// Modeled as a no-op.
+func AddCount(name string, n uint64) {
+	metricUint64(&metrics.counters, name).Add(n)
+}
+
+// SetGauge sets the gauge name to v.
+//
+// Modeled as a no-op.
+func SetGauge(name string, v uint64) {
+	metricUint64(&metrics.gauges, name).Store(v)
+}
+
+// RecordLatency adds a sample of ns nanoseconds to the latency histogram
+// name.
+//
+// Modeled as a no-op.
+func RecordLatency(name string, ns uint64) {
+	h, ok := metrics.latencies.Load(name)
+	if !ok {
+		h, _ = metrics.latencies.LoadOrStore(name, NewHistogram())
+	}
+	h.(*Histogram).Record(ns)
+}
+
+// MetricsSnapshot holds the values of every metric at one point.
+type MetricsSnapshot struct {
+	Counters map[string]uint64
+	Gauges   map[string]uint64
+	// Latencies holds each histogram's buckets, in the layout of
+	// Histogram.Buckets.
+	Latencies map[string][]uint64
+}
+
+// Metrics returns the current value of every metric. Updates made
+// concurrently may or may not be reflected.
+func Metrics() MetricsSnapshot {
+	s := MetricsSnapshot{
+		Counters:  make(map[string]uint64),
+		Gauges:    make(map[string]uint64),
+		Latencies: make(map[string][]uint64),
+	}
+	metrics.counters.Range(func(k, v any) bool {
+		s.Counters[k.(string)] = v.(*atomic.Uint64).Load()
+		return true
+	})
+	metrics.gauges.Range(func(k, v any) bool {
+		s.Gauges[k.(string)] = v.(*atomic.Uint64).Load()
+		return true
+	})
+	metrics.latencies.Range(func(k, v any) bool {
+		s.Latencies[k.(string)] = v.(*Histogram).Buckets()
+		return true
+	})
+	return s
+}
@@ -0,0 +1,31 @@
+package primitive
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	assert := assert.New(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			CountOp("test.ops")
+		}()
+	}
+	wg.Wait()
+	AddCount("test.bytes", 4096)
+	SetGauge("test.queue", 7)
+	SetGauge("test.queue", 3)
+	RecordLatency("test.read", 5)
+
+	m := Metrics()
+	assert.Equal(uint64(10), m.Counters["test.ops"])
+	assert.Equal(uint64(4096), m.Counters["test.bytes"])
+	assert.Equal(uint64(3), m.Gauges["test.queue"])
+	assert.Equal(uint64(1), m.Latencies["test.read"][3], "5ns falls in [4, 8)")
+}
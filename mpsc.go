@@ -0,0 +1,75 @@
+package primitive
+
+import "sync/atomic"
+
+type mpscNode[T any] struct {
+	next atomic.Pointer[mpscNode[T]]
+	v    T
+}
+
+// MPSCQueue is an unbounded lock-free FIFO queue with any number of producers
+// and a single consumer, for high-throughput handoff (e.g., to a logger or a
+// write-ahead log's flusher).
+//
+// Modeled as a logically-atomic queue: Push atomically appends a value and
+// TryPop atomically removes the oldest value, if any. Push and TryPop never
+// take a lock; producers contend only on a single atomic swap.
+//
+// Only one goroutine at a time may call TryPop or PopWait.
+type MPSCQueue[T any] struct {
+	// head is a sentinel owned by the consumer; the queue's contents are the
+	// nodes after it.
+	head *mpscNode[T]
+	tail atomic.Pointer[mpscNode[T]]
+	// notify has a pending token whenever a Push may not yet have been
+	// observed by the consumer.
+	notify chan struct{}
+}
+
+// NewMPSCQueue creates an empty queue.
+func NewMPSCQueue[T any]() *MPSCQueue[T] {
+	stub := &mpscNode[T]{}
+	q := &MPSCQueue[T]{head: stub, notify: make(chan struct{}, 1)}
+	q.tail.Store(stub)
+	return q
+}
+
+// Push appends v to the queue. Safe to call from any number of goroutines.
+func (q *MPSCQueue[T]) Push(v T) {
+	n := &mpscNode[T]{v: v}
+	prev := q.tail.Swap(n)
+	prev.next.Store(n)
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// TryPop removes and returns the oldest value in the queue without blocking.
+// ok is false if the queue is empty.
+//
+// A Push that is still in progress may not be visible yet; PopWait accounts for
+// this by waiting for the producer to finish.
+func (q *MPSCQueue[T]) TryPop() (v T, ok bool) {
+	next := q.head.next.Load()
+	if next == nil {
+		return v, false
+	}
+	q.head = next
+	v = next.v
+	// the new sentinel should not keep the value alive
+	var zero T
+	next.v = zero
+	return v, true
+}
+
+// PopWait removes and returns the oldest value in the queue, blocking while
+// the queue is empty.
+func (q *MPSCQueue[T]) PopWait() T {
+	for {
+		if v, ok := q.TryPop(); ok {
+			return v
+		}
+		<-q.notify
+	}
+}
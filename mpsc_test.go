@@ -0,0 +1,47 @@
+package primitive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMPSCQueueFIFO(t *testing.T) {
+	assert := assert.New(t)
+	q := NewMPSCQueue[uint64]()
+	_, ok := q.TryPop()
+	assert.False(ok)
+	for i := uint64(0); i < 5; i++ {
+		q.Push(i)
+	}
+	for i := uint64(0); i < 5; i++ {
+		v, ok := q.TryPop()
+		assert.True(ok)
+		assert.Equal(i, v)
+	}
+	_, ok = q.TryPop()
+	assert.False(ok)
+}
+
+func TestMPSCQueueConcurrent(t *testing.T) {
+	const producers = 8
+	const perProducer = 1000
+	q := NewMPSCQueue[uint64]()
+	for p := uint64(0); p < producers; p++ {
+		go func() {
+			for i := uint64(0); i < perProducer; i++ {
+				q.Push(p<<32 | i)
+			}
+		}()
+	}
+	var next [producers]uint64
+	for n := 0; n < producers*perProducer; n++ {
+		v := q.PopWait()
+		p, i := v>>32, v&0xffffffff
+		// each producer's values arrive in order
+		assert.Equal(t, next[p], i)
+		next[p]++
+	}
+	_, ok := q.TryPop()
+	assert.False(t, ok)
+}
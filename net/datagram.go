@@ -0,0 +1,80 @@
+package net
+
+import (
+	gonet "net"
+
+	"github.com/goose-lang/primitive"
+)
+
+// MaxDatagramSize is the largest message a DatagramSocket can send or
+// receive.
+const MaxDatagramSize uint64 = 65507
+
+// datagramBufs holds the buffers ReceiveFrom reads into before copying out
+// the message.
+var datagramBufs = primitive.NewBufPool(MaxDatagramSize)
+
+// DatagramSocket sends and receives individual messages without a
+// connection, over UDP.
+//
+// Modeled as an explicitly lossy network: SendTo adds a message to the set
+// of messages sent to an address, and ReceiveFrom returns any message ever
+// sent to this socket's address (so messages may be lost, duplicated and
+// reordered), together with its sender.
+type DatagramSocket struct {
+	c *gonet.UDPConn
+}
+
+// BindDatagram opens a socket bound to the UDP address addr, in "host:port"
+// form. Panics if the address cannot be bound.
+func BindDatagram(addr string) DatagramSocket {
+	a, err := gonet.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		panic(err)
+	}
+	c, err := gonet.ListenUDP("udp", a)
+	if err != nil {
+		panic(err)
+	}
+	return DatagramSocket{c: c}
+}
+
+// Addr returns the address the socket is bound to.
+func (s DatagramSocket) Addr() string {
+	return s.c.LocalAddr().String()
+}
+
+// SendTo sends msg to the socket bound to addr. Delivery is not
+// guaranteed, and a message that is dropped is not reported; err is only
+// true if the message could not be sent at all (for example, because addr is
+// invalid). Requires len(msg) <= MaxDatagramSize; a larger message is not
+// sent, and err is true.
+func (s DatagramSocket) SendTo(addr string, msg []byte) (err bool) {
+	if uint64(len(msg)) > MaxDatagramSize {
+		return true
+	}
+	a, e := gonet.ResolveUDPAddr("udp", addr)
+	if e != nil {
+		return true
+	}
+	_, e = s.c.WriteToUDP(msg, a)
+	return e != nil
+}
+
+// ReceiveFrom waits for the next message sent to the socket, returning it and
+// the address of its sender. err is true if the socket has failed or been
+// closed.
+func (s DatagramSocket) ReceiveFrom() (msg []byte, from string, err bool) {
+	buf := datagramBufs.Get()
+	defer datagramBufs.Put(buf)
+	n, a, e := s.c.ReadFromUDP(buf)
+	if e != nil {
+		return nil, "", true
+	}
+	return append([]byte(nil), buf[:n]...), a.String(), false
+}
+
+// Close closes the socket, causing a pending ReceiveFrom to fail.
+func (s DatagramSocket) Close() {
+	s.c.Close()
+}
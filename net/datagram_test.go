@@ -0,0 +1,40 @@
+package net
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatagram(t *testing.T) {
+	assert := assert.New(t)
+	a := BindDatagram("127.0.0.1:0")
+	defer a.Close()
+	b := BindDatagram("127.0.0.1:0")
+	defer b.Close()
+
+	assert.False(a.SendTo(b.Addr(), []byte("ping")))
+	msg, from, err := b.ReceiveFrom()
+	assert.False(err)
+	assert.Equal([]byte("ping"), msg)
+	assert.Less(cap(msg), 64, "a small message does not keep a whole receive buffer alive")
+	assert.Equal(a.Addr(), from)
+
+	assert.False(b.SendTo(from, []byte("pong")))
+	msg, _, _ = a.ReceiveFrom()
+	assert.Equal([]byte("pong"), msg)
+
+	assert.True(a.SendTo("not an address", []byte("x")))
+	assert.True(a.SendTo(b.Addr(), make([]byte, MaxDatagramSize+1)), "oversized datagrams are not sent")
+}
+
+func TestDatagramClose(t *testing.T) {
+	s := BindDatagram("127.0.0.1:0")
+	done := make(chan bool)
+	go func() {
+		_, _, err := s.ReceiveFrom()
+		done <- err
+	}()
+	s.Close()
+	assert.True(t, <-done)
+}
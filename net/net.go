@@ -0,0 +1,170 @@
+// Package net provides message-passing network primitives over TCP, for
+// verified distributed systems.
+//
+// The model is an unreliable network: a message sent on a connection may be
+// lost, duplicated or reordered, and Receive may return any message
+// previously sent to the receiving end. Proofs therefore cannot rely on TCP's
+// ordering or delivery guarantees; in exchange, the implementation is free to
+// drop a connection at any time. (This is the network model of Perennial's
+// Grove.)
+//
+// On the wire, each message is framed by its length as a little-endian
+// uint64.
+package net
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	gonet "net"
+	"sync"
+)
+
+// MaxMessageSize is the largest message, in bytes, that can be sent or
+// received on a Connection. A peer announcing a larger message is treated as
+// a failed connection rather than trusted with an allocation of that size.
+const MaxMessageSize uint64 = 64 << 20
+
+// Listener accepts incoming connections on an address.
+type Listener struct {
+	l gonet.Listener
+}
+
+// Listen starts listening for connections on the TCP address addr, in the
+// "host:port" form of the standard library's net package. Panics if the
+// address cannot be bound.
+func Listen(addr string) Listener {
+	l, err := gonet.Listen("tcp", addr)
+	if err != nil {
+		panic(err)
+	}
+	return Listener{l: l}
+}
+
+// Addr returns the address the listener is bound to, which is useful when
+// listening on port 0.
+func (l Listener) Addr() string {
+	return l.l.Addr().String()
+}
+
+// Accept waits for and returns the next incoming connection. Panics if the
+// listener fails (for example, because it was closed).
+func (l Listener) Accept() Connection {
+	c, err := l.TryAccept()
+	if err {
+		panic("accept failed")
+	}
+	return c
+}
+
+// TryAccept is like Accept, but err is true rather than panicking if the
+// listener fails.
+func (l Listener) TryAccept() (c Connection, err bool) {
+	conn, e := l.l.Accept()
+	if e != nil {
+		return Connection{}, true
+	}
+	return newConnection(conn), false
+}
+
+// Close stops listening. Connections already accepted are unaffected.
+func (l Listener) Close() {
+	l.l.Close()
+}
+
+// Connection is one end of a connection between two addresses.
+//
+// Send and Receive may each be called from several goroutines: concurrent
+// Sends are serialized, as are concurrent Receives.
+type Connection struct {
+	c      gonet.Conn
+	sendMu *sync.Mutex
+	w      *bufio.Writer
+	recvMu *sync.Mutex
+	r      *bufio.Reader
+}
+
+func newConnection(c gonet.Conn) Connection {
+	return Connection{
+		c:      c,
+		sendMu: new(sync.Mutex),
+		w:      bufio.NewWriter(c),
+		recvMu: new(sync.Mutex),
+		r:      bufio.NewReader(c),
+	}
+}
+
+// Connect opens a connection to the TCP address addr. err is true if the
+// connection could not be established.
+func Connect(addr string) (c Connection, err bool) {
+	conn, e := gonet.Dial("tcp", addr)
+	if e != nil {
+		return Connection{}, true
+	}
+	return newConnection(conn), false
+}
+
+// LocalAddr returns the address of this end of the connection.
+func (c Connection) LocalAddr() string {
+	return c.c.LocalAddr().String()
+}
+
+// RemoteAddr returns the address of the other end of the connection.
+func (c Connection) RemoteAddr() string {
+	return c.c.RemoteAddr().String()
+}
+
+// Send sends data as one message. err is true if the connection has failed;
+// it should then be closed and reestablished. Requires len(data) <=
+// MaxMessageSize; a larger message is not sent, and err is true.
+//
+// Modeled as adding data to the set of messages sent to the other end, or
+// doing nothing if err is true.
+func (c Connection) Send(data []byte) (err bool) {
+	if uint64(len(data)) > MaxMessageSize {
+		return true
+	}
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	var hdr [8]byte
+	binary.LittleEndian.PutUint64(hdr[:], uint64(len(data)))
+	if _, e := c.w.Write(hdr[:]); e != nil {
+		return true
+	}
+	if _, e := c.w.Write(data); e != nil {
+		return true
+	}
+	return c.w.Flush() != nil
+}
+
+// Receive waits for the next message. err is true if the connection has
+// failed or was closed by the other end, or if the other end announced a
+// message larger than MaxMessageSize, in which case the connection is
+// closed.
+//
+// Modeled as returning an arbitrary message previously sent to this end, or
+// failing.
+func (c Connection) Receive() (data []byte, err bool) {
+	c.recvMu.Lock()
+	defer c.recvMu.Unlock()
+	var hdr [8]byte
+	if _, e := io.ReadFull(c.r, hdr[:]); e != nil {
+		return nil, true
+	}
+	n := binary.LittleEndian.Uint64(hdr[:])
+	if n > MaxMessageSize {
+		c.c.Close()
+		return nil, true
+	}
+	data = make([]byte, n)
+	if _, e := io.ReadFull(c.r, data); e != nil {
+		return nil, true
+	}
+	return data, false
+}
+
+// Close closes the connection, causing pending and future Sends and
+// Receives on both ends to fail.
+func (c Connection) Close() {
+	c.c.Close()
+}
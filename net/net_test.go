@@ -0,0 +1,73 @@
+package net
+
+import (
+	"encoding/binary"
+	gonet "net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendReceive(t *testing.T) {
+	assert := assert.New(t)
+	l := Listen("127.0.0.1:0")
+	defer l.Close()
+	accepted := make(chan Connection)
+	go func() { accepted <- l.Accept() }()
+
+	c, err := Connect(l.Addr())
+	assert.False(err)
+	defer c.Close()
+	s := <-accepted
+	defer s.Close()
+	assert.Equal(c.LocalAddr(), s.RemoteAddr())
+
+	assert.False(c.Send([]byte("hello")))
+	assert.False(c.Send(nil))
+	msg, err := s.Receive()
+	assert.False(err)
+	assert.Equal([]byte("hello"), msg)
+	msg, err = s.Receive()
+	assert.False(err)
+	assert.Empty(msg)
+
+	assert.False(s.Send([]byte("reply")))
+	msg, _ = c.Receive()
+	assert.Equal([]byte("reply"), msg)
+
+	c.Close()
+	_, err = s.Receive()
+	assert.True(err, "receive should fail once the peer closes")
+}
+
+func TestConnectFails(t *testing.T) {
+	l := Listen("127.0.0.1:0")
+	addr := l.Addr()
+	l.Close()
+	_, err := Connect(addr)
+	assert.True(t, err)
+}
+
+func TestReceiveOversized(t *testing.T) {
+	assert := assert.New(t)
+	l := Listen("127.0.0.1:0")
+	defer l.Close()
+	accepted := make(chan Connection)
+	go func() { accepted <- l.Accept() }()
+
+	raw, e := gonet.Dial("tcp", l.Addr())
+	assert.NoError(e)
+	defer raw.Close()
+	s := <-accepted
+	var hdr [8]byte
+	binary.LittleEndian.PutUint64(hdr[:], ^uint64(0))
+	raw.Write(hdr[:])
+	_, err := s.Receive()
+	assert.True(err, "an oversized header should fail the connection")
+	_, err = s.Receive()
+	assert.True(err, "the connection should be closed")
+
+	c, _ := Connect(l.Addr())
+	defer c.Close()
+	assert.True(c.Send(make([]byte, MaxMessageSize+1)))
+}
@@ -0,0 +1,30 @@
+package primitive
+
+import "sync"
+
+// Once runs a function exactly once across all callers, for lazy
+// initialization of shared state.
+//
+// In the model Once is a lock protecting a done flag: the first Do runs f while
+// holding the lock, and every Do returns only after f has finished, so callers
+// may rely on the effects of f afterward.
+//
+// The zero value is ready to use.
+type Once struct {
+	m    sync.Mutex
+	done bool
+}
+
+// Do calls f if and only if this is the first call to Do on o; other callers
+// block until that call returns.
+//
+// If f panics, o is still considered done. f must not call Do on o.
+func (o *Once) Do(f func()) {
+	o.m.Lock()
+	defer o.m.Unlock()
+	if o.done {
+		return
+	}
+	o.done = true
+	f()
+}
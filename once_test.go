@@ -0,0 +1,32 @@
+package primitive
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnce(t *testing.T) {
+	var o Once
+	var calls uint64
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o.Do(func() { calls++ })
+			// f's effects are visible to every caller after Do
+			assert.Equal(t, uint64(1), calls)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestOncePanic(t *testing.T) {
+	var o Once
+	assert.Panics(t, func() { o.Do(func() { panic("init failed") }) })
+	ran := false
+	o.Do(func() { ran = true })
+	assert.False(t, ran)
+}
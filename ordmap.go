@@ -0,0 +1,118 @@
+package primitive
+
+// orderedMapMaxLevel bounds the height of an OrderedMap's skip list, enough
+// for billions of keys with the promotion probability of 1/4.
+const orderedMapMaxLevel = 16
+
+type skipNode[V any] struct {
+	key  uint64
+	val  V
+	next []*skipNode[V]
+}
+
+// OrderedMap is a map from uint64 keys to values of type V that iterates in
+// increasing key order.
+//
+// Modeled as a finite map; unlike a Go map, iteration order is determined by
+// the keys, so enumerating it introduces no nondeterminism. It is
+// implemented as a skip list whose node heights come from a Rand with a
+// fixed seed, so even its performance is reproducible.
+//
+// An OrderedMap is not safe for concurrent use.
+type OrderedMap[V any] struct {
+	head  skipNode[V]
+	level int
+	len   uint64
+	rng   *Rand
+}
+
+// NewOrderedMap creates an empty map.
+func NewOrderedMap[V any]() *OrderedMap[V] {
+	m := &OrderedMap[V]{level: 1, rng: NewRand(0)}
+	m.head.next = make([]*skipNode[V], orderedMapMaxLevel)
+	return m
+}
+
+func (m *OrderedMap[V]) randomLevel() int {
+	l := 1
+	for l < orderedMapMaxLevel && m.rng.Uint64()%4 == 0 {
+		l++
+	}
+	return l
+}
+
+// findPrev fills prev[i] with the last node at level i whose key is less
+// than key, and returns the node following prev[0].
+func (m *OrderedMap[V]) findPrev(key uint64, prev []*skipNode[V]) *skipNode[V] {
+	x := &m.head
+	for i := m.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && x.next[i].key < key {
+			x = x.next[i]
+		}
+		if prev != nil {
+			prev[i] = x
+		}
+	}
+	return x.next[0]
+}
+
+// Len returns the number of keys in the map.
+func (m *OrderedMap[V]) Len() uint64 {
+	return m.len
+}
+
+// Lookup returns the value for key, and whether it is present.
+func (m *OrderedMap[V]) Lookup(key uint64) (v V, ok bool) {
+	x := m.findPrev(key, nil)
+	if x != nil && x.key == key {
+		return x.val, true
+	}
+	return v, false
+}
+
+// Insert sets the value for key to v, replacing any existing value.
+func (m *OrderedMap[V]) Insert(key uint64, v V) {
+	var prev [orderedMapMaxLevel]*skipNode[V]
+	x := m.findPrev(key, prev[:])
+	if x != nil && x.key == key {
+		x.val = v
+		return
+	}
+	l := m.randomLevel()
+	for ; m.level < l; m.level++ {
+		prev[m.level] = &m.head
+	}
+	n := &skipNode[V]{key: key, val: v, next: make([]*skipNode[V], l)}
+	for i := 0; i < l; i++ {
+		n.next[i] = prev[i].next[i]
+		prev[i].next[i] = n
+	}
+	m.len++
+}
+
+// Delete removes key from the map, reporting whether it was present.
+func (m *OrderedMap[V]) Delete(key uint64) bool {
+	var prev [orderedMapMaxLevel]*skipNode[V]
+	x := m.findPrev(key, prev[:])
+	if x == nil || x.key != key {
+		return false
+	}
+	for i := range x.next {
+		prev[i].next[i] = x.next[i]
+	}
+	for m.level > 1 && m.head.next[m.level-1] == nil {
+		m.level--
+	}
+	m.len--
+	return true
+}
+
+// IterateRange calls f on each key in [lo, hi) and its value, in increasing
+// key order, stopping early if f returns false. f must not modify the map.
+func (m *OrderedMap[V]) IterateRange(lo, hi uint64, f func(key uint64, v V) bool) {
+	for x := m.findPrev(lo, nil); x != nil && x.key < hi; x = x.next[0] {
+		if !f(x.key, x.val) {
+			return
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package primitive
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderedMap(t *testing.T) {
+	assert := assert.New(t)
+	m := NewOrderedMap[string]()
+	m.Insert(5, "five")
+	m.Insert(1, "one")
+	m.Insert(3, "three")
+	m.Insert(3, "THREE")
+	assert.Equal(uint64(3), m.Len())
+
+	v, ok := m.Lookup(3)
+	assert.True(ok)
+	assert.Equal("THREE", v)
+	_, ok = m.Lookup(2)
+	assert.False(ok)
+
+	var keys []uint64
+	m.IterateRange(0, ^uint64(0), func(k uint64, v string) bool {
+		keys = append(keys, k)
+		return true
+	})
+	assert.Equal([]uint64{1, 3, 5}, keys)
+
+	keys = nil
+	m.IterateRange(2, 5, func(k uint64, v string) bool {
+		keys = append(keys, k)
+		return true
+	})
+	assert.Equal([]uint64{3}, keys, "hi is exclusive")
+
+	assert.True(m.Delete(3))
+	assert.False(m.Delete(3))
+	assert.Equal(uint64(2), m.Len())
+}
+
+func TestOrderedMapRandom(t *testing.T) {
+	m := NewOrderedMap[uint64]()
+	ref := make(map[uint64]uint64)
+	r := NewRand(1)
+	for i := 0; i < 5000; i++ {
+		k := r.Uint64n(1000)
+		if r.Uint64n(3) == 0 {
+			_, present := ref[k]
+			assert.Equal(t, present, m.Delete(k))
+			delete(ref, k)
+		} else {
+			m.Insert(k, uint64(i))
+			ref[k] = uint64(i)
+		}
+	}
+	var want []uint64
+	for k := range ref {
+		want = append(want, k)
+	}
+	sort.Slice(want, func(i, j int) bool { return want[i] < want[j] })
+	var got []uint64
+	m.IterateRange(0, 1000, func(k, v uint64) bool {
+		assert.Equal(t, ref[k], v)
+		got = append(got, k)
+		return true
+	})
+	assert.Equal(t, want, got)
+	assert.Equal(t, uint64(len(ref)), m.Len())
+
+	n := 0
+	m.IterateRange(0, 1000, func(k, v uint64) bool {
+		n++
+		return n < 3
+	})
+	assert.Equal(t, 3, n, "iteration should stop when f returns false")
+}
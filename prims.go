@@ -5,9 +5,13 @@ package primitive
 
 import (
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
+	"math"
 	"math/rand"
 	"os"
+	"runtime"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -42,11 +46,293 @@ func UInt32Put(p []byte, n uint32) {
 	binary.LittleEndian.PutUint32(p, n)
 }
 
+// UInt64GetChecked is like UInt64Get, but returns (0, false) if p is shorter
+// than 8 bytes instead of requiring the caller to guarantee its length.
+func UInt64GetChecked(p []byte) (uint64, bool) {
+	if len(p) < 8 {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint64(p), true
+}
+
+// UInt32GetChecked is like UInt32Get, but returns (0, false) if p is shorter
+// than 4 bytes instead of requiring the caller to guarantee its length.
+func UInt32GetChecked(p []byte) (uint32, bool) {
+	if len(p) < 4 {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint32(p), true
+}
+
+// UInt16Get converts the first 2 bytes of p (in little-endian order) to a
+// uint16.
+//
+// Requires p be at least 2 bytes long.
+func UInt16Get(p []byte) uint16 {
+	return binary.LittleEndian.Uint16(p)
+}
+
+// UInt16Put stores n to the first 2 bytes of p in little-endian order.
+//
+// Requires p to be at least 2 bytes long.
+func UInt16Put(p []byte, n uint16) {
+	binary.LittleEndian.PutUint16(p, n)
+}
+
+// UInt64GetBE converts the first 8 bytes of p (in big-endian order) to a
+// uint64.
+//
+// Requires p be at least 8 bytes long.
+func UInt64GetBE(p []byte) uint64 {
+	return binary.BigEndian.Uint64(p)
+}
+
+// UInt64PutBE stores n to the first 8 bytes of p in big-endian order.
+//
+// Requires p to be at least 8 bytes long.
+func UInt64PutBE(p []byte, n uint64) {
+	binary.BigEndian.PutUint64(p, n)
+}
+
+// UInt32GetBE converts the first 4 bytes of p (in big-endian order) to a
+// uint32.
+//
+// Requires p be at least 4 bytes long.
+func UInt32GetBE(p []byte) uint32 {
+	return binary.BigEndian.Uint32(p)
+}
+
+// UInt32PutBE stores n to the first 4 bytes of p in big-endian order.
+//
+// Requires p to be at least 4 bytes long.
+func UInt32PutBE(p []byte, n uint32) {
+	binary.BigEndian.PutUint32(p, n)
+}
+
+// UInt16GetBE converts the first 2 bytes of p (in big-endian order) to a
+// uint16.
+//
+// Requires p be at least 2 bytes long.
+func UInt16GetBE(p []byte) uint16 {
+	return binary.BigEndian.Uint16(p)
+}
+
+// UInt16PutBE stores n to the first 2 bytes of p in big-endian order.
+//
+// Requires p to be at least 2 bytes long.
+func UInt16PutBE(p []byte, n uint16) {
+	binary.BigEndian.PutUint16(p, n)
+}
+
+// ByteGet returns the first byte of p.
+//
+// Requires p be at least 1 byte long.
+func ByteGet(p []byte) byte {
+	return p[0]
+}
+
+// BytePut stores b to the first byte of p.
+//
+// Requires p to be at least 1 byte long.
+func BytePut(p []byte, b byte) {
+	p[0] = b
+}
+
+// BoolGet decodes a boolean from the first byte of p, which must hold the
+// canonical encoding written by BoolPut: 0 for false or 1 for true.
+//
+// The proof assumes the byte is canonical; in Go, any other value panics.
+// Requires p be at least 1 byte long.
+func BoolGet(p []byte) bool {
+	Assume(p[0] <= 1)
+	return p[0] == 1
+}
+
+// BoolPut stores b to the first byte of p as 1 (true) or 0 (false).
+//
+// Requires p to be at least 1 byte long.
+func BoolPut(p []byte, b bool) {
+	if b {
+		p[0] = 1
+	} else {
+		p[0] = 0
+	}
+}
+
+// Int64Get converts the first 8 bytes of p (in little-endian order) to an
+// int64, interpreting them as two's complement.
+//
+// Requires p be at least 8 bytes long.
+func Int64Get(p []byte) int64 {
+	return int64(binary.LittleEndian.Uint64(p))
+}
+
+// Int64Put stores n to the first 8 bytes of p in little-endian, two's
+// complement form.
+//
+// This is the same encoding as UInt64Put(p, uint64(n)): negative numbers wrap
+// around to large unsigned values, and Int64Get inverts the conversion.
+//
+// Requires p to be at least 8 bytes long.
+func Int64Put(p []byte, n int64) {
+	binary.LittleEndian.PutUint64(p, uint64(n))
+}
+
+// Int32Get converts the first 4 bytes of p (in little-endian order) to an
+// int32, interpreting them as two's complement.
+//
+// Requires p be at least 4 bytes long.
+func Int32Get(p []byte) int32 {
+	return int32(binary.LittleEndian.Uint32(p))
+}
+
+// Int32Put stores n to the first 4 bytes of p in little-endian, two's
+// complement form (the same encoding as UInt32Put(p, uint32(n))).
+//
+// Requires p to be at least 4 bytes long.
+func Int32Put(p []byte, n int32) {
+	binary.LittleEndian.PutUint32(p, uint32(n))
+}
+
+// Float64ToBits returns the IEEE 754 binary representation of f.
+//
+// Modeled as a pure, injective function (distinct NaN payloads are
+// preserved).
+func Float64ToBits(f float64) uint64 {
+	return math.Float64bits(f)
+}
+
+// Float64FromBits returns the float64 with IEEE 754 binary representation b,
+// the inverse of Float64ToBits.
+func Float64FromBits(b uint64) float64 {
+	return math.Float64frombits(b)
+}
+
+// Float64Get converts the first 8 bytes of p (in little-endian order) to a
+// float64 via its IEEE 754 bits.
+//
+// Requires p be at least 8 bytes long.
+func Float64Get(p []byte) float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(p))
+}
+
+// Float64Put stores the IEEE 754 bits of f to the first 8 bytes of p in
+// little-endian order.
+//
+// Requires p to be at least 8 bytes long.
+func Float64Put(p []byte, f float64) {
+	binary.LittleEndian.PutUint64(p, math.Float64bits(f))
+}
+
+// MaxVarintLen is the maximum number of bytes PutUvarint uses.
+const MaxVarintLen uint64 = binary.MaxVarintLen64
+
+// UvarintLen returns the number of bytes PutUvarint uses to encode x.
+func UvarintLen(x uint64) uint64 {
+	n := uint64(1)
+	for x >= 0x80 {
+		x >>= 7
+		n++
+	}
+	return n
+}
+
+// PutUvarint stores x to the start of p as an unsigned LEB128 varint (the
+// same format as encoding/binary) and returns the number of bytes written,
+// between 1 and MaxVarintLen.
+//
+// Requires p to be at least UvarintLen(x) bytes long; panics otherwise.
+func PutUvarint(p []byte, x uint64) uint64 {
+	if uint64(len(p)) < UvarintLen(x) {
+		panic("PutUvarint: buffer too short")
+	}
+	return uint64(binary.PutUvarint(p, x))
+}
+
+// GetUvarint decodes an unsigned LEB128 varint from the start of p, returning
+// the value and the number of bytes consumed.
+//
+// Requires p to start with a complete, valid encoding of a uint64; panics if
+// p is too short or the encoding overflows 64 bits.
+func GetUvarint(p []byte) (uint64, uint64) {
+	x, n := binary.Uvarint(p)
+	if n == 0 {
+		panic("GetUvarint: buffer too short")
+	}
+	if n < 0 {
+		panic("GetUvarint: value overflows uint64")
+	}
+	return x, uint64(n)
+}
+
 // RandomUint64 returns a random uint64 using the global seed.
 func RandomUint64() uint64 {
 	return rand.Uint64()
 }
 
+// RandomUint64n returns a uniformly random value in [0, n) using the global
+// seed.
+//
+// Modeled as a nondeterministic choice of a value less than n. Assumes n > 0.
+func RandomUint64n(n uint64) uint64 {
+	Assume(n > 0)
+	return uniformBelow(n, rand.Uint64)
+}
+
+// ArbitraryUint64 returns an arbitrary uint64, for modeling external inputs or
+// adversarial choices.
+//
+// Modeled as a fully nondeterministic value; unlike RandomUint64, proofs may
+// not assume anything about its distribution. In Go it is implemented with
+// RandomUint64.
+func ArbitraryUint64() uint64 {
+	return RandomUint64()
+}
+
+// ArbitraryBool returns an arbitrary bool.
+//
+// Modeled as a nondeterministic choice. In Go it is implemented with
+// RandomUint64.
+func ArbitraryBool() bool {
+	return RandomUint64()&1 == 1
+}
+
+// RandomBytes returns a slice of n random bytes using the global seed.
+//
+// Modeled as returning an arbitrary slice of length n. Not suitable for keys
+// or nonces that must be unpredictable.
+func RandomBytes(n uint64) []byte {
+	p := make([]byte, n)
+	var buf [8]byte
+	for i := uint64(0); i < n; i += 8 {
+		binary.LittleEndian.PutUint64(buf[:], rand.Uint64())
+		copy(p[i:], buf[:])
+	}
+	return p
+}
+
+// RandomUUID returns a random version-4 UUID (RFC 4122), using the global
+// seed.
+//
+// Modeled as a nondeterministic choice among 128-bit values with the version
+// and variant bits set.
+func RandomUUID() [16]byte {
+	var u [16]byte
+	binary.LittleEndian.PutUint64(u[:8], rand.Uint64())
+	binary.LittleEndian.PutUint64(u[8:], rand.Uint64())
+	u[6] = (u[6] & 0x0f) | 0x40 // version 4
+	u[8] = (u[8] & 0x3f) | 0x80 // variant 10
+	return u
+}
+
+// UUIDToString formats u in the canonical 8-4-4-4-12 lowercase hexadecimal
+// form.
+//
+// Assumed to be pure and injective in the Coq model.
+func UUIDToString(u [16]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
+
 // UInt64ToString formats a number as a string.
 //
 // Assumed to be pure and injective in the Coq model.
@@ -54,6 +340,53 @@ func UInt64ToString(x uint64) string {
 	return fmt.Sprintf("%d", x)
 }
 
+// UInt64FromString parses a decimal number, the inverse of UInt64ToString.
+//
+// Only the canonical strings produced by UInt64ToString are accepted: no sign,
+// no leading zeros (other than "0" itself), and no surrounding space. So
+// UInt64FromString(s) returns (x, true) exactly when s = UInt64ToString(x),
+// and returns false otherwise, including on overflow.
+//
+// Assumed to be pure in the Coq model.
+func UInt64FromString(s string) (uint64, bool) {
+	if len(s) == 0 || (s[0] == '0' && len(s) > 1) || s[0] == '+' {
+		return 0, false
+	}
+	x, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return x, true
+}
+
+// UInt64ToHexString formats x in lowercase hexadecimal, without a "0x" prefix
+// or leading zeros.
+//
+// Assumed to be pure and injective in the Coq model.
+func UInt64ToHexString(x uint64) string {
+	return strconv.FormatUint(x, 16)
+}
+
+// HexEncode returns the lowercase hexadecimal encoding of p, two characters
+// per byte.
+//
+// Assumed to be pure and injective in the Coq model.
+func HexEncode(p []byte) string {
+	return hex.EncodeToString(p)
+}
+
+// HexDecode decodes a hexadecimal string (of either case) to bytes, returning
+// false if s has odd length or contains a non-hex character.
+//
+// Inverts HexEncode: HexDecode(HexEncode(p)) = (p, true).
+func HexDecode(s string) ([]byte, bool) {
+	p, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, false
+	}
+	return p, true
+}
+
 // Linearize does nothing.
 //
 // Translates to an atomic step that supports opening invariants conveniently for
@@ -61,6 +394,20 @@ func UInt64ToString(x uint64) string {
 // a procedure.
 func Linearize() {}
 
+// LinearizeNamed does nothing.
+//
+// Like Linearize, but tags the linearization point with a name so that a
+// procedure with several conditional linearization points can refer to each
+// one by tag in the model and in proofs. tag should be a string literal.
+func LinearizeNamed(tag string) {}
+
+// Observe does nothing.
+//
+// Translates to an atomic observation step of x, so a spec can state that the
+// value read at this point satisfies some property without restructuring the
+// code around Linearize.
+func Observe(x uint64) {}
+
 // Assume lets the proof assume that `c` is true.
 //
 // In Go, if the assumption is violated this function will panic, whereas in the
@@ -84,6 +431,63 @@ func Assert(c bool) {
 	}
 }
 
+// Assumef is like Assume, but includes a message formatted from format and
+// args in the panic, to identify which assumption was violated. The message is
+// only formatted if c is false, and is ignored in the GooseLang model.
+func Assumef(c bool, format string, args ...interface{}) {
+	if !c {
+		panic("Assume condition violated: " + fmt.Sprintf(format, args...))
+	}
+}
+
+// Assertf is like Assert, but includes a message formatted from format and
+// args in the panic, to identify which assertion failed. The message is only
+// formatted if c is false, and is ignored in the GooseLang model.
+func Assertf(c bool, format string, args ...interface{}) {
+	if !c {
+		panic("Assert condition violated: " + fmt.Sprintf(format, args...))
+	}
+}
+
+// TryCall runs f, recovering from any panic in it, and reports whether f
+// completed normally.
+//
+// Modeled as f either completing (returning true) or aborting at some point
+// (returning false, with whatever effects f had before aborting). This lets a
+// server contain, say, a failed Assert while handling one request; state that
+// f shares with the rest of the program may be left inconsistent by an abort,
+// and locks it holds are not released unless it defers their release.
+func TryCall(f func()) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	f()
+	return true
+}
+
+// allocSlack is the number of heap allocations AssertNoAllocGrowth tolerates,
+// to absorb incidental allocations by the runtime.
+const allocSlack = 16
+
+// AssertNoAllocGrowth runs f and panics if it performed more than a small
+// number of heap allocations.
+//
+// This is a testing affordance for catching allocation regressions and has no
+// meaning in the GooseLang model, where it is treated as just calling f. The
+// allocation count is process-wide, so concurrent goroutines can cause
+// spurious failures.
+func AssertNoAllocGrowth(f func()) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	f()
+	runtime.ReadMemStats(&after)
+	if n := after.Mallocs - before.Mallocs; n > allocSlack {
+		panic(fmt.Errorf("AssertNoAllocGrowth: %d allocations", n))
+	}
+}
+
 // Exit terminates the program with the given exit code.
 //
 // Modeled as an infinite loop since no more steps will be taken.
@@ -91,28 +495,150 @@ func Exit(n uint64) {
 	os.Exit(int(n))
 }
 
+var exitHooks struct {
+	sync.Mutex
+	hooks []func()
+}
+
+// RegisterExitHook registers f to run when the program terminates via
+// ExitClean.
+//
+// Hooks are Go-side cleanup (flushing disks, closing files) and have no
+// semantic effect in the GooseLang model.
+func RegisterExitHook(f func()) {
+	exitHooks.Lock()
+	defer exitHooks.Unlock()
+	exitHooks.hooks = append(exitHooks.hooks, f)
+}
+
+// RegisterShutdownHook registers f to run on an orderly shutdown via
+// ExitClean. It is another name for RegisterExitHook.
+func RegisterShutdownHook(f func()) {
+	RegisterExitHook(f)
+}
+
+// runExitHooks runs the registered exit hooks in LIFO order, removing them
+// as it goes.
+func runExitHooks() {
+	for {
+		exitHooks.Lock()
+		n := len(exitHooks.hooks)
+		if n == 0 {
+			exitHooks.Unlock()
+			return
+		}
+		f := exitHooks.hooks[n-1]
+		exitHooks.hooks = exitHooks.hooks[:n-1]
+		exitHooks.Unlock()
+		runExitHook(f)
+	}
+}
+
+// runExitHook runs f, reporting rather than propagating a panic so that one
+// failing hook does not prevent the remaining hooks from running or the
+// program from exiting.
+func runExitHook(f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "exit hook panicked: %v\n", r)
+		}
+	}()
+	f()
+}
+
+// ExitClean runs all hooks registered with RegisterExitHook (most recently
+// registered first) and then terminates the program with the given exit code.
+// A hook that panics is reported on stderr and skipped; each hook runs at most
+// once even if ExitClean is called from several goroutines.
+//
+// Like Exit, this is modeled as an infinite loop; the hooks are not modeled.
+func ExitClean(n uint64) {
+	runExitHooks()
+	os.Exit(int(n))
+}
+
+// NewCond creates a condition variable associated with l, for use with
+// WaitTimeout and WaitTimeoutCancel as well as the ordinary Wait, Signal, and
+// Broadcast.
+func NewCond(l sync.Locker) *sync.Cond {
+	return sync.NewCond(l)
+}
+
+// wakeAfter arranges for cond to be broadcast after timeoutMs milliseconds,
+// setting *flag first, unless *finished has been set by then. It takes
+// cond.L, so it cannot run until the caller (which holds cond.L) is waiting,
+// and the wakeup cannot be lost; the caller sets *finished, under cond.L,
+// once it stops waiting, so a callback that fires too late to be stopped
+// does not wake anyone.
+func wakeAfter(cond *sync.Cond, timeoutMs uint64, flag *bool, finished *bool) *time.Timer {
+	return time.AfterFunc(nsDuration(SatMul(timeoutMs, uint64(time.Millisecond))), func() {
+		cond.L.Lock()
+		if !*finished {
+			*flag = true
+			cond.Broadcast()
+		}
+		cond.L.Unlock()
+	})
+}
+
 // WaitTimeout is like cond.Wait(), but waits for a maximum time of timeoutMs
-// milliseconds.
+// milliseconds. It returns true if it woke up because the timeout elapsed
+// rather than because cond was signaled.
 //
-// Not provided by sync.Cond, so we have to (inefficiently) implement this
-// ourselves.
-func WaitTimeout(cond *sync.Cond, timeoutMs uint64) {
+// Requires cond.L to be held, and re-acquires it before returning. The timeout
+// is delivered by broadcasting on cond, so other waiters on the same cond may
+// see a spurious wakeup; as with any cond, waiters must re-check their
+// condition in a loop. Once WaitTimeout returns its timeout no longer
+// broadcasts, even if the timer had already fired.
+func WaitTimeout(cond *sync.Cond, timeoutMs uint64) bool {
+	var timedOut, finished bool
+	t := wakeAfter(cond, timeoutMs, &timedOut, &finished)
+	cond.Wait()
+	finished = true
+	t.Stop()
+	return timedOut
+}
+
+// WaitTimeoutCancel is like WaitTimeout, but also returns early if cancel is
+// closed.
+//
+// It returns in one of three ways: the cond is signaled (both results false),
+// timeoutMs milliseconds elapse (timedOut), or cancel is closed (canceled). In
+// every case cond.L is held again on return. If several happen at once,
+// cancellation takes precedence over the timeout.
+//
+// In the model the cause of the wakeup is a nondeterministic choice, so
+// callers must re-check their condition whatever the result.
+func WaitTimeoutCancel(cond *sync.Cond, timeoutMs uint64, cancel <-chan struct{}) (canceled bool, timedOut bool) {
+	select {
+	case <-cancel:
+		return true, false
+	default:
+	}
+	var finished bool
+	t := wakeAfter(cond, timeoutMs, &timedOut, &finished)
 	done := make(chan struct{})
 	go func() {
-		cond.Wait()
-		cond.L.Unlock()
-		close(done)
+		select {
+		case <-cancel:
+			cond.L.Lock()
+			// cancel may be chosen even after the wait is over
+			if !finished {
+				canceled = true
+				cond.Broadcast()
+			}
+			cond.L.Unlock()
+		case <-done:
+		}
 	}()
-	select {
-	case <-time.After(time.Duration(timeoutMs) * time.Millisecond):
-		// timed out
-		cond.L.Lock()
-		return
-	case <-done:
-		// Wait returned
-		cond.L.Lock()
-		return
+	cond.Wait()
+	finished = true
+	t.Stop()
+	close(done)
+	if canceled {
+		return true, false
 	}
+	return false, timedOut
 }
 
 // TimeNow returns the current time in nanoseconds.
@@ -120,11 +646,60 @@ func TimeNow() uint64 {
 	return uint64(time.Now().UnixNano())
 }
 
+// ClockUncertaintyNs is the assumed bound, in nanoseconds, on how far the
+// local clock may be from true time.
+const ClockUncertaintyNs uint64 = 1_000_000
+
+// GetTimeRange returns bounds on the true time at the call, in nanoseconds
+// since the Unix epoch: the local clock reading widened by ClockUncertaintyNs
+// in each direction.
+//
+// The model guarantees lo <= t <= hi for the true time t at some point during
+// the call, which is what lease-based protocols need; the Go implementation is
+// only as good as the uncertainty bound.
+func GetTimeRange() (lo uint64, hi uint64) {
+	t := TimeNow()
+	return SatSub(t, ClockUncertaintyNs), SatAdd(t, ClockUncertaintyNs)
+}
+
+// monotonicEpoch is the reference point for TimeMonotonicNow; time.Since uses
+// its monotonic clock reading.
+var monotonicEpoch = time.Now()
+
+// TimeMonotonicNow returns the time in nanoseconds from a monotonic clock.
+//
+// Unlike TimeNow, the result is unaffected by adjustments to the wall clock,
+// and its model guarantees that successive reads never decrease. The values
+// are relative to an arbitrary point near program start, so they are only
+// meaningful compared to each other within one execution.
+func TimeMonotonicNow() uint64 {
+	return uint64(time.Since(monotonicEpoch))
+}
+
+// nsDuration converts ns nanoseconds to a time.Duration, saturating at the
+// largest Duration (about 292 years) instead of wrapping to a negative one.
+func nsDuration(ns uint64) time.Duration {
+	if ns > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(ns)
+}
+
 // Sleep waits for ns nanoseconds.
 //
 // Modeled as a no-op.
 func Sleep(ns uint64) {
-	time.Sleep(time.Duration(ns) * time.Nanosecond)
+	time.Sleep(nsDuration(ns))
+}
+
+// SleepUntil waits until TimeNow() >= deadlineNs, returning immediately if the
+// deadline has already passed.
+//
+// Like Sleep, modeled as a no-op.
+func SleepUntil(deadlineNs uint64) {
+	if now := TimeNow(); now < deadlineNs {
+		time.Sleep(nsDuration(deadlineNs - now))
+	}
 }
 
 // MapClear deletes all values from the map m.
@@ -1,8 +1,11 @@
 package primitive
 
 import (
+	"math"
+	"runtime"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -47,6 +50,157 @@ func TestUInt32GetPut(t *testing.T) {
 	}
 }
 
+func TestGetChecked(t *testing.T) {
+	assert := assert.New(t)
+	p := make([]byte, 8)
+	UInt64Put(p, 0x0102030405060708)
+	x, ok := UInt64GetChecked(p)
+	assert.True(ok)
+	assert.Equal(uint64(0x0102030405060708), x)
+	_, ok = UInt64GetChecked(p[:7])
+	assert.False(ok)
+
+	y, ok := UInt32GetChecked(p[:4])
+	assert.True(ok)
+	assert.Equal(uint32(0x05060708), y)
+	y, ok = UInt32GetChecked(p[:3])
+	assert.False(ok)
+	assert.Equal(uint32(0), y)
+}
+
+func TestUInt16GetPut(t *testing.T) {
+	assert := assert.New(t)
+	tests := []uint16{
+		0, 1, ^uint16(1),
+		13 << 8,
+		0xfc<<8 | 0xb<<4 | 0x1,
+	}
+	for _, tt := range tests {
+		p := make([]byte, 2)
+		UInt16Put(p, tt)
+		assert.Equal(tt, UInt16Get(p))
+	}
+	for _, tt := range tests {
+		p := make([]byte, 10)
+		UInt16Put(p, tt)
+		assert.Equal(tt, UInt16Get(p), "with larger buffer")
+	}
+	p := make([]byte, 2)
+	UInt16Put(p, 0x0102)
+	assert.Equal([]byte{0x02, 0x01}, p, "little-endian")
+}
+
+func TestBigEndianGetPut(t *testing.T) {
+	assert := assert.New(t)
+	p := make([]byte, 10)
+	UInt64PutBE(p, 0x0102030405060708)
+	assert.Equal([]byte{1, 2, 3, 4, 5, 6, 7, 8}, p[:8])
+	assert.Equal(uint64(0x0102030405060708), UInt64GetBE(p))
+
+	p = make([]byte, 10)
+	UInt32PutBE(p, 0x01020304)
+	assert.Equal([]byte{1, 2, 3, 4}, p[:4])
+	assert.Equal(uint32(0x01020304), UInt32GetBE(p))
+
+	p = make([]byte, 10)
+	UInt16PutBE(p, 0x0102)
+	assert.Equal([]byte{1, 2}, p[:2])
+	assert.Equal(uint16(0x0102), UInt16GetBE(p))
+
+	for _, tt := range []uint64{0, 1, ^uint64(1), 0xfc<<30 | 0xb<<20 | 0xa<<10 | 0x1} {
+		UInt64PutBE(p, tt)
+		assert.Equal(tt, UInt64GetBE(p))
+	}
+}
+
+func TestBoolBytePutGet(t *testing.T) {
+	assert := assert.New(t)
+	p := make([]byte, 2)
+	BytePut(p, 0xab)
+	assert.Equal(byte(0xab), ByteGet(p))
+
+	BoolPut(p, true)
+	assert.Equal(byte(1), p[0])
+	assert.True(BoolGet(p))
+	BoolPut(p, false)
+	assert.Equal(byte(0), p[0])
+	assert.False(BoolGet(p))
+
+	p[0] = 2
+	assert.Panics(func() { BoolGet(p) }, "non-canonical bool")
+}
+
+func TestIntGetPut(t *testing.T) {
+	assert := assert.New(t)
+	for _, tt := range []int64{0, 1, -1, math.MinInt64, math.MaxInt64, -1 << 40} {
+		p := make([]byte, 10)
+		Int64Put(p, tt)
+		assert.Equal(tt, Int64Get(p))
+		assert.Equal(uint64(tt), UInt64Get(p), "two's complement")
+	}
+	for _, tt := range []int32{0, 1, -1, math.MinInt32, math.MaxInt32, -1 << 20} {
+		p := make([]byte, 10)
+		Int32Put(p, tt)
+		assert.Equal(tt, Int32Get(p))
+		assert.Equal(uint32(tt), UInt32Get(p), "two's complement")
+	}
+	p := make([]byte, 4)
+	Int32Put(p, -2)
+	assert.Equal([]byte{0xfe, 0xff, 0xff, 0xff}, p)
+}
+
+func TestFloat64GetPut(t *testing.T) {
+	assert := assert.New(t)
+	for _, tt := range []float64{0, 1.5, -2.25, math.Inf(1), math.SmallestNonzeroFloat64, math.MaxFloat64} {
+		p := make([]byte, 10)
+		Float64Put(p, tt)
+		assert.Equal(tt, Float64Get(p))
+		assert.Equal(tt, Float64FromBits(Float64ToBits(tt)))
+	}
+	assert.Equal(uint64(0x3ff0000000000000), Float64ToBits(1.0))
+	assert.Equal(uint64(0x8000000000000000), Float64ToBits(math.Copysign(0, -1)))
+
+	p := make([]byte, 8)
+	Float64Put(p, math.NaN())
+	assert.True(math.IsNaN(Float64Get(p)))
+	nan := uint64(0x7ff8000000000123)
+	assert.Equal(nan, Float64ToBits(Float64FromBits(nan)), "NaN payload preserved")
+}
+
+func TestUvarint(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		x uint64
+		n uint64
+	}{
+		{0, 1},
+		{127, 1},
+		{128, 2},
+		{300, 2},
+		{1 << 42, 7},
+		{^uint64(0), 10},
+	}
+	for _, tt := range tests {
+		p := make([]byte, 12)
+		assert.Equal(tt.n, UvarintLen(tt.x))
+		assert.Equal(tt.n, PutUvarint(p, tt.x), "encode %d", tt.x)
+		x, n := GetUvarint(p)
+		assert.Equal(tt.x, x)
+		assert.Equal(tt.n, n)
+	}
+	p := make([]byte, 2)
+	PutUvarint(p, 300)
+	assert.Equal([]byte{0xac, 0x02}, p)
+}
+
+func TestUvarintShortBuffer(t *testing.T) {
+	assert.Panics(t, func() { PutUvarint(make([]byte, 1), 300) })
+	assert.Panics(t, func() { GetUvarint([]byte{0xac}) })
+	assert.Panics(t, func() { GetUvarint(nil) })
+	overflow := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}
+	assert.Panics(t, func() { GetUvarint(overflow) })
+}
+
 func TestUInt64ToString(t *testing.T) {
 	assert := assert.New(t)
 	tests := []struct {
@@ -62,12 +216,97 @@ func TestUInt64ToString(t *testing.T) {
 	}
 }
 
+func TestUInt64FromString(t *testing.T) {
+	assert := assert.New(t)
+	for _, x := range []uint64{0, 2, 1024, math.MaxUint64} {
+		y, ok := UInt64FromString(UInt64ToString(x))
+		assert.True(ok)
+		assert.Equal(x, y)
+	}
+	for _, s := range []string{"", "-1", "+1", "01", "00", " 1", "1a", "0x10",
+		"18446744073709551616"} {
+		_, ok := UInt64FromString(s)
+		assert.False(ok, "%q", s)
+	}
+}
+
+func TestHex(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("0", UInt64ToHexString(0))
+	assert.Equal("ff", UInt64ToHexString(255))
+	assert.Equal("ffffffffffffffff", UInt64ToHexString(math.MaxUint64))
+
+	assert.Equal("", HexEncode(nil))
+	assert.Equal("00ab10", HexEncode([]byte{0x00, 0xab, 0x10}))
+	p, ok := HexDecode("00ab10")
+	assert.True(ok)
+	assert.Equal([]byte{0x00, 0xab, 0x10}, p)
+	p, ok = HexDecode("ABCD")
+	assert.True(ok)
+	assert.Equal([]byte{0xab, 0xcd}, p)
+
+	_, ok = HexDecode("abc")
+	assert.False(ok, "odd length")
+	_, ok = HexDecode("zz")
+	assert.False(ok, "invalid character")
+}
+
 func TestRandomDoesNotPanic(t *testing.T) {
 	// not much we can test here
 	RandomUint64()
 	RandomUint64()
 }
 
+func TestArbitraryBool(t *testing.T) {
+	// both choices should show up quickly
+	seen := map[bool]bool{}
+	for i := 0; i < 100; i++ {
+		seen[ArbitraryBool()] = true
+	}
+	assert.Len(t, seen, 2)
+	ArbitraryUint64()
+}
+
+func TestRandomUint64n(t *testing.T) {
+	assert := assert.New(t)
+	seen := make(map[uint64]bool)
+	for i := 0; i < 1000; i++ {
+		x := RandomUint64n(5)
+		assert.Less(x, uint64(5))
+		seen[x] = true
+	}
+	assert.Len(seen, 5, "all values should appear")
+	assert.Equal(uint64(0), RandomUint64n(1))
+	assert.Less(RandomUint64n(math.MaxUint64), uint64(math.MaxUint64))
+	assert.Panics(func() { RandomUint64n(0) })
+}
+
+func TestRandomBytes(t *testing.T) {
+	assert := assert.New(t)
+	assert.Empty(RandomBytes(0))
+	for _, n := range []uint64{1, 7, 8, 13, 4096} {
+		assert.Len(RandomBytes(n), int(n))
+	}
+	assert.NotEqual(RandomBytes(32), RandomBytes(32))
+}
+
+func TestRandomUUID(t *testing.T) {
+	assert := assert.New(t)
+	u1 := RandomUUID()
+	u2 := RandomUUID()
+	assert.NotEqual(u1, u2)
+	for _, u := range [][16]byte{u1, u2} {
+		assert.Equal(byte(0x40), u[6]&0xf0, "version bits")
+		assert.Equal(byte(0x80), u[8]&0xc0, "variant bits")
+	}
+}
+
+func TestUUIDToString(t *testing.T) {
+	u := [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x42, 0xd3,
+		0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+	assert.Equal(t, "123e4567-e89b-42d3-a456-426614174000", UUIDToString(u))
+}
+
 func TestLinearizeDoesNothing(t *testing.T) {
 	// not much we can test here
 	Linearize()
@@ -84,6 +323,282 @@ func TestWaitTimeout(t *testing.T) {
 	c := sync.NewCond(&m)
 
 	m.Lock()
-	WaitTimeout(c, 10)
+	timedOut := WaitTimeout(c, 10)
 	m.Unlock()
+	assert.True(t, timedOut)
+}
+
+func TestWaitTimeoutSignaled(t *testing.T) {
+	var m sync.Mutex
+	c := sync.NewCond(&m)
+	returned := false
+	go func() {
+		for {
+			m.Lock()
+			if returned {
+				m.Unlock()
+				return
+			}
+			c.Signal()
+			m.Unlock()
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	m.Lock()
+	timedOut := WaitTimeout(c, 10_000)
+	returned = true
+	m.Unlock()
+	assert.False(t, timedOut)
+}
+
+func TestWaitTimeoutWithBroadcast(t *testing.T) {
+	var m sync.Mutex
+	c := NewCond(&m)
+	ready := false
+	var wg sync.WaitGroup
+	var timedOut bool
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		m.Lock()
+		for !ready {
+			c.Wait()
+		}
+		m.Unlock()
+	}()
+	go func() {
+		defer wg.Done()
+		m.Lock()
+		for !ready {
+			timedOut = WaitTimeout(c, 10_000)
+		}
+		m.Unlock()
+	}()
+	time.Sleep(10 * time.Millisecond)
+	m.Lock()
+	ready = true
+	c.Broadcast()
+	m.Unlock()
+	// both the plain and the timed waiter are woken by the same broadcast
+	wg.Wait()
+	assert.False(t, timedOut)
+}
+
+func TestWaitTimeoutNoLeak(t *testing.T) {
+	var m sync.Mutex
+	c := sync.NewCond(&m)
+	before := runtime.NumGoroutine()
+	m.Lock()
+	for i := 0; i < 20; i++ {
+		WaitTimeout(c, 1)
+		WaitTimeoutCancel(c, 1, make(chan struct{}))
+	}
+	m.Unlock()
+	// give exiting timer callbacks a chance to finish
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
+
+// checkNoStrayWakeup waits on c, holding m, and checks that the first wakeup
+// is the one this function arranges, not a leftover from an earlier wait.
+func checkNoStrayWakeup(t *testing.T, m *sync.Mutex, c *sync.Cond) {
+	woken := false
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		m.Lock()
+		woken = true
+		c.Signal()
+		m.Unlock()
+	}()
+	c.Wait()
+	assert.True(t, woken, "woken by a wait that had already returned")
+	for !woken {
+		c.Wait()
+	}
+}
+
+func TestWaitTimeoutLateTimer(t *testing.T) {
+	var m sync.Mutex
+	c := sync.NewCond(&m)
+	m.Lock()
+	defer m.Unlock()
+	var timedOut, finished bool
+	timer := wakeAfter(c, 1, &timedOut, &finished)
+	// the timer fires and its callback blocks on m, as when a wait is
+	// signaled just as it times out
+	time.Sleep(10 * time.Millisecond)
+	finished = true
+	assert.False(t, timer.Stop(), "the callback has already fired")
+	checkNoStrayWakeup(t, &m, c)
+	assert.False(t, timedOut)
+}
+
+func TestWaitTimeoutCancelTimedOut(t *testing.T) {
+	var m sync.Mutex
+	c := sync.NewCond(&m)
+
+	m.Lock()
+	canceled, timedOut := WaitTimeoutCancel(c, 10, make(chan struct{}))
+	m.Unlock()
+	assert.False(t, canceled)
+	assert.True(t, timedOut)
+}
+
+func TestWaitTimeoutCancelCanceled(t *testing.T) {
+	var m sync.Mutex
+	c := sync.NewCond(&m)
+	cancel := make(chan struct{})
+	close(cancel)
+
+	m.Lock()
+	canceled, timedOut := WaitTimeoutCancel(c, 10_000, cancel)
+	m.Unlock()
+	assert.True(t, canceled)
+	assert.False(t, timedOut)
+}
+
+func TestWaitTimeoutCancelSignaled(t *testing.T) {
+	var m sync.Mutex
+	c := sync.NewCond(&m)
+	returned := false
+	go func() {
+		for {
+			m.Lock()
+			if returned {
+				m.Unlock()
+				return
+			}
+			c.Broadcast()
+			m.Unlock()
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	m.Lock()
+	canceled, timedOut := WaitTimeoutCancel(c, 10_000, make(chan struct{}))
+	returned = true
+	m.Unlock()
+	assert.False(t, canceled)
+	assert.False(t, timedOut)
+}
+
+func TestWaitTimeoutCancelLateCancel(t *testing.T) {
+	var m sync.Mutex
+	c := sync.NewCond(&m)
+	m.Lock()
+	defer m.Unlock()
+	for i := 0; i < 20; i++ {
+		cancel := make(chan struct{})
+		go func() {
+			m.Lock()
+			// the waiter is signaled, and then the helper sees cancel
+			// and blocks on m behind it
+			c.Signal()
+			time.Sleep(time.Millisecond)
+			close(cancel)
+			time.Sleep(time.Millisecond)
+			m.Unlock()
+		}()
+		WaitTimeoutCancel(c, 10_000, cancel)
+		checkNoStrayWakeup(t, &m, c)
+	}
+}
+
+func TestGetTimeRange(t *testing.T) {
+	before := TimeNow()
+	lo, hi := GetTimeRange()
+	after := TimeNow()
+	assert.LessOrEqual(t, lo, before)
+	assert.GreaterOrEqual(t, hi, after)
+	assert.Equal(t, 2*ClockUncertaintyNs, hi-lo)
+}
+
+func TestTimeMonotonicNow(t *testing.T) {
+	last := TimeMonotonicNow()
+	for i := 0; i < 1000; i++ {
+		now := TimeMonotonicNow()
+		assert.GreaterOrEqual(t, now, last)
+		last = now
+	}
+	Sleep(uint64(time.Millisecond))
+	assert.GreaterOrEqual(t, TimeMonotonicNow()-last, uint64(time.Millisecond))
+}
+
+func TestSleepUntil(t *testing.T) {
+	deadline := TimeNow() + uint64(2*time.Millisecond)
+	SleepUntil(deadline)
+	assert.GreaterOrEqual(t, TimeNow(), deadline)
+
+	start := time.Now()
+	SleepUntil(0)
+	assert.Less(t, time.Since(start), time.Second, "past deadline returns immediately")
+}
+
+func TestExitHooksLIFO(t *testing.T) {
+	var order []int
+	for i := 0; i < 3; i++ {
+		RegisterExitHook(func() { order = append(order, i) })
+	}
+	runExitHooks()
+	assert.Equal(t, []int{2, 1, 0}, order)
+
+	// hooks only run once
+	runExitHooks()
+	assert.Equal(t, []int{2, 1, 0}, order)
+}
+
+func TestExitHookPanic(t *testing.T) {
+	var ran []string
+	RegisterShutdownHook(func() { ran = append(ran, "first") })
+	RegisterExitHook(func() { panic("flush failed") })
+	RegisterExitHook(func() { ran = append(ran, "last") })
+	assert.NotPanics(t, runExitHooks)
+	assert.Equal(t, []string{"last", "first"}, ran)
+}
+
+func TestAssumefAssertf(t *testing.T) {
+	assert.NotPanics(t, func() {
+		Assumef(true, "unused %d", 1)
+		Assertf(true, "unused %d", 1)
+	})
+	assert.PanicsWithValue(t, "Assume condition violated: addr 7 out of range",
+		func() { Assumef(false, "addr %d out of range", 7) })
+	assert.PanicsWithValue(t, "Assert condition violated: bad header",
+		func() { Assertf(false, "bad header") })
+}
+
+func TestTryCall(t *testing.T) {
+	x := 0
+	assert.True(t, TryCall(func() { x = 1 }))
+	assert.Equal(t, 1, x)
+	assert.False(t, TryCall(func() {
+		x = 2
+		Assert(false)
+		x = 3
+	}))
+	assert.Equal(t, 2, x, "effects before the abort persist")
+}
+
+var allocSink [][]byte
+
+func TestAssertNoAllocGrowth(t *testing.T) {
+	buf := make([]byte, 100)
+	assert.NotPanics(t, func() {
+		AssertNoAllocGrowth(func() {
+			for i := range buf {
+				buf[i]++
+			}
+		})
+	})
+	assert.Panics(t, func() {
+		AssertNoAllocGrowth(func() {
+			for i := 0; i < 1000; i++ {
+				allocSink = append(allocSink, make([]byte, 64))
+			}
+		})
+	})
+	allocSink = nil
 }
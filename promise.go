@@ -0,0 +1,53 @@
+package primitive
+
+import "sync"
+
+// Promise is a single-assignment cell: it starts empty, is resolved with a
+// value exactly once, and can then be read any number of times.
+//
+// In the model the cell moves from unresolved to resolved(v) at Resolve and
+// never changes again, so any value read from it is the value it was resolved
+// with.
+type Promise[T any] struct {
+	m        sync.Mutex
+	cond     *sync.Cond
+	resolved bool
+	v        T
+}
+
+// NewPromise creates an unresolved promise.
+func NewPromise[T any]() *Promise[T] {
+	p := &Promise[T]{}
+	p.cond = sync.NewCond(&p.m)
+	return p
+}
+
+// Resolve sets the promise's value and wakes all waiters.
+//
+// Assumes the promise has not already been resolved.
+func (p *Promise[T]) Resolve(v T) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	Assume(!p.resolved)
+	p.v = v
+	p.resolved = true
+	p.cond.Broadcast()
+}
+
+// Wait blocks until the promise is resolved and returns its value.
+func (p *Promise[T]) Wait() T {
+	p.m.Lock()
+	defer p.m.Unlock()
+	for !p.resolved {
+		p.cond.Wait()
+	}
+	return p.v
+}
+
+// TryGet returns the promise's value if it has been resolved, without
+// blocking. ok is false if it has not been resolved yet.
+func (p *Promise[T]) TryGet() (v T, ok bool) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.v, p.resolved
+}
@@ -0,0 +1,30 @@
+package primitive
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromise(t *testing.T) {
+	assert := assert.New(t)
+	p := NewPromise[uint64]()
+	_, ok := p.TryGet()
+	assert.False(ok)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(uint64(42), p.Wait())
+		}()
+	}
+	p.Resolve(42)
+	wg.Wait()
+	v, ok := p.TryGet()
+	assert.True(ok)
+	assert.Equal(uint64(42), v)
+	assert.Panics(func() { p.Resolve(43) }, "resolved twice")
+	assert.Equal(uint64(42), p.Wait())
+}
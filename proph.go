@@ -11,9 +11,23 @@ package primitive
 type prophId struct{ p struct{} }
 type ProphId = *prophId
 
+// NewProph creates a fresh prophecy variable.
+//
+// In Go this only allocates a placeholder; GooseLang translates it to the
+// NewProph operation, which introduces a prophecy whose future resolutions the
+// proof may refer to.
 func NewProph() ProphId {
 	return &prophId{}
 }
 
-func (p ProphId) ResolveBool(b bool)  {}
+// ResolveBool resolves p with b. A no-op in Go.
+func (p ProphId) ResolveBool(b bool) {}
+
+// ResolveU64 resolves p with i. A no-op in Go.
 func (p ProphId) ResolveU64(i uint64) {}
+
+// ResolveProph resolves p with v, and is equivalent to p.ResolveU64(v). A
+// no-op in Go; GooseLang translates it to the ResolveProph operation.
+func ResolveProph(p ProphId, v uint64) {
+	p.ResolveU64(v)
+}
@@ -0,0 +1,96 @@
+package primitive
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"math/bits"
+)
+
+// uniformBelow maps the random stream next to a uniform value in [0, n),
+// using Lemire's method of rejecting the few values that would bias the
+// result. Requires n > 0.
+func uniformBelow(n uint64, next func() uint64) uint64 {
+	threshold := -n % n
+	for {
+		hi, lo := bits.Mul64(next(), n)
+		if lo >= threshold {
+			return hi
+		}
+	}
+}
+
+// Rand is a deterministic pseudo-random number generator.
+//
+// The stream is the splitmix64 sequence: the state starts at the seed, each
+// step adds 0x9e3779b97f4a7c15 to it, and the output is the splitmix64
+// finalizer of the new state. Since the stream is a pure function of the seed,
+// executions using a Rand are reproducible, and it is modeled precisely
+// rather than as nondeterministic choice. It is not cryptographically secure.
+//
+// A Rand is not safe for concurrent use.
+type Rand struct {
+	state uint64
+}
+
+// NewRand creates a generator whose stream is determined by seed.
+func NewRand(seed uint64) *Rand {
+	return &Rand{state: seed}
+}
+
+// Uint64 returns the next value in the stream.
+func (r *Rand) Uint64() uint64 {
+	r.state += 0x9e3779b97f4a7c15
+	return mix64(r.state)
+}
+
+// Uint64n returns a value in [0, n) derived from the stream, uniformly
+// distributed if the stream is. Assumes n > 0.
+func (r *Rand) Uint64n(n uint64) uint64 {
+	Assume(n > 0)
+	return uniformBelow(n, r.Uint64)
+}
+
+// CryptoRandomBytes returns n bytes from the operating system's
+// cryptographically secure random source, suitable for keys and tokens.
+//
+// Modeled as returning an arbitrary slice of length n. Panics if the source
+// fails, which does not happen on supported platforms.
+func CryptoRandomBytes(n uint64) []byte {
+	p := make([]byte, n)
+	if _, err := crand.Read(p); err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// CryptoRandomUint64 returns a cryptographically secure random uint64.
+//
+// Modeled as a nondeterministic choice.
+func CryptoRandomUint64() uint64 {
+	return binary.LittleEndian.Uint64(CryptoRandomBytes(8))
+}
+
+// Shuffle randomly permutes the elements of s in place, using the global
+// seed.
+//
+// Modeled as replacing s's contents with an arbitrary permutation of them.
+func Shuffle[T any](s []T) {
+	// Fisher-Yates
+	for i := uint64(len(s)); i > 1; i-- {
+		j := RandomUint64n(i)
+		s[i-1], s[j] = s[j], s[i-1]
+	}
+}
+
+// RandomPermutation returns a random permutation of 0, 1, ..., n-1 using the
+// global seed.
+//
+// Modeled as returning an arbitrary permutation.
+func RandomPermutation(n uint64) []uint64 {
+	p := make([]uint64, n)
+	for i := range p {
+		p[i] = uint64(i)
+	}
+	Shuffle(p)
+	return p
+}
@@ -0,0 +1,81 @@
+package primitive
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenUnit is one token, in the fixed-point units of RateLimiter.tokens.
+const tokenUnit uint64 = 1_000_000_000
+
+// RateLimiter is a token-bucket rate limiter: it holds up to burst tokens,
+// refills at ratePerSec tokens per second, and each admitted operation takes
+// one token. The bucket starts full.
+//
+// The model is conservative: Allow may nondeterministically refuse, and Wait
+// eventually returns, so proofs can only rely on admission control for
+// liveness. A RateLimiter is safe for concurrent use.
+type RateLimiter struct {
+	m          sync.Mutex
+	ratePerSec uint64
+	capacity   uint64
+	// tokens is in units of 1/tokenUnit of a token (so that refilling by
+	// elapsed nanoseconds times ratePerSec is exact)
+	tokens uint64
+	last   uint64
+	now    func() uint64
+}
+
+// NewRateLimiter creates a limiter admitting ratePerSec operations per second
+// on average, with bursts of up to burst operations.
+//
+// Assumes ratePerSec > 0 and burst > 0.
+func NewRateLimiter(ratePerSec uint64, burst uint64) *RateLimiter {
+	Assume(ratePerSec > 0 && burst > 0)
+	capacity := SatMul(burst, tokenUnit)
+	return &RateLimiter{
+		ratePerSec: ratePerSec,
+		capacity:   capacity,
+		tokens:     capacity,
+		last:       TimeMonotonicNow(),
+		now:        TimeMonotonicNow,
+	}
+}
+
+func (r *RateLimiter) refill() {
+	now := r.now()
+	if now > r.last {
+		r.tokens = MinUint64(r.capacity, SatAdd(r.tokens, SatMul(now-r.last, r.ratePerSec)))
+		r.last = now
+	}
+}
+
+// Allow takes a token if one is available, without blocking, and reports
+// whether it did.
+func (r *RateLimiter) Allow() bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.refill()
+	if r.tokens < tokenUnit {
+		return false
+	}
+	r.tokens -= tokenUnit
+	return true
+}
+
+// Wait blocks until a token is available and takes it.
+func (r *RateLimiter) Wait() {
+	for {
+		r.m.Lock()
+		r.refill()
+		if r.tokens >= tokenUnit {
+			r.tokens -= tokenUnit
+			r.m.Unlock()
+			return
+		}
+		// time until the missing fraction of a token refills, rounded up
+		ns := (tokenUnit - r.tokens + r.ratePerSec - 1) / r.ratePerSec
+		r.m.Unlock()
+		time.Sleep(time.Duration(ns))
+	}
+}
@@ -0,0 +1,43 @@
+package primitive
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterBurst(t *testing.T) {
+	assert := assert.New(t)
+	r := NewRateLimiter(1, 3)
+	var now uint64
+	r.now = func() uint64 { return now }
+	r.last = 0
+
+	assert.True(r.Allow())
+	assert.True(r.Allow())
+	assert.True(r.Allow())
+	assert.False(r.Allow(), "burst exhausted")
+
+	now += uint64(time.Second) / 2
+	assert.False(r.Allow(), "half a token")
+	now += uint64(time.Second) / 2
+	assert.True(r.Allow())
+	assert.False(r.Allow())
+
+	now += 100 * uint64(time.Second)
+	for i := 0; i < 3; i++ {
+		assert.True(r.Allow())
+	}
+	assert.False(r.Allow(), "refill is capped at burst")
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	r := NewRateLimiter(1000, 1)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		r.Wait()
+	}
+	// the first token is immediate, the next four take ~1ms each
+	assert.GreaterOrEqual(t, time.Since(start), 3*time.Millisecond)
+}
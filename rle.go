@@ -0,0 +1,45 @@
+package primitive
+
+// Run-length encoding.
+//
+// An encoding is a sequence of 2-byte pairs (count, value), each standing for
+// count consecutive copies of value, where 1 <= count <= 255. Runs longer than
+// 255 are split into several pairs, and RleEncode always emits maximal runs, so
+// every input has exactly one encoding it produces. The empty input encodes to
+// the empty output.
+
+// RleEncode returns the run-length encoding of p.
+func RleEncode(p []byte) []byte {
+	var out []byte
+	for i := 0; i < len(p); {
+		v := p[i]
+		n := 1
+		for i+n < len(p) && p[i+n] == v && n < 255 {
+			n++
+		}
+		out = append(out, byte(n), v)
+		i += n
+	}
+	return out
+}
+
+// RleDecode reconstructs the data encoded by p.
+//
+// Returns false if p is malformed: its length is odd or some pair has a count
+// of zero.
+func RleDecode(p []byte) ([]byte, bool) {
+	if len(p)%2 != 0 {
+		return nil, false
+	}
+	var out []byte
+	for i := 0; i < len(p); i += 2 {
+		n, v := p[i], p[i+1]
+		if n == 0 {
+			return nil, false
+		}
+		for j := byte(0); j < n; j++ {
+			out = append(out, v)
+		}
+	}
+	return out, true
+}
@@ -0,0 +1,43 @@
+package primitive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRleZeroBlock(t *testing.T) {
+	assert := assert.New(t)
+	p := make([]byte, 4096)
+	enc := RleEncode(p)
+	assert.Len(enc, 2*17, "4096 = 16*255 + 16")
+	dec, ok := RleDecode(enc)
+	assert.True(ok)
+	assert.Equal(p, dec)
+}
+
+func TestRleAlternating(t *testing.T) {
+	assert := assert.New(t)
+	p := make([]byte, 100)
+	for i := range p {
+		p[i] = byte(i % 2)
+	}
+	enc := RleEncode(p)
+	assert.Len(enc, 200)
+	dec, ok := RleDecode(enc)
+	assert.True(ok)
+	assert.Equal(p, dec)
+}
+
+func TestRleFormat(t *testing.T) {
+	assert.Equal(t, []byte{3, 'a', 1, 'b'}, RleEncode([]byte("aaab")))
+	assert.Empty(t, RleEncode(nil))
+}
+
+func TestRleDecodeMalformed(t *testing.T) {
+	enc := RleEncode([]byte("aaab"))
+	_, ok := RleDecode(enc[:len(enc)-1])
+	assert.False(t, ok, "truncated encoding")
+	_, ok = RleDecode([]byte{0, 'a'})
+	assert.False(t, ok, "zero count")
+}
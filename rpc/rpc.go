@@ -0,0 +1,240 @@
+// Package rpc is a minimal RPC framework over the connections of package
+// net: a Server dispatches calls, identified by a uint64 method ID, to
+// registered handlers, and a Client issues calls with a timeout.
+//
+// Since the underlying network is modeled as unreliable, so is a call: a
+// handler may run any number of times for one Call (including zero, if Call
+// fails), and Call only returns a reply the handler produced for its
+// arguments. Handlers should therefore be idempotent or tolerate duplicates.
+//
+// A request is framed as seq, method ID, then the arguments; a reply as seq,
+// a status, then the reply. seq and the method ID are little-endian uint64s.
+package rpc
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/goose-lang/primitive/net"
+)
+
+// Error codes returned by Call.
+const (
+	ErrNone uint64 = iota
+	// ErrTimeout means no reply arrived within the timeout.
+	ErrTimeout
+	// ErrDisconnect means the connection failed; the Client must be
+	// replaced.
+	ErrDisconnect
+	// ErrUnknownMethod means the server has no handler for the method ID.
+	ErrUnknownMethod
+	// ErrHandlerFailed means the handler panicked, or returned a reply larger
+	// than MaxReplySize; the server keeps serving other calls.
+	ErrHandlerFailed
+)
+
+// reply statuses
+const (
+	statusOK uint8 = iota
+	statusUnknownMethod
+	statusHandlerFailed
+)
+
+// maxInFlight is the most handlers that run at once for one connection;
+// further requests are not read from the connection until one finishes.
+const maxInFlight = 64
+
+// MaxReplySize is the largest reply a handler may return: what fits in one
+// net message after the reply header.
+const MaxReplySize = net.MaxMessageSize - 9
+
+// Handler computes the reply to a call from its arguments. The reply must be
+// at most MaxReplySize bytes; a larger one fails the call with
+// ErrHandlerFailed.
+type Handler func(args []byte) []byte
+
+// Server serves calls to a fixed set of handlers.
+type Server struct {
+	handlers map[uint64]Handler
+}
+
+// NewServer creates a server dispatching each method ID in handlers to its
+// handler.
+func NewServer(handlers map[uint64]Handler) *Server {
+	return &Server{handlers: handlers}
+}
+
+// Serve accepts connections from l and serves calls on them, each in its own
+// goroutine, until l is closed. It returns immediately.
+//
+// Up to maxInFlight (64) handlers run concurrently per connection. A handler
+// that panics fails only its call, with ErrHandlerFailed.
+func (s *Server) Serve(l net.Listener) {
+	go func() {
+		for {
+			c, err := l.TryAccept()
+			if err {
+				return
+			}
+			go s.serveConn(c)
+		}
+	}()
+}
+
+func (s *Server) serveConn(c net.Connection) {
+	defer c.Close()
+	slots := make(chan struct{}, maxInFlight)
+	for {
+		req, err := c.Receive()
+		if err {
+			return
+		}
+		if len(req) < 16 {
+			// malformed; the client is not following the protocol
+			return
+		}
+		slots <- struct{}{}
+		go func() {
+			defer func() { <-slots }()
+			s.handle(c, req)
+		}()
+	}
+}
+
+// run runs h on args, recovering a panic (or rejecting an oversized reply)
+// as statusHandlerFailed.
+func run(h Handler, args []byte) (reply []byte, status uint8) {
+	defer func() {
+		if recover() != nil {
+			reply, status = nil, statusHandlerFailed
+		}
+	}()
+	reply = h(args)
+	if uint64(len(reply)) > MaxReplySize {
+		return nil, statusHandlerFailed
+	}
+	return reply, statusOK
+}
+
+func (s *Server) handle(c net.Connection, req []byte) {
+	seq := binary.LittleEndian.Uint64(req)
+	id := binary.LittleEndian.Uint64(req[8:])
+	var reply []byte
+	status := statusUnknownMethod
+	if h, ok := s.handlers[id]; ok {
+		reply, status = run(h, req[16:])
+	}
+	msg := make([]byte, 9, 9+len(reply))
+	binary.LittleEndian.PutUint64(msg, seq)
+	msg[8] = status
+	c.Send(append(msg, reply...))
+}
+
+type callResult struct {
+	reply  []byte
+	status uint8
+}
+
+// Client issues calls over a single connection to a Server. It is safe for
+// concurrent use, and concurrent calls are pipelined over the connection.
+type Client struct {
+	conn net.Connection
+
+	m       sync.Mutex
+	nextSeq uint64
+	pending map[uint64]chan callResult
+	// failed is set once the connection has failed
+	failed bool
+}
+
+// NewClient connects to the server at addr. err is true if the connection
+// could not be established.
+func NewClient(addr string) (cl *Client, err bool) {
+	conn, err := net.Connect(addr)
+	if err {
+		return nil, true
+	}
+	cl = &Client{conn: conn, pending: make(map[uint64]chan callResult)}
+	go cl.receive()
+	return cl, false
+}
+
+// receive delivers replies to the waiting calls until the connection fails,
+// and then fails every pending call.
+func (cl *Client) receive() {
+	for {
+		msg, err := cl.conn.Receive()
+		if err || len(msg) < 9 {
+			break
+		}
+		seq := binary.LittleEndian.Uint64(msg)
+		cl.m.Lock()
+		ch, ok := cl.pending[seq]
+		delete(cl.pending, seq)
+		cl.m.Unlock()
+		if ok {
+			ch <- callResult{reply: msg[9:], status: msg[8]}
+		}
+		// otherwise the call timed out, or this is a duplicate
+	}
+	cl.m.Lock()
+	cl.failed = true
+	for seq, ch := range cl.pending {
+		close(ch)
+		delete(cl.pending, seq)
+	}
+	cl.m.Unlock()
+	cl.conn.Close()
+}
+
+// Call calls method id with args, waiting up to timeoutMs milliseconds for
+// the reply. err is ErrNone if reply is valid, and one of the other error
+// codes otherwise.
+func (cl *Client) Call(id uint64, args []byte, timeoutMs uint64) (reply []byte, err uint64) {
+	ch := make(chan callResult, 1)
+	cl.m.Lock()
+	if cl.failed {
+		cl.m.Unlock()
+		return nil, ErrDisconnect
+	}
+	seq := cl.nextSeq
+	cl.nextSeq++
+	cl.pending[seq] = ch
+	cl.m.Unlock()
+
+	req := make([]byte, 16, 16+len(args))
+	binary.LittleEndian.PutUint64(req, seq)
+	binary.LittleEndian.PutUint64(req[8:], id)
+	if cl.conn.Send(append(req, args...)) {
+		cl.conn.Close() // stops receive, which fails the pending calls
+		return nil, ErrDisconnect
+	}
+
+	timer := time.NewTimer(time.Duration(timeoutMs) * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case r, ok := <-ch:
+		if !ok {
+			return nil, ErrDisconnect
+		}
+		switch r.status {
+		case statusUnknownMethod:
+			return nil, ErrUnknownMethod
+		case statusHandlerFailed:
+			return nil, ErrHandlerFailed
+		}
+		return r.reply, ErrNone
+	case <-timer.C:
+		cl.m.Lock()
+		delete(cl.pending, seq)
+		cl.m.Unlock()
+		return nil, ErrTimeout
+	}
+}
+
+// Close closes the connection; pending and future calls fail with
+// ErrDisconnect.
+func (cl *Client) Close() {
+	cl.conn.Close()
+}
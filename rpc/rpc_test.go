@@ -0,0 +1,117 @@
+package rpc
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goose-lang/primitive/net"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	methodEcho uint64 = iota
+	methodSlow
+	methodPanic
+	methodHuge
+)
+
+func startServer(t *testing.T) string {
+	l := net.Listen("127.0.0.1:0")
+	t.Cleanup(l.Close)
+	NewServer(map[uint64]Handler{
+		methodEcho: func(args []byte) []byte { return args },
+		methodSlow: func(args []byte) []byte {
+			time.Sleep(200 * time.Millisecond)
+			return nil
+		},
+		methodPanic: func(args []byte) []byte { panic("handler bug") },
+		methodHuge:  func(args []byte) []byte { return make([]byte, MaxReplySize+1) },
+	}).Serve(l)
+	return l.Addr()
+}
+
+func TestCall(t *testing.T) {
+	assert := assert.New(t)
+	cl, err := NewClient(startServer(t))
+	assert.False(err)
+	defer cl.Close()
+
+	reply, e := cl.Call(methodEcho, []byte("hello"), 1000)
+	assert.Equal(ErrNone, e)
+	assert.Equal([]byte("hello"), reply)
+
+	_, e = cl.Call(42, nil, 1000)
+	assert.Equal(ErrUnknownMethod, e)
+}
+
+func TestCallConcurrent(t *testing.T) {
+	cl, _ := NewClient(startServer(t))
+	defer cl.Close()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(x byte) {
+			defer wg.Done()
+			reply, e := cl.Call(methodEcho, []byte{x}, 1000)
+			assert.Equal(t, ErrNone, e)
+			assert.Equal(t, []byte{x}, reply)
+		}(byte(i))
+	}
+	wg.Wait()
+}
+
+func TestCallTimeout(t *testing.T) {
+	cl, _ := NewClient(startServer(t))
+	defer cl.Close()
+	_, e := cl.Call(methodSlow, nil, 10)
+	assert.Equal(t, ErrTimeout, e)
+	// the late reply is discarded and the client remains usable
+	time.Sleep(300 * time.Millisecond)
+	reply, e := cl.Call(methodEcho, []byte("x"), 1000)
+	assert.Equal(t, ErrNone, e)
+	assert.Equal(t, []byte("x"), reply)
+}
+
+func TestCallDisconnect(t *testing.T) {
+	cl, _ := NewClient(startServer(t))
+	cl.Close()
+	_, e := cl.Call(methodEcho, nil, 1000)
+	assert.Equal(t, ErrDisconnect, e)
+}
+
+func TestCallHandlerPanic(t *testing.T) {
+	assert := assert.New(t)
+	cl, err := NewClient(startServer(t))
+	assert.False(err)
+	defer cl.Close()
+	_, e := cl.Call(methodPanic, nil, 1000)
+	assert.Equal(ErrHandlerFailed, e)
+	reply, e := cl.Call(methodEcho, []byte("still up"), 1000)
+	assert.Equal(ErrNone, e, "the server should survive a panicking handler")
+	assert.Equal([]byte("still up"), reply)
+}
+
+func TestCallManyInFlight(t *testing.T) {
+	cl, err := NewClient(startServer(t))
+	assert.False(t, err)
+	defer cl.Close()
+	// more slow calls than may run at once; the rest wait for a slot
+	var wg sync.WaitGroup
+	for i := 0; i < maxInFlight+8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, e := cl.Call(methodSlow, nil, 5000)
+			assert.Equal(t, ErrNone, e)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCallReplyTooLarge(t *testing.T) {
+	cl, _ := NewClient(startServer(t))
+	defer cl.Close()
+	_, e := cl.Call(methodHuge, nil, 5000)
+	assert.Equal(t, ErrHandlerFailed, e, "an oversized reply fails definitely, not by timeout")
+}
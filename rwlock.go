@@ -0,0 +1,59 @@
+package primitive
+
+import "sync"
+
+// RWLock is a reader-writer lock.
+//
+// In the model, any number of readers or a single writer may hold the lock at
+// a time. The Try variants are modeled as nondeterministically failing even if
+// the lock is available, so proofs can only rely on them succeeding when they
+// return true. The zero value is an unlocked RWLock.
+type RWLock struct {
+	m sync.RWMutex
+}
+
+// NewRWLock returns a new, unlocked RWLock.
+func NewRWLock() *RWLock {
+	return new(RWLock)
+}
+
+// RLock acquires the lock for reading.
+func (l *RWLock) RLock() {
+	l.m.RLock()
+}
+
+// RUnlock releases a read hold on the lock.
+func (l *RWLock) RUnlock() {
+	l.m.RUnlock()
+}
+
+// Lock acquires the lock for writing.
+func (l *RWLock) Lock() {
+	l.m.Lock()
+}
+
+// Unlock releases the write hold on the lock.
+func (l *RWLock) Unlock() {
+	l.m.Unlock()
+}
+
+// TryRLock attempts to acquire the lock for reading without blocking,
+// reporting whether it succeeded.
+func (l *RWLock) TryRLock() bool {
+	return l.m.TryRLock()
+}
+
+// TryLock attempts to acquire the lock for writing without blocking, reporting
+// whether it succeeded.
+func (l *RWLock) TryLock() bool {
+	return l.m.TryLock()
+}
+
+// TryLock attempts to acquire m without blocking, reporting whether it
+// succeeded.
+//
+// Modeled as nondeterministically failing even if m is free, so proofs only
+// learn that m is held when TryLock returns true.
+func TryLock(m *sync.Mutex) bool {
+	return m.TryLock()
+}
@@ -0,0 +1,66 @@
+package primitive
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRWLockTry(t *testing.T) {
+	assert := assert.New(t)
+	l := NewRWLock()
+
+	assert.True(l.TryRLock())
+	assert.True(l.TryRLock(), "readers share the lock")
+	assert.False(l.TryLock(), "writer excluded by readers")
+	l.RUnlock()
+	l.RUnlock()
+
+	assert.True(l.TryLock())
+	assert.False(l.TryRLock(), "reader excluded by writer")
+	assert.False(l.TryLock(), "writer excluded by writer")
+	l.Unlock()
+
+	l.RLock()
+	l.RUnlock()
+	l.Lock()
+	l.Unlock()
+}
+
+func TestRWLockConcurrent(t *testing.T) {
+	var l RWLock
+	var wg sync.WaitGroup
+	x := 0
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Lock()
+				x++
+				l.Unlock()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if l.TryRLock() {
+					_ = x
+					l.RUnlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 400, x)
+}
+
+func TestMutexTryLock(t *testing.T) {
+	var m sync.Mutex
+	assert.True(t, TryLock(&m))
+	assert.False(t, TryLock(&m))
+	m.Unlock()
+	assert.True(t, TryLock(&m))
+	m.Unlock()
+}
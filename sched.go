@@ -0,0 +1,181 @@
+package primitive
+
+import (
+	"fmt"
+	"sync"
+)
+
+var activeScheduler struct {
+	sync.Mutex
+	s *Scheduler
+}
+
+// SchedYield marks a point at which a test scheduler may switch to another
+// goroutine, for systematically exploring the interleavings of extracted
+// code. label identifies the point in the Scheduler's trace.
+//
+// Modeled as a no-op. In Go it also does nothing, unless a Scheduler is
+// running, in which case the calling goroutine (which must be one started by
+// the Scheduler) pauses until the Scheduler chooses it again.
+func SchedYield(label string) {
+	activeScheduler.Lock()
+	s := activeScheduler.s
+	activeScheduler.Unlock()
+	if s != nil {
+		s.yield(label)
+	}
+}
+
+// SchedStep is one scheduling decision of a Scheduler: the goroutine it ran
+// next and the yield point at which that goroutine had paused ("start" for
+// its first step).
+type SchedStep struct {
+	Thread uint64
+	Label  string
+}
+
+type schedThread struct {
+	id    uint64
+	label string
+	wake  chan struct{}
+}
+
+// Scheduler runs a set of goroutines one at a time, switching between them
+// only at SchedYield calls, so that the interleaving of a test is chosen by
+// the Scheduler and can be reproduced. The code between two yield points of a
+// goroutine runs without interruption by the other scheduled goroutines.
+//
+// Because only one scheduled goroutine runs at a time, a goroutine that blocks
+// (on a lock held by another scheduled goroutine paused at a yield point, for
+// example) deadlocks the run: the code under test must only wait for other
+// scheduled goroutines at yield points, such as in a loop that yields between
+// attempts to acquire.
+type Scheduler struct {
+	m        sync.Mutex
+	choose   func(n uint64) uint64
+	nextID   uint64
+	runnable []*schedThread
+	current  *schedThread
+	// stopped is signaled when the current goroutine yields or finishes.
+	stopped  chan struct{}
+	trace    []SchedStep
+	choices  []uint64
+	panicked bool
+	panicVal interface{}
+}
+
+// NewScheduler creates a scheduler that, at each step, runs the goroutine at
+// index choose(n) among the n runnable ones (listed in the order they became
+// runnable). choose must return an index less than n.
+//
+// Replaying the choices of an earlier run (see Choices) reproduces its
+// interleaving.
+func NewScheduler(choose func(n uint64) uint64) *Scheduler {
+	return &Scheduler{choose: choose, stopped: make(chan struct{})}
+}
+
+// NewRandomScheduler creates a scheduler that picks the next goroutine at
+// random, from a generator seeded with seed. Running a test under many seeds
+// samples many interleavings.
+func NewRandomScheduler(seed uint64) *Scheduler {
+	rng := NewRand(seed)
+	return NewScheduler(rng.Uint64n)
+}
+
+// Go adds f as a goroutine to be scheduled. It may be called before Run, or
+// from a scheduled goroutine to spawn another one.
+func (s *Scheduler) Go(f func()) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	t := &schedThread{id: s.nextID, label: "start", wake: make(chan struct{})}
+	s.nextID++
+	s.runnable = append(s.runnable, t)
+	go func() {
+		<-t.wake
+		defer func() {
+			if r := recover(); r != nil {
+				s.m.Lock()
+				s.panicked, s.panicVal = true, r
+				s.m.Unlock()
+			}
+			s.stopped <- struct{}{}
+		}()
+		f()
+	}()
+}
+
+// Run runs the scheduled goroutines to completion, making s the scheduler
+// for SchedYield meanwhile. If a scheduled goroutine panics, Run stops and
+// panics with the same value; the other goroutines are left paused.
+//
+// Only one Scheduler may run at a time.
+func (s *Scheduler) Run() {
+	activeScheduler.Lock()
+	if activeScheduler.s != nil {
+		activeScheduler.Unlock()
+		panic("another Scheduler is already running")
+	}
+	activeScheduler.s = s
+	activeScheduler.Unlock()
+	defer func() {
+		activeScheduler.Lock()
+		activeScheduler.s = nil
+		activeScheduler.Unlock()
+	}()
+
+	for {
+		s.m.Lock()
+		n := uint64(len(s.runnable))
+		if n == 0 {
+			s.m.Unlock()
+			return
+		}
+		i := s.choose(n)
+		if i >= n {
+			s.m.Unlock()
+			panic(fmt.Errorf("scheduler chose %d of %d goroutines", i, n))
+		}
+		t := s.runnable[i]
+		s.runnable = append(s.runnable[:i], s.runnable[i+1:]...)
+		s.current = t
+		s.choices = append(s.choices, i)
+		s.trace = append(s.trace, SchedStep{Thread: t.id, Label: t.label})
+		s.m.Unlock()
+
+		t.wake <- struct{}{}
+		<-s.stopped
+
+		s.m.Lock()
+		panicked, v := s.panicked, s.panicVal
+		s.m.Unlock()
+		if panicked {
+			panic(v)
+		}
+	}
+}
+
+// yield pauses the current goroutine at label until it is chosen again.
+func (s *Scheduler) yield(label string) {
+	s.m.Lock()
+	t := s.current
+	t.label = label
+	s.runnable = append(s.runnable, t)
+	s.m.Unlock()
+	s.stopped <- struct{}{}
+	<-t.wake
+}
+
+// Trace returns the steps the scheduler has taken, in order.
+func (s *Scheduler) Trace() []SchedStep {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return append([]SchedStep(nil), s.trace...)
+}
+
+// Choices returns the index chosen at each step, for replaying the run with
+// NewScheduler.
+func (s *Scheduler) Choices() []uint64 {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return append([]uint64(nil), s.choices...)
+}
@@ -0,0 +1,96 @@
+package primitive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedYieldUnscheduled(t *testing.T) {
+	SchedYield("nothing") // does not block without a running Scheduler
+}
+
+// racyIncrements runs two unsynchronized increments of a counter under s and
+// returns the final count.
+func racyIncrements(s *Scheduler) uint64 {
+	var counter uint64
+	for i := 0; i < 2; i++ {
+		s.Go(func() {
+			x := counter
+			SchedYield("read")
+			counter = x + 1
+		})
+	}
+	s.Run()
+	return counter
+}
+
+func TestSchedulerFirstRunnable(t *testing.T) {
+	s := NewScheduler(func(n uint64) uint64 { return 0 })
+	assert.Equal(t, uint64(1), racyIncrements(s), "round-robin loses an update")
+	assert.Equal(t, []SchedStep{
+		{Thread: 0, Label: "start"},
+		{Thread: 1, Label: "start"},
+		{Thread: 0, Label: "read"},
+		{Thread: 1, Label: "read"},
+	}, s.Trace())
+}
+
+func TestSchedulerFindsRace(t *testing.T) {
+	assert := assert.New(t)
+	outcomes := make(map[uint64]bool)
+	var lost []uint64
+	for seed := uint64(0); seed < 20; seed++ {
+		s := NewRandomScheduler(seed)
+		c := racyIncrements(s)
+		outcomes[c] = true
+		if c == 1 {
+			lost = s.Choices()
+		}
+	}
+	assert.Equal(map[uint64]bool{1: true, 2: true}, outcomes,
+		"random schedules should find both outcomes")
+
+	// replaying the choices reproduces the lost update
+	i := 0
+	replay := NewScheduler(func(n uint64) uint64 {
+		c := lost[i]
+		i++
+		return c
+	})
+	assert.Equal(uint64(1), racyIncrements(replay))
+}
+
+func TestSchedulerDeterministic(t *testing.T) {
+	s1 := NewRandomScheduler(7)
+	s2 := NewRandomScheduler(7)
+	racyIncrements(s1)
+	racyIncrements(s2)
+	assert.Equal(t, s1.Trace(), s2.Trace())
+}
+
+func TestSchedulerSpawn(t *testing.T) {
+	var order []string
+	s := NewScheduler(func(n uint64) uint64 { return 0 })
+	s.Go(func() {
+		s.Go(func() { order = append(order, "child") })
+		SchedYield("spawned")
+		order = append(order, "parent")
+	})
+	s.Run()
+	assert.Equal(t, []string{"child", "parent"}, order)
+}
+
+func TestSchedulerPanic(t *testing.T) {
+	s := NewScheduler(func(n uint64) uint64 { return 0 })
+	s.Go(func() {
+		SchedYield("before")
+		panic("boom")
+	})
+	assert.PanicsWithValue(t, "boom", s.Run)
+
+	// the failed run no longer holds the scheduler
+	s = NewScheduler(func(n uint64) uint64 { return 0 })
+	s.Go(func() {})
+	s.Run()
+}
@@ -0,0 +1,30 @@
+package primitive
+
+// LowerBound returns the index of the first element of s that is >= x, or
+// len(s) if there is none.
+//
+// Assumes s is sorted in increasing order; the result is unspecified
+// otherwise. Modeled as a pure function. Takes O(log len(s)) comparisons.
+func LowerBound(s []uint64, x uint64) uint64 {
+	lo, hi := uint64(0), uint64(len(s))
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if s[mid] < x {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// BinarySearchUint64 searches s for x. If found, it returns the index of the
+// first occurrence of x and true; if not, the index at which x would be
+// inserted to keep s sorted (LowerBound(s, x)) and false.
+//
+// Assumes s is sorted in increasing order; the result is unspecified
+// otherwise. Modeled as a pure function.
+func BinarySearchUint64(s []uint64, x uint64) (idx uint64, found bool) {
+	i := LowerBound(s, x)
+	return i, i < uint64(len(s)) && s[i] == x
+}
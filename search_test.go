@@ -0,0 +1,33 @@
+package primitive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLowerBound(t *testing.T) {
+	s := []uint64{1, 3, 3, 3, 7}
+	for _, tc := range []struct{ x, idx uint64 }{
+		{0, 0}, {1, 0}, {2, 1}, {3, 1}, {4, 4}, {7, 4}, {8, 5},
+	} {
+		assert.Equal(t, tc.idx, LowerBound(s, tc.x), "LowerBound(%d)", tc.x)
+	}
+	assert.Equal(t, uint64(0), LowerBound(nil, 5))
+}
+
+func TestBinarySearchUint64(t *testing.T) {
+	assert := assert.New(t)
+	s := []uint64{1, 3, 3, 7, ^uint64(0)}
+	idx, found := BinarySearchUint64(s, 3)
+	assert.True(found)
+	assert.Equal(uint64(1), idx, "the first occurrence is found")
+	idx, found = BinarySearchUint64(s, 5)
+	assert.False(found)
+	assert.Equal(uint64(3), idx)
+	idx, found = BinarySearchUint64(s, ^uint64(0))
+	assert.True(found)
+	assert.Equal(uint64(4), idx)
+	_, found = BinarySearchUint64(nil, 0)
+	assert.False(found)
+}
@@ -0,0 +1,61 @@
+package primitive
+
+import "sync"
+
+// Semaphore is a weighted counting semaphore guarding n units of some
+// resource.
+//
+// In the model it owns the resources that are not currently acquired, handing
+// k of them to each Acquire(k) and taking them back on Release(k). Waiters are
+// not served in FIFO order, so a large Acquire can be starved by a stream of
+// small ones.
+type Semaphore struct {
+	m        sync.Mutex
+	cond     *sync.Cond
+	capacity uint64
+	free     uint64
+}
+
+// NewSemaphore creates a semaphore with n units, all available.
+func NewSemaphore(n uint64) *Semaphore {
+	s := &Semaphore{capacity: n, free: n}
+	s.cond = sync.NewCond(&s.m)
+	return s
+}
+
+// Acquire blocks until k units are available and takes them.
+//
+// Assumes k is at most the semaphore's capacity (otherwise it would block
+// forever).
+func (s *Semaphore) Acquire(k uint64) {
+	Assume(k <= s.capacity)
+	s.m.Lock()
+	defer s.m.Unlock()
+	for s.free < k {
+		s.cond.Wait()
+	}
+	s.free -= k
+}
+
+// TryAcquire takes k units if they are available right now, reporting whether
+// it did.
+func (s *Semaphore) TryAcquire(k uint64) bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.free < k {
+		return false
+	}
+	s.free -= k
+	return true
+}
+
+// Release returns k units to the semaphore.
+//
+// Assumes the caller had acquired at least k units.
+func (s *Semaphore) Release(k uint64) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	Assume(k <= s.capacity-s.free)
+	s.free += k
+	s.cond.Broadcast()
+}
@@ -0,0 +1,51 @@
+package primitive
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSemaphoreTryAcquire(t *testing.T) {
+	assert := assert.New(t)
+	s := NewSemaphore(3)
+	assert.True(s.TryAcquire(2))
+	assert.False(s.TryAcquire(2))
+	assert.True(s.TryAcquire(1))
+	assert.False(s.TryAcquire(1))
+	s.Release(3)
+	assert.True(s.TryAcquire(3))
+	s.Release(3)
+	assert.Panics(func() { s.Release(1) }, "over-release")
+}
+
+func TestSemaphoreBoundsConcurrency(t *testing.T) {
+	s := NewSemaphore(4)
+	var active, maxActive atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Acquire(2)
+			n := active.Add(1)
+			for {
+				m := maxActive.Load()
+				if n <= m || maxActive.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			active.Add(-1)
+			s.Release(2)
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, maxActive.Load(), int64(2))
+	assert.True(t, s.TryAcquire(4), "all units returned")
+}
+
+func TestSemaphoreAcquireTooLarge(t *testing.T) {
+	assert.Panics(t, func() { NewSemaphore(2).Acquire(3) })
+}
@@ -0,0 +1,67 @@
+package primitive
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+var shutdownHandlers struct {
+	sync.Mutex
+	handlers []func()
+	// installed is set once the signal handler is running.
+	installed bool
+}
+
+// exitOnSignal finishes a signal-triggered shutdown. It is a variable so tests
+// can observe the shutdown without exiting.
+var exitOnSignal = func(sig os.Signal) {
+	code := uint64(1)
+	if s, ok := sig.(syscall.Signal); ok {
+		code = 128 + uint64(s)
+	}
+	ExitClean(code)
+}
+
+// OnSignalShutdown registers f to run when the process receives SIGINT or
+// SIGTERM, so a server can stop accepting work and finish in-progress
+// writes instead of dying mid-operation.
+//
+// On the first such signal, the registered functions run one at a time in
+// registration order, followed by the exit hooks (see ExitClean), and the
+// process then exits with status 128 plus the signal number. A second signal
+// during shutdown exits immediately.
+//
+// Modeled as forking a thread that runs f at a nondeterministically chosen
+// point (or never); proofs must therefore show f is safe to run concurrently
+// with the rest of the program.
+func OnSignalShutdown(f func()) {
+	shutdownHandlers.Lock()
+	defer shutdownHandlers.Unlock()
+	shutdownHandlers.handlers = append(shutdownHandlers.handlers, f)
+	if shutdownHandlers.installed {
+		return
+	}
+	shutdownHandlers.installed = true
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go handleShutdownSignals(sigs)
+}
+
+// handleShutdownSignals carries out the shutdown for the first signal
+// received on sigs, exiting immediately on a second one.
+func handleShutdownSignals(sigs <-chan os.Signal) {
+	sig := <-sigs
+	go func() {
+		<-sigs
+		os.Exit(1)
+	}()
+	shutdownHandlers.Lock()
+	handlers := shutdownHandlers.handlers
+	shutdownHandlers.Unlock()
+	for _, h := range handlers {
+		runExitHook(h)
+	}
+	exitOnSignal(sig)
+}
@@ -0,0 +1,39 @@
+package primitive
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnSignalShutdown(t *testing.T) {
+	exited := make(chan os.Signal, 1)
+	origExit := exitOnSignal
+	exitOnSignal = func(sig os.Signal) { exited <- sig }
+	defer func() { exitOnSignal = origExit }()
+
+	var order []int
+	shutdownHandlers.Lock()
+	origHandlers := shutdownHandlers.handlers
+	shutdownHandlers.handlers = []func(){
+		func() { order = append(order, 1) },
+		func() { panic("handler failure") },
+		func() { order = append(order, 3) },
+	}
+	shutdownHandlers.Unlock()
+	defer func() {
+		shutdownHandlers.Lock()
+		shutdownHandlers.handlers = origHandlers
+		shutdownHandlers.Unlock()
+	}()
+
+	// drive the handler with a fake signal rather than a real one, which
+	// would be handled only once per process
+	sigs := make(chan os.Signal, 1)
+	sigs <- syscall.SIGTERM
+	handleShutdownSignals(sigs)
+	assert.Equal(t, os.Signal(syscall.SIGTERM), <-exited)
+	assert.Equal(t, []int{1, 3}, order, "handlers run in order, and a panic does not stop the rest")
+}
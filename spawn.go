@@ -0,0 +1,25 @@
+package primitive
+
+// JoinHandle is the result of a spawned goroutine.
+type JoinHandle struct {
+	result *Promise[uint64]
+}
+
+// Spawn runs f in a new goroutine and returns a handle for collecting its
+// result.
+//
+// Modeled as a fork whose child resolves a one-shot result cell (a Promise)
+// with f's return value.
+func Spawn(f func() uint64) *JoinHandle {
+	h := &JoinHandle{result: NewPromise[uint64]()}
+	go func() {
+		h.result.Resolve(f())
+	}()
+	return h
+}
+
+// Join blocks until the spawned goroutine finishes and returns f's result.
+// Join may be called any number of times, from any goroutine.
+func (h *JoinHandle) Join() uint64 {
+	return h.result.Wait()
+}
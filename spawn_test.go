@@ -0,0 +1,20 @@
+package primitive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpawnJoin(t *testing.T) {
+	var hs []*JoinHandle
+	for i := uint64(0); i < 10; i++ {
+		hs = append(hs, Spawn(func() uint64 { return i * i }))
+	}
+	var sum uint64
+	for _, h := range hs {
+		sum += h.Join()
+	}
+	assert.Equal(t, uint64(285), sum)
+	assert.Equal(t, uint64(81), hs[9].Join(), "join is repeatable")
+}
@@ -0,0 +1,40 @@
+package primitive
+
+import "unsafe"
+
+// StringToBytes returns a new slice holding the bytes of s. The caller owns
+// the slice and may modify it.
+//
+// Modeled as allocating a fresh slice with the contents of s.
+func StringToBytes(s string) []byte {
+	return []byte(s)
+}
+
+// BytesToString returns a string holding a copy of b. Later changes to b do
+// not affect the string.
+//
+// Modeled as a pure function of b's current contents.
+func BytesToString(b []byte) string {
+	return string(b)
+}
+
+// StringToBytesNoCopy returns a slice that shares the memory of s, without
+// copying.
+//
+// Strings are immutable, so the caller must never modify the returned slice
+// (doing so is undefined behavior in Go and cannot be detected at runtime).
+// Modeled as StringToBytes, with the caller assuming it does not write to
+// the result.
+func StringToBytesNoCopy(s string) []byte {
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
+
+// BytesToStringNoCopy returns a string that shares the memory of b, without
+// copying.
+//
+// The caller must not modify b for as long as the string is in use; the
+// string would otherwise change with it. Modeled as BytesToString, with the
+// caller assuming b is not written to afterward.
+func BytesToStringNoCopy(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
@@ -0,0 +1,24 @@
+package primitive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringBytesCopy(t *testing.T) {
+	assert := assert.New(t)
+	b := StringToBytes("key")
+	assert.Equal([]byte("key"), b)
+	s := BytesToString(b)
+	b[0] = 'K'
+	assert.Equal("key", s, "the string should not share b's memory")
+}
+
+func TestStringBytesNoCopy(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal([]byte("key"), StringToBytesNoCopy("key"))
+	assert.Empty(StringToBytesNoCopy(""))
+	assert.Equal("key", BytesToStringNoCopy([]byte("key")))
+	assert.Equal("", BytesToStringNoCopy(nil))
+}
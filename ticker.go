@@ -0,0 +1,56 @@
+package primitive
+
+import (
+	"sync"
+	"time"
+)
+
+// Ticker delivers ticks at a regular interval, for periodic background work
+// such as heartbeats and flushes. Ticks are scheduled against the start
+// time rather than the previous tick, so the period does not drift; ticks
+// that nobody is waiting for are dropped rather than queued.
+//
+// In the model, ticks are a nondeterministic but fair event source: Wait
+// returns at some point, so a loop around it makes progress, but proofs
+// must not rely on the interval.
+type Ticker struct {
+	t    *time.Ticker
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewTicker creates a ticker whose first tick is intervalNs nanoseconds from
+// now. Assumes intervalNs > 0.
+func NewTicker(intervalNs uint64) *Ticker {
+	Assume(intervalNs > 0)
+	return &Ticker{
+		t:    time.NewTicker(nsDuration(intervalNs)),
+		stop: make(chan struct{}),
+	}
+}
+
+// Wait blocks until the next tick, returning true, or until the ticker is
+// stopped, returning false.
+func (t *Ticker) Wait() bool {
+	// check stop first, since a tick may already be buffered
+	select {
+	case <-t.stop:
+		return false
+	default:
+	}
+	select {
+	case <-t.t.C:
+		return true
+	case <-t.stop:
+		return false
+	}
+}
+
+// Stop stops the ticker: pending and future calls to Wait return false.
+// Stopping a stopped ticker does nothing.
+func (t *Ticker) Stop() {
+	t.once.Do(func() {
+		t.t.Stop()
+		close(t.stop)
+	})
+}
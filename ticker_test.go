@@ -0,0 +1,38 @@
+package primitive
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTicker(t *testing.T) {
+	tk := NewTicker(uint64(time.Millisecond))
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		assert.True(t, tk.Wait())
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 5*time.Millisecond)
+	tk.Stop()
+	tk.Stop()
+	assert.False(t, tk.Wait())
+}
+
+func TestTickerStopWakesWaiter(t *testing.T) {
+	tk := NewTicker(uint64(time.Hour))
+	done := make(chan bool)
+	go func() { done <- tk.Wait() }()
+	tk.Stop()
+	assert.False(t, <-done)
+}
+
+func TestTickerHugeInterval(t *testing.T) {
+	tk := NewTicker(math.MaxUint64)
+	done := make(chan bool, 1)
+	go func() { done <- tk.Wait() }()
+	time.Sleep(10 * time.Millisecond)
+	tk.Stop()
+	assert.False(t, <-done, "a huge interval should not wrap around")
+}
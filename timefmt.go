@@ -0,0 +1,43 @@
+package primitive
+
+import (
+	"math"
+	"time"
+)
+
+// TimeToRFC3339 formats ns, a time in nanoseconds since the Unix epoch as
+// returned by TimeNow, as an RFC 3339 timestamp in UTC, such as
+// "2024-03-01T12:30:00.5Z". The fractional seconds are omitted when zero and
+// otherwise have no trailing zeros.
+//
+// Assumed to be pure and injective in the Coq model.
+func TimeToRFC3339(ns uint64) string {
+	t := time.Unix(int64(ns/1e9), int64(ns%1e9)).UTC()
+	return t.Format(time.RFC3339Nano)
+}
+
+// RFC3339ToTime parses an RFC 3339 timestamp, with any UTC offset and with
+// or without fractional seconds, to nanoseconds since the Unix epoch. It
+// returns false if s is not a valid timestamp or is outside the range of a
+// uint64 (before 1970 or after 2554).
+//
+// A partial inverse of TimeToRFC3339: RFC3339ToTime(TimeToRFC3339(ns)) = (ns,
+// true). The converse holds only for the canonical strings TimeToRFC3339
+// produces, since one time has many representations.
+//
+// Assumed to be pure in the Coq model.
+func RFC3339ToTime(s string) (uint64, bool) {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return 0, false
+	}
+	sec, nsec := t.Unix(), uint64(t.Nanosecond())
+	if sec < 0 {
+		return 0, false
+	}
+	const maxSec, maxNsec uint64 = math.MaxUint64 / 1_000_000_000, math.MaxUint64 % 1_000_000_000
+	if uint64(sec) > maxSec || (uint64(sec) == maxSec && nsec > maxNsec) {
+		return 0, false
+	}
+	return uint64(sec)*1e9 + nsec, true
+}
@@ -0,0 +1,42 @@
+package primitive
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeToRFC3339(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("1970-01-01T00:00:00Z", TimeToRFC3339(0))
+	assert.Equal("2024-03-01T12:30:00.5Z", TimeToRFC3339(1709296200_500000000))
+	assert.Equal("1970-01-01T00:00:00.000000001Z", TimeToRFC3339(1))
+	assert.Equal("2554-07-21T23:34:33.709551615Z", TimeToRFC3339(math.MaxUint64))
+}
+
+func TestRFC3339ToTime(t *testing.T) {
+	assert := assert.New(t)
+	for _, ns := range []uint64{0, 1, 1709296200_500000000, TimeNow(), math.MaxUint64} {
+		got, ok := RFC3339ToTime(TimeToRFC3339(ns))
+		assert.True(ok)
+		assert.Equal(ns, got, "round trip of %d", ns)
+	}
+
+	ns, ok := RFC3339ToTime("2024-03-01T14:30:00.50+02:00")
+	assert.True(ok, "offsets and trailing zeros are accepted")
+	assert.Equal(uint64(1709296200_500000000), ns)
+
+	for _, s := range []string{
+		"",
+		"2024-03-01",
+		"2024-03-01 12:30:00Z",
+		"2024-02-30T00:00:00Z",
+		"1969-12-31T23:59:59Z",
+		"2554-07-21T23:34:33.709551616Z",
+		"3000-01-01T00:00:00Z",
+	} {
+		_, ok := RFC3339ToTime(s)
+		assert.False(ok, "%q", s)
+	}
+}
@@ -0,0 +1,83 @@
+package primitive
+
+import (
+	"sync"
+	"time"
+)
+
+// Timer is a one-shot timer that expires after a delay.
+//
+// In the model, a running timer may expire at any point (the delay only
+// guides the Go implementation), so protocols should rely on expiry for
+// liveness, not for safety. An expired or stopped timer stays that way until
+// Reset.
+type Timer struct {
+	m       sync.Mutex
+	t       *time.Timer
+	expired bool
+	stopped bool
+	// gen distinguishes the current run of the timer from earlier ones, so a
+	// stale callback can't expire a timer that has since been Reset
+	gen uint64
+}
+
+// NewTimer creates a timer that expires after ns nanoseconds.
+func NewTimer(ns uint64) *Timer {
+	t := &Timer{}
+	t.Reset(ns)
+	return t
+}
+
+// stop reports whether the timer was running. A callback that has fired but
+// not yet acquired t.m is stale once gen changes, so this call prevents its
+// expiry even though t.t.Stop fails.
+func (t *Timer) stop() bool {
+	running := !t.expired && !t.stopped
+	t.gen++
+	t.t.Stop()
+	t.stopped = true
+	return running
+}
+
+// Reset restarts the timer to expire ns nanoseconds from now, whether or not
+// it was running, expired, or stopped.
+func (t *Timer) Reset(ns uint64) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	if t.t != nil {
+		t.stop()
+	}
+	t.expired = false
+	t.stopped = false
+	gen := t.gen
+	t.t = time.AfterFunc(nsDuration(ns), func() {
+		t.m.Lock()
+		defer t.m.Unlock()
+		if t.gen == gen {
+			t.expired = true
+		}
+	})
+}
+
+// Stop prevents the timer from expiring, returning true if it was running
+// (neither expired nor already stopped).
+func (t *Timer) Stop() bool {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return t.stop()
+}
+
+// Expired reports whether the timer has expired since it was last started.
+func (t *Timer) Expired() bool {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return t.expired
+}
+
+// RunAfter runs f on a new goroutine after ns nanoseconds, without blocking
+// the caller.
+//
+// Modeled as forking a thread that runs f after a nondeterministic delay.
+func RunAfter(ns uint64, f func()) {
+	time.AfterFunc(nsDuration(ns), f)
+}
@@ -0,0 +1,76 @@
+package primitive
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func waitExpired(t *Timer) bool {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if t.Expired() {
+			return true
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return false
+}
+
+func TestTimerExpires(t *testing.T) {
+	timer := NewTimer(uint64(time.Millisecond))
+	assert.True(t, waitExpired(timer))
+	assert.False(t, timer.Stop(), "already expired")
+}
+
+func TestTimerStop(t *testing.T) {
+	timer := NewTimer(uint64(time.Hour))
+	assert.False(t, timer.Expired())
+	assert.True(t, timer.Stop())
+	assert.False(t, timer.Stop(), "already stopped")
+	assert.False(t, timer.Expired())
+}
+
+func TestTimerStopFiredCallback(t *testing.T) {
+	timer := NewTimer(uint64(time.Millisecond))
+	timer.m.Lock()
+	// let the callback fire and block on the lock
+	time.Sleep(20 * time.Millisecond)
+	stopped := timer.stop()
+	timer.m.Unlock()
+	assert.True(t, stopped, "Stop prevented the pending expiry")
+	time.Sleep(5 * time.Millisecond)
+	assert.False(t, timer.Expired())
+}
+
+func TestTimerHugeDelay(t *testing.T) {
+	timer := NewTimer(math.MaxUint64)
+	var ran atomic.Bool
+	RunAfter(math.MaxUint64, func() { ran.Store(true) })
+	time.Sleep(10 * time.Millisecond)
+	assert.False(t, timer.Expired(), "a huge delay should not wrap around")
+	assert.False(t, ran.Load())
+	assert.True(t, timer.Stop())
+}
+
+func TestTimerReset(t *testing.T) {
+	timer := NewTimer(uint64(time.Millisecond))
+	assert.True(t, waitExpired(timer))
+	timer.Reset(uint64(time.Hour))
+	assert.False(t, timer.Expired(), "Reset clears expiry")
+	timer.Reset(uint64(time.Millisecond))
+	assert.True(t, waitExpired(timer))
+	timer.Stop()
+}
+
+func TestRunAfter(t *testing.T) {
+	start := time.Now()
+	done := make(chan time.Duration)
+	RunAfter(uint64(2*time.Millisecond), func() {
+		done <- time.Since(start)
+	})
+	assert.GreaterOrEqual(t, <-done, 2*time.Millisecond)
+}
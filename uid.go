@@ -0,0 +1,39 @@
+package primitive
+
+import "sync/atomic"
+
+var uidCounter atomic.Uint64
+
+// GenUID returns an identifier that is unique within the process: every call
+// returns a value greater than any returned before it, starting from 1 (so 0
+// can mean "no id").
+//
+// Modeled as returning a fresh value, distinct from every previously
+// returned one. It is safe for concurrent use.
+func GenUID() uint64 {
+	return uidCounter.Add(1)
+}
+
+// IDGen generates string identifiers made of a fixed prefix and an
+// increasing counter, such as "txn-1", "txn-2", and so on.
+//
+// Identifiers from one IDGen are all distinct; two IDGens with the same
+// prefix produce the same identifiers. It is safe for concurrent use.
+type IDGen struct {
+	prefix string
+	next   atomic.Uint64
+}
+
+// NewIDGen creates a generator of identifiers starting with prefix.
+func NewIDGen(prefix string) *IDGen {
+	return &IDGen{prefix: prefix}
+}
+
+// Next returns the next identifier: the prefix followed by the decimal
+// count of identifiers generated so far, starting at 1.
+//
+// Modeled as returning a fresh value, distinct from every identifier the
+// generator returned before.
+func (g *IDGen) Next() string {
+	return g.prefix + UInt64ToString(g.next.Add(1))
+}
@@ -0,0 +1,38 @@
+package primitive
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenUID(t *testing.T) {
+	var m sync.Mutex
+	seen := make(map[uint64]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			last := uint64(0)
+			for j := 0; j < 100; j++ {
+				id := GenUID()
+				assert.Greater(t, id, last, "ids should increase")
+				last = id
+				m.Lock()
+				assert.False(t, seen[id], "ids should be unique")
+				seen[id] = true
+				m.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestIDGen(t *testing.T) {
+	g := NewIDGen("txn-")
+	assert.Equal(t, "txn-1", g.Next())
+	assert.Equal(t, "txn-2", g.Next())
+	assert.Equal(t, "req-1", NewIDGen("req-").Next())
+}
@@ -0,0 +1,53 @@
+package primitive
+
+import "sync"
+
+// WaitGroup waits for a collection of tasks to finish, for fork-join
+// parallelism.
+//
+// In the model the counter tracks outstanding tasks: Add(n) hands out n
+// obligations, each Done discharges one, and Wait returns once all have been
+// discharged.
+type WaitGroup struct {
+	m     sync.Mutex
+	cond  *sync.Cond
+	count uint64
+}
+
+// NewWaitGroup creates a wait group with no outstanding tasks.
+func NewWaitGroup() *WaitGroup {
+	wg := &WaitGroup{}
+	wg.cond = sync.NewCond(&wg.m)
+	return wg
+}
+
+// Add registers n more outstanding tasks.
+//
+// Assumes the count does not overflow.
+func (wg *WaitGroup) Add(n uint64) {
+	wg.m.Lock()
+	defer wg.m.Unlock()
+	wg.count = SumAssumeNoOverflow(wg.count, n)
+}
+
+// Done marks one outstanding task as finished.
+//
+// Assumes there is an outstanding task.
+func (wg *WaitGroup) Done() {
+	wg.m.Lock()
+	defer wg.m.Unlock()
+	Assume(wg.count > 0)
+	wg.count--
+	if wg.count == 0 {
+		wg.cond.Broadcast()
+	}
+}
+
+// Wait blocks until there are no outstanding tasks.
+func (wg *WaitGroup) Wait() {
+	wg.m.Lock()
+	defer wg.m.Unlock()
+	for wg.count > 0 {
+		wg.cond.Wait()
+	}
+}
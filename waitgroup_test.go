@@ -0,0 +1,27 @@
+package primitive
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitGroup(t *testing.T) {
+	wg := NewWaitGroup()
+	wg.Wait() // no tasks: returns immediately
+	var n atomic.Uint64
+	wg.Add(10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			n.Add(1)
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, uint64(10), n.Load())
+}
+
+func TestWaitGroupDoneUnderflow(t *testing.T) {
+	assert.Panics(t, func() { NewWaitGroup().Done() })
+}
@@ -0,0 +1,113 @@
+package primitive
+
+import (
+	"math"
+	"math/bits"
+	"strconv"
+)
+
+// Word size
+//
+// GooseLang's machine words are 64 bits by default, and the primitives in
+// this package operate on uint64. For programs verified against GooseLang's
+// 32-bit word mode, the functions below are the uint32 counterparts of the
+// 64-bit-only primitives, with the same models at 32 bits: each is named
+// after its 64-bit form with 64 replaced by 32 (or 32 appended), and
+// overflow is checked against math.MaxUint32 rather than math.MaxUint64.
+// Encoding primitives (UInt32Get, UInt32Put and their variants) and
+// narrowing (ToUInt32) are defined alongside their 64-bit forms.
+
+// RandomUint32 returns a random uint32 using the global seed.
+func RandomUint32() uint32 {
+	return uint32(RandomUint64() >> 32)
+}
+
+// RandomUint32n returns a uniformly random value in [0, n) using the global
+// seed.
+//
+// Modeled as a nondeterministic choice of a value less than n. Assumes n > 0.
+func RandomUint32n(n uint32) uint32 {
+	return uint32(RandomUint64n(uint64(n)))
+}
+
+// UInt32ToString formats a number as a string.
+//
+// Assumed to be pure and injective in the Coq model.
+func UInt32ToString(x uint32) string {
+	return strconv.FormatUint(uint64(x), 10)
+}
+
+// UInt32FromString parses a decimal number, the inverse of UInt32ToString,
+// accepting exactly the canonical strings it produces (see UInt64FromString)
+// and returning false otherwise, including on overflow.
+//
+// Assumed to be pure in the Coq model.
+func UInt32FromString(s string) (uint32, bool) {
+	x, ok := UInt64FromString(s)
+	if !ok || x > math.MaxUint32 {
+		return 0, false
+	}
+	return uint32(x), true
+}
+
+// SumNoOverflow32 returns true if x + y does not overflow a uint32.
+func SumNoOverflow32(x uint32, y uint32) bool {
+	return x+y >= x
+}
+
+// SumAssumeNoOverflow32 returns x + y, assuming that the addition does not
+// overflow.
+func SumAssumeNoOverflow32(x uint32, y uint32) uint32 {
+	Assume(SumNoOverflow32(x, y))
+	return x + y
+}
+
+// MulNoOverflow32 returns true if x * y does not overflow a uint32.
+func MulNoOverflow32(x uint32, y uint32) bool {
+	hi, _ := bits.Mul32(x, y)
+	return hi == 0
+}
+
+// MulAssumeNoOverflow32 returns x * y, assuming that the multiplication does
+// not overflow.
+func MulAssumeNoOverflow32(x uint32, y uint32) uint32 {
+	Assume(MulNoOverflow32(x, y))
+	return x * y
+}
+
+// SubNoUnderflow32 returns true if y <= x.
+func SubNoUnderflow32(x uint32, y uint32) bool {
+	return y <= x
+}
+
+// SubAssumeNoUnderflow32 returns x - y, assuming that y <= x.
+func SubAssumeNoUnderflow32(x uint32, y uint32) uint32 {
+	Assume(SubNoUnderflow32(x, y))
+	return x - y
+}
+
+// SatAdd32 returns x + y, or math.MaxUint32 if the sum overflows.
+//
+// Modeled as a pure function: min(x + y, 2^32 - 1).
+func SatAdd32(x uint32, y uint32) uint32 {
+	if !SumNoOverflow32(x, y) {
+		return math.MaxUint32
+	}
+	return x + y
+}
+
+// MinUint32 returns the smaller of x and y.
+func MinUint32(x uint32, y uint32) uint32 {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+// MaxUint32 returns the larger of x and y.
+func MaxUint32(x uint32, y uint32) uint32 {
+	if x > y {
+		return x
+	}
+	return y
+}
@@ -0,0 +1,42 @@
+package primitive
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomUint32n(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		assert.Less(t, RandomUint32n(10), uint32(10))
+	}
+	assert.Panics(t, func() { RandomUint32n(0) })
+}
+
+func TestUInt32String(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("4294967295", UInt32ToString(math.MaxUint32))
+	x, ok := UInt32FromString("4294967295")
+	assert.True(ok)
+	assert.Equal(uint32(math.MaxUint32), x)
+	_, ok = UInt32FromString("4294967296")
+	assert.False(ok, "overflow should be rejected")
+	_, ok = UInt32FromString("01")
+	assert.False(ok)
+}
+
+func TestOverflow32(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(SumNoOverflow32(math.MaxUint32-5, 5))
+	assert.False(SumNoOverflow32(math.MaxUint32-5, 6))
+	assert.Panics(func() { SumAssumeNoOverflow32(math.MaxUint32, 1) })
+	assert.True(MulNoOverflow32(1<<16, 1<<15))
+	assert.False(MulNoOverflow32(1<<16, 1<<16))
+	assert.Panics(func() { MulAssumeNoOverflow32(1<<16, 1<<16) })
+	assert.Equal(uint32(2), SubAssumeNoUnderflow32(5, 3))
+	assert.Panics(func() { SubAssumeNoUnderflow32(3, 5) })
+	assert.Equal(uint32(math.MaxUint32), SatAdd32(math.MaxUint32, 1))
+	assert.Equal(uint32(3), MinUint32(3, 5))
+	assert.Equal(uint32(5), MaxUint32(3, 5))
+}